@@ -0,0 +1,471 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-logr/logr"
+	cadvisorapi "github.com/google/cadvisor/info/v1"
+	cobra "github.com/spf13/cobra"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/simulation"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/resourcemanager/settings"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetype"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type simulateCmd struct {
+	topology       *topology.Topology
+	reservedCPUs   []int
+	reservedMemory uint64
+	simpleOptions  cpumanager.SimplePolicyOptions
+	staticOptions  []cpumanager.StaticPolicyOptions
+	policies       []string
+
+	events        []simulation.Event
+	instanceTypes []simulation.InstanceType
+
+	output     string
+	showEvents bool
+}
+
+func buildSimulateCmd() *cobra.Command {
+	c := &simulateCmd{}
+
+	cmd := cobra.Command{
+		Use:           "simulate",
+		Aliases:       []string{"s"},
+		Short:         "Replay VM allocations with the simple and static policies and report the node capacity",
+		Args:          cobra.ExactArgs(0),
+		PreRunE:       c.parseArgs,
+		RunE:          c.runSimulate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("node-settings", "n", "", "path to the node settings JSON file")
+	flags.StringP("machine-info", "", "", "path to the cadvisor MachineInfo JSON file")
+	flags.StringP("events", "e", "", "path to the JSON or YAML list of allocate and release events")
+	flags.StringP("instance-types", "i", "", "path to the instance types JSON file, generated from --cpus and --memfactor if empty")
+	flags.StringP("cpus", "c", "1,2,4,8,16", "comma-separated list of vCPU counts of the generated instance types")
+	flags.StringP("memfactor", "m", "2,3,4,8", "comma-separated list of memory multipliers per vCPU of the generated instance types")
+
+	flags.StringP("reserved-cpus", "", "", "reserved host CPUs, e.g. 0,4 (defaults to the node settings)")
+	flags.StringP("reserved-memory", "", "", "reserved host memory, e.g. 4Gi (defaults to the node settings)")
+	flags.Float64P("cpu-overcommit", "", 0, "CPU overcommit ratio of the simple policy (defaults to the node settings)")
+	flags.Float64P("memory-overcommit", "", 0, "memory overcommit ratio of the simple policy (defaults to the node settings)")
+
+	flags.StringP("policies", "p", "simple,static", "comma-separated list of policies to run (simple, static)")
+	flags.BoolP("full-pcpus-only", "", false, "static policy: allocate only full physical cores")
+	flags.BoolP("distribute-across-numa", "", false, "static policy: distribute CPUs evenly across NUMA nodes")
+	flags.BoolP("distribute-across-cores", "", false, "static policy: spread CPUs across physical cores")
+	flags.BoolP("align-by-uncore", "", true, "static policy: prefer CPUs sharing the uncore cache")
+	flags.BoolP("all-options", "a", false, "run the static policy with every combination of its options")
+
+	flags.StringP("output", "o", outputText, "output format (text, json)")
+	flags.BoolP("show-events", "", false, "print the result of each event")
+
+	return &cmd
+}
+
+func (c *simulateCmd) parseArgs(cmd *cobra.Command, _ []string) (err error) {
+	flags := cmd.Flags()
+
+	nodeSettingsFile, err := flags.GetString("node-settings")
+	if err != nil {
+		return err
+	}
+
+	machineInfoFile, err := flags.GetString("machine-info")
+	if err != nil {
+		return err
+	}
+
+	nodeSettings := &settings.NodeSettings{}
+
+	switch {
+	case nodeSettingsFile != "" && machineInfoFile != "":
+		return fmt.Errorf("flags --node-settings and --machine-info are mutually exclusive")
+	case nodeSettingsFile != "":
+		if err = readFile(nodeSettingsFile, nodeSettings); err != nil {
+			return err
+		}
+
+		if c.topology, err = topology.DiscoverFromSettings(nodeSettings); err != nil {
+			return err
+		}
+	case machineInfoFile != "":
+		machineInfo := &cadvisorapi.MachineInfo{}
+		if err = readFile(machineInfoFile, machineInfo); err != nil {
+			return err
+		}
+
+		if c.topology, err = topology.DiscoverCadvisor(logr.Discard(), machineInfo); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("one of the flags --node-settings or --machine-info is required")
+	}
+
+	c.reservedCPUs = nodeSettings.ReservedCPUs
+	if reservedCPUs, _ := flags.GetString("reserved-cpus"); reservedCPUs != "" { //nolint:errcheck
+		cpus, err := cpuset.Parse(reservedCPUs)
+		if err != nil {
+			return fmt.Errorf("invalid reserved CPUs %q: %w", reservedCPUs, err)
+		}
+
+		c.reservedCPUs = cpus.List()
+	}
+
+	c.reservedMemory = nodeSettings.ReservedMemory
+	if reservedMemory, _ := flags.GetString("reserved-memory"); reservedMemory != "" { //nolint:errcheck
+		q, err := resource.ParseQuantity(reservedMemory)
+		if err != nil {
+			return fmt.Errorf("invalid reserved memory %q: %w", reservedMemory, err)
+		}
+
+		c.reservedMemory = uint64(q.Value())
+	}
+
+	c.simpleOptions = cpumanager.SimplePolicyOptions{
+		CPUOvercommitRatio:    nodeSettings.CPUOvercommitRatio,
+		MemoryOvercommitRatio: nodeSettings.MemoryOvercommitRatio,
+	}
+
+	if flags.Changed("cpu-overcommit") {
+		c.simpleOptions.CPUOvercommitRatio, _ = flags.GetFloat64("cpu-overcommit") //nolint:errcheck
+	}
+
+	if flags.Changed("memory-overcommit") {
+		c.simpleOptions.MemoryOvercommitRatio, _ = flags.GetFloat64("memory-overcommit") //nolint:errcheck
+	}
+
+	policies, err := flags.GetString("policies")
+	if err != nil {
+		return err
+	}
+
+	for p := range strings.SplitSeq(policies, ",") {
+		switch p = strings.TrimSpace(p); p {
+		case string(cpumanager.PolicySimple), string(cpumanager.PolicyStatic):
+			c.policies = append(c.policies, p)
+		case "":
+		default:
+			return fmt.Errorf("unknown policy %q", p)
+		}
+	}
+
+	if err = c.parseStaticOptions(cmd); err != nil {
+		return err
+	}
+
+	if err = c.parseInstanceTypes(cmd); err != nil {
+		return err
+	}
+
+	eventsFile, err := flags.GetString("events")
+	if err != nil {
+		return err
+	}
+
+	c.events = []simulation.Event{}
+	if eventsFile != "" {
+		if err = readFile(eventsFile, &c.events); err != nil {
+			return err
+		}
+	}
+
+	if c.output, err = flags.GetString("output"); err != nil {
+		return err
+	}
+
+	if c.output != outputText && c.output != outputJSON {
+		return fmt.Errorf("unknown output format %q", c.output)
+	}
+
+	if c.showEvents, err = flags.GetBool("show-events"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *simulateCmd) parseStaticOptions(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	allOptions, err := flags.GetBool("all-options")
+	if err != nil {
+		return err
+	}
+
+	if allOptions {
+		for i := range 16 {
+			c.staticOptions = append(c.staticOptions, cpumanager.StaticPolicyOptions{
+				FullPhysicalCPUsOnly:           i&1 != 0,
+				DistributeCPUsAcrossNUMA:       i&2 != 0,
+				DistributeCPUsAcrossCores:      i&4 != 0,
+				PreferAlignByUncoreCacheOption: i&8 != 0,
+			})
+		}
+
+		return nil
+	}
+
+	options := cpumanager.StaticPolicyOptions{}
+
+	if options.FullPhysicalCPUsOnly, err = flags.GetBool("full-pcpus-only"); err != nil {
+		return err
+	}
+
+	if options.DistributeCPUsAcrossNUMA, err = flags.GetBool("distribute-across-numa"); err != nil {
+		return err
+	}
+
+	if options.DistributeCPUsAcrossCores, err = flags.GetBool("distribute-across-cores"); err != nil {
+		return err
+	}
+
+	if options.PreferAlignByUncoreCacheOption, err = flags.GetBool("align-by-uncore"); err != nil {
+		return err
+	}
+
+	c.staticOptions = []cpumanager.StaticPolicyOptions{options}
+
+	return nil
+}
+
+func (c *simulateCmd) parseInstanceTypes(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	instanceTypesFile, err := flags.GetString("instance-types")
+	if err != nil {
+		return err
+	}
+
+	instanceTypes := []*instancetype.InstanceTypeStatic{}
+
+	if instanceTypesFile != "" {
+		if err := readFile(instanceTypesFile, &instanceTypes); err != nil {
+			return err
+		}
+	} else {
+		options := instancetype.InstanceTypeOptions{}
+
+		if options.CPUs, err = parseIntList(flags.GetString("cpus")); err != nil {
+			return err
+		}
+
+		if options.MemFactors, err = parseIntList(flags.GetString("memfactor")); err != nil {
+			return err
+		}
+
+		instanceTypes = options.Generate()
+	}
+
+	for _, i := range instanceTypes {
+		c.instanceTypes = append(c.instanceTypes, simulation.InstanceType{
+			Name:   i.Name,
+			CPUs:   int(i.Capacity.Cpu().Value()),
+			Memory: uint64(i.Capacity.Memory().Value()),
+		})
+	}
+
+	return nil
+}
+
+func (c *simulateCmd) runSimulate(_ *cobra.Command, _ []string) error {
+	logger := logr.Discard()
+	simulator := simulation.NewSimulator(c.topology, c.reservedCPUs, c.instanceTypes)
+	reports := []*simulation.Report{}
+
+	for _, policy := range c.policies {
+		if policy == string(cpumanager.PolicySimple) {
+			report, err := simulator.Run(policy, func() (cpumanager.Policy, error) {
+				return cpumanager.NewSimplePolicyWithOptions(c.topology, c.reservedCPUs, c.reservedMemory, c.simpleOptions)
+			}, c.events)
+			if err != nil {
+				return err
+			}
+
+			reports = append(reports, report)
+
+			continue
+		}
+
+		for _, options := range c.staticOptions {
+			report, err := simulator.Run(staticPolicyName(options), func() (cpumanager.Policy, error) {
+				return cpumanager.NewStaticPolicyWithOptions(logger, c.topology, c.reservedCPUs, c.reservedMemory, options)
+			}, c.events)
+			if err != nil {
+				return err
+			}
+
+			reports = append(reports, report)
+		}
+	}
+
+	if c.output == outputJSON {
+		jsonData, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(jsonData))
+
+		return nil
+	}
+
+	fmt.Printf("Topology: %s\n\n", c.topology.String())
+
+	c.printReports(reports)
+
+	return nil
+}
+
+func (c *simulateCmd) printReports(reports []*simulation.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "POLICY\tVMS\tFAILED\tFREE CPUS\tFREE MEMORY\tFREE CORES\tSPLIT CORES\tSPLIT UNCORE\tFRAGMENTATION\tNUMA ALIGNED\tUNCORE ALIGNED\tSHARED CORES")
+
+	for _, r := range reports {
+		fragmentation := []string{"-", "-", "-", "-"}
+		if f := r.Fragmentation; f != nil {
+			fragmentation = []string{
+				strconv.Itoa(f.FreeCores),
+				strconv.Itoa(f.SplitCores),
+				strconv.Itoa(f.SplitUncoreCaches),
+				fmt.Sprintf("%.2f", f.Ratio),
+			}
+		}
+
+		alignment := []string{"-", "-", "-"}
+		if a := r.Alignment; a != nil {
+			alignment = []string{
+				fmt.Sprintf("%d/%d", a.NUMAAligned, a.VMs),
+				fmt.Sprintf("%d/%d", a.UncoreAligned, a.VMs),
+				strconv.Itoa(a.SharedCores),
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", r.Name, r.VMs, r.FailedEvents, r.AvailableCPUs,
+			resource.NewQuantity(int64(r.AvailableMemory), resource.BinarySI).String(), //nolint:gosec
+			strings.Join(fragmentation, "\t"), strings.Join(alignment, "\t"))
+	}
+
+	fmt.Fprintln(w)
+
+	header := []string{"INSTANCE TYPE"}
+	for _, r := range reports {
+		header = append(header, r.Name)
+	}
+
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, instanceType := range c.instanceTypes {
+		row := []string{instanceType.Name}
+		for _, r := range reports {
+			row = append(row, strconv.Itoa(r.Fits[instanceType.Name]))
+		}
+
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	if c.showEvents {
+		for _, r := range reports {
+			fmt.Fprintf(w, "\nEVENTS %s\nOP\tVMID\tCPUS\tCPUSET\tERROR\n", r.Name)
+
+			for _, e := range r.Events {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", e.Op, e.ID, e.CPUs, e.CPUSet, e.Error)
+			}
+		}
+	}
+
+	w.Flush() //nolint:errcheck
+}
+
+// staticPolicyName returns the name of the static policy with the enabled options
+func staticPolicyName(options cpumanager.StaticPolicyOptions) string {
+	enabled := []string{}
+
+	if options.FullPhysicalCPUsOnly {
+		enabled = append(enabled, "full-pcpus")
+	}
+
+	if options.DistributeCPUsAcrossNUMA {
+		enabled = append(enabled, "numa")
+	}
+
+	if options.DistributeCPUsAcrossCores {
+		enabled = append(enabled, "cores")
+	}
+
+	if options.PreferAlignByUncoreCacheOption {
+		enabled = append(enabled, "uncore")
+	}
+
+	return fmt.Sprintf("%s[%s]", cpumanager.PolicyStatic, strings.Join(enabled, ","))
+}
+
+func parseIntList(value string, err error) ([]int, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	list := []int{}
+
+	for s := range strings.SplitSeq(value, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %w", s, err)
+		}
+
+		list = append(list, i)
+	}
+
+	return list, nil
+}
+
+func readFile(name string, obj any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	if err := yaml.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("failed to parse file %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -49,7 +49,7 @@ func run() int {
 	cmd := cobra.Command{
 		Use:     command,
 		Version: fmt.Sprintf("%s (commit: %s)", version, commit),
-		Short:   "A command-line utility to generate instance types for Karpenter Proxmox",
+		Short:   "A command-line utility to generate instance types and render bootstrap data for Karpenter Proxmox",
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 			_ = cmd.Flags()
 
@@ -60,6 +60,7 @@ func run() int {
 	}
 
 	cmd.AddCommand(buildGenerateCmd())
+	cmd.AddCommand(buildRenderCmd())
 
 	err := cmd.ExecuteContext(ctx)
 	if err != nil {
@@ -0,0 +1,207 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+
+	cobra "github.com/spf13/cobra"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetype"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/yaml"
+)
+
+type renderCmd struct {
+	nodeClass *v1alpha1.ProxmoxNodeClass
+	templates map[string][]byte
+	values    map[string]string
+
+	options instancetype.InstanceTypeOptions
+	region  string
+	zone    string
+}
+
+func buildRenderCmd() *cobra.Command {
+	c := &renderCmd{}
+
+	cmd := cobra.Command{
+		Use:           "render",
+		Aliases:       []string{"r"},
+		Short:         "Render bootstrap data of the NodeClass for a hypothetical instance (dry-run)",
+		Args:          cobra.ExactArgs(0),
+		PreRunE:       c.parseArgs,
+		RunE:          c.runRender,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("nodeclass", "n", "", "path to the ProxmoxNodeClass manifest")
+	flags.StringP("templates", "t", "", "path to the metadata templates secret manifest (metadataOptions.templatesRef)")
+	flags.StringP("values", "v", "", "path to the template values secret manifest (metadataOptions.valuesRef)")
+	flags.IntP("cpus", "c", 2, "vCPU count of the instance type")
+	flags.IntP("memfactor", "m", 2, "memory multiplier per vCPU of the instance type")
+	flags.StringP("region", "", "", "region name, defaults to the NodeClass region")
+	flags.StringP("zone", "", "", "zone name")
+
+	_ = cmd.MarkFlagRequired("nodeclass")
+	_ = cmd.MarkFlagRequired("templates")
+
+	return &cmd
+}
+
+func (c *renderCmd) parseArgs(cmd *cobra.Command, _ []string) (err error) {
+	flags := cmd.Flags()
+
+	nodeClassFile, err := flags.GetString("nodeclass")
+	if err != nil {
+		return err
+	}
+
+	c.nodeClass = &v1alpha1.ProxmoxNodeClass{}
+	if err = readManifest(nodeClassFile, c.nodeClass); err != nil {
+		return err
+	}
+
+	if c.nodeClass.Spec.MetadataOptions == nil {
+		c.nodeClass.Spec.MetadataOptions = &v1alpha1.MetadataOptions{}
+	}
+
+	templatesFile, err := flags.GetString("templates")
+	if err != nil {
+		return err
+	}
+
+	c.templates, err = readSecretData(templatesFile)
+	if err != nil {
+		return err
+	}
+
+	c.values = map[string]string{}
+
+	valuesFile, err := flags.GetString("values")
+	if err != nil {
+		return err
+	}
+
+	if valuesFile != "" {
+		data, err := readSecretData(valuesFile)
+		if err != nil {
+			return err
+		}
+
+		for k, v := range data {
+			c.values[k] = string(v)
+		}
+	}
+
+	cpus, err := flags.GetInt("cpus")
+	if err != nil {
+		return err
+	}
+
+	memFactor, err := flags.GetInt("memfactor")
+	if err != nil {
+		return err
+	}
+
+	c.options = instancetype.InstanceTypeOptions{
+		CPUs:       []int{cpus},
+		MemFactors: []int{memFactor},
+	}
+
+	if c.region, err = flags.GetString("region"); err != nil {
+		return err
+	}
+
+	if c.zone, err = flags.GetString("zone"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *renderCmd) runRender(_ *cobra.Command, _ []string) error {
+	static := c.options.Generate()[0]
+	instanceType := &cloudprovider.InstanceType{
+		Name:     static.Name,
+		Capacity: static.Capacity,
+		Overhead: static.Overhead,
+	}
+
+	data, err := cloudinit.RenderDryRun(c.nodeClass, c.templates, c.values, instanceType, c.region, c.zone)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range []struct {
+		name string
+		data string
+	}{
+		{"user-data", data.UserData},
+		{"meta-data", data.MetaData},
+		{"vendor-data", data.VendorData},
+		{"network-config", data.NetworkConfig},
+	} {
+		if doc.data == "" {
+			continue
+		}
+
+		fmt.Printf("### %s\n%s\n", doc.name, doc.data)
+	}
+
+	return nil
+}
+
+func readManifest(name string, obj any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	if err := yaml.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("failed to parse file %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func readSecretData(name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := readManifest(name, secret); err != nil {
+		return nil, err
+	}
+
+	data := maps.Clone(secret.Data)
+	if data == nil {
+		data = map[string][]byte{}
+	}
+
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+	}
+
+	return data, nil
+}
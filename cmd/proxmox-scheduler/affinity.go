@@ -0,0 +1,259 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/syslog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+
+	goproxmox "github.com/sergelogvinov/go-proxmox"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
+	vmresources "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources/vm"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
+
+	"k8s.io/utils/cpuset"
+)
+
+// affinityConflict returns the conflict of the VM CPU affinity with the host topology
+// or with the CPU affinity of the other pinned VMs, nil if there is no conflict.
+func (r *SchedulerHandler) affinityConflict(vmID int, cpus cpuset.CPUSet) *vmconfig.AffinityConflict {
+	if r.topology == nil {
+		return nil
+	}
+
+	vms := r.pinnedVMs()
+	delete(vms, vmID)
+
+	order, affinity := affinityOrder(vms)
+	order = append(order, vmID)
+	affinity[vmID] = cpus
+
+	for _, conflict := range vmconfig.ValidateAffinity(order, affinity, r.topology.CPUDetails.CPUs()) {
+		if conflict.VMID == vmID {
+			return &conflict
+		}
+	}
+
+	return nil
+}
+
+// validateAffinity checks the CPU affinity of the pinned VMs, the VM started earlier keeps the CPUs.
+// The conflicting VMs are re-planned if the pinning policy of the VM allows to repair the affinity.
+func (r *SchedulerHandler) validateAffinity(ctx context.Context) {
+	if r.topology == nil {
+		return
+	}
+
+	order, affinity := affinityOrder(r.pinnedVMs())
+
+	for _, conflict := range vmconfig.ValidateAffinity(order, affinity, r.topology.CPUDetails.CPUs()) {
+		r.tracker.mu.RLock()
+		vmInfo, ok := r.tracker.vms[conflict.VMID]
+		r.tracker.mu.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		pinning := r.vmPolicy(vmInfo.Tags).Pinning
+		repair := pinning.Enabled && pinning.RepairAffinity
+
+		r.reportAffinityConflict(&conflict, repair)
+
+		if !repair {
+			continue
+		}
+
+		vmConfig, err := vmconfig.LoadVMConfig(conflict.VMID)
+		if err != nil {
+			r.logger.Error(err, "Failed to load VM config", "vmID", conflict.VMID)
+
+			continue
+		}
+
+		if _, err := r.repairAffinity(ctx, conflict.VMID, vmConfig); err != nil {
+			continue
+		}
+
+		if err := r.updateVMInfo(conflict.VMID, vmInfo.PID, vmConfig); err != nil {
+			r.logger.Error(err, "Failed to update VM info", "vmID", conflict.VMID)
+		}
+	}
+}
+
+// repairAffinity re-plans the CPU affinity of the VM with the conflict
+// and updates the affinity in the VM config.
+func (r *SchedulerHandler) repairAffinity(ctx context.Context, vmID int, vmConfig *proxmox.VirtualMachineConfig) (cpuset.CPUSet, error) {
+	cpus, err := r.replanVMAffinity(ctx, vmID, vmConfig)
+	if err != nil {
+		pinningErrorsTotal.WithLabelValues(pinningErrorAffinity).Inc()
+		r.logger.Error(err, "Failed to repair CPU affinity for VM", "vmID", vmID)
+
+		return cpus, err
+	}
+
+	affinityRepairsTotal.Inc()
+	r.logger.Info("VM CPU affinity repaired", "vmID", vmID, "oldAffinity", vmConfig.Affinity, "affinity", cpus.String())
+
+	vmConfig.Affinity = cpus.String()
+
+	return cpus, nil
+}
+
+// reportAffinityConflict logs the conflict and sends a structured warning to syslog
+func (r *SchedulerHandler) reportAffinityConflict(conflict *vmconfig.AffinityConflict, repair bool) {
+	affinityConflictsTotal.WithLabelValues(conflict.Reason).Inc()
+
+	vms := make([]string, 0, len(conflict.VMs))
+	for _, vmID := range conflict.VMs {
+		vms = append(vms, strconv.Itoa(vmID))
+	}
+
+	r.logger.Info("Warning: VM CPU affinity conflict",
+		"vmID", conflict.VMID,
+		"reason", conflict.Reason,
+		"affinity", conflict.Affinity.String(),
+		"cpus", conflict.CPUs.String(),
+		"vms", strings.Join(vms, ","),
+		"repair", repair,
+	)
+
+	if r.syslog == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("affinity conflict: vmid=%d reason=%s affinity=%s cpus=%s vms=%s repair=%t",
+		conflict.VMID, conflict.Reason, conflict.Affinity.String(), conflict.CPUs.String(), strings.Join(vms, ","), repair)
+
+	if err := r.syslog.Warning(msg); err != nil {
+		r.logger.V(1).Info("Failed to write to syslog", "error", err.Error())
+	}
+}
+
+// newSyslogWriter returns the syslog writer for the affinity warnings, nil if syslog is not available
+func newSyslogWriter() *syslog.Writer {
+	w, err := syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, "proxmox-scheduler")
+	if err != nil {
+		return nil
+	}
+
+	return w
+}
+
+// replanVMAffinity allocates the CPUs of the VM with the static policy
+// and updates the VM config with the new CPU affinity and NUMA nodes.
+func (r *SchedulerHandler) replanVMAffinity(ctx context.Context, vmID int, vmConfig *proxmox.VirtualMachineConfig) (cpuset.CPUSet, error) {
+	pinning := r.vmPolicy(vmConfig.Tags).Pinning
+	reservedCPUs := pinning.housekeepingCPUSet()
+
+	policy, err := cpumanager.NewStaticPolicy(r.logger, r.topology, reservedCPUs.List(), 0)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("failed to create static policy: %w", err)
+	}
+
+	r.tracker.mu.RLock()
+	for id, vmInfo := range r.tracker.vms {
+		if id == vmID || vmInfo.AffinitySet.IsEmpty() {
+			continue
+		}
+
+		if err := policy.AllocateOrUpdate(&resources.VMResources{
+			ID:     id,
+			CPUSet: vmInfo.AffinitySet,
+			Memory: vmInfo.Memory,
+		}); err != nil {
+			r.logger.Error(err, "Failed to account pinned VM resources", "vmID", id)
+		}
+	}
+	r.tracker.mu.RUnlock()
+
+	op := &resources.VMResources{
+		ID:     vmID,
+		CPUs:   vmConfig.Cores,
+		Memory: uint64(vmConfig.Memory) * 1024 * 1024,
+	}
+
+	if err := policy.Allocate(op); err != nil {
+		return cpuset.New(), fmt.Errorf("failed to allocate CPUs: %w", err)
+	}
+
+	vmOptions, err := vmresources.GenerateVMOptionsFromResources(op)
+	if err != nil {
+		return cpuset.New(), err
+	}
+
+	affinity := op.CPUSet.String()
+	options := map[string]any{
+		"affinity":    affinity,
+		"description": vmconfig.SetDescriptionAffinity(vmConfig.Description, affinity),
+	}
+
+	if vmConfig.Numa == 1 {
+		deleteOptions := []string{}
+
+		for key, value := range vmOptions {
+			if strings.HasPrefix(key, "numa") && key != "numa" {
+				options[key] = value
+			}
+		}
+
+		for key := range vmConfig.MergeNumas() {
+			if _, ok := options[key]; !ok {
+				deleteOptions = append(deleteOptions, key)
+			}
+		}
+
+		if len(deleteOptions) > 0 {
+			slices.Sort(deleteOptions)
+			options["delete"] = strings.Join(deleteOptions, ",")
+		}
+	}
+
+	if err := goproxmox.UpdateLocalVM(ctx, vmID, options); err != nil {
+		return cpuset.New(), fmt.Errorf("failed to update VM config: %w", err)
+	}
+
+	return op.CPUSet, nil
+}
+
+// affinityOrder returns the VM IDs ordered by the start of the VM process and their CPU affinity
+func affinityOrder(vms map[int]*VMInfo) ([]int, map[int]cpuset.CPUSet) {
+	order := make([]int, 0, len(vms))
+	affinity := make(map[int]cpuset.CPUSet, len(vms))
+
+	for vmID, vmInfo := range vms {
+		order = append(order, vmID)
+		affinity[vmID] = vmInfo.AffinitySet
+	}
+
+	slices.SortFunc(order, func(a, b int) int {
+		if vms[a].PID != vms[b].PID {
+			return vms[a].PID - vms[b].PID
+		}
+
+		return a - b
+	})
+
+	return order, affinity
+}
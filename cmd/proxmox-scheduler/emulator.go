@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// EmulatorThreadsNone leaves the QEMU auxiliary threads unpinned
+	EmulatorThreadsNone = "none"
+	// EmulatorThreadsSiblings pins the QEMU auxiliary threads to the hyperthread siblings of the VM cores
+	EmulatorThreadsSiblings = "siblings"
+	// EmulatorThreadsHousekeeping pins the QEMU auxiliary threads to the host housekeeping CPUs
+	EmulatorThreadsHousekeeping = "housekeeping"
+	// EmulatorThreadsNUMA pins the QEMU auxiliary threads to the unpinned CPUs of the VM NUMA nodes
+	EmulatorThreadsNUMA = "numa"
+)
+
+var emulatorThreadsPolicies = []string{
+	EmulatorThreadsNone,
+	EmulatorThreadsSiblings,
+	EmulatorThreadsHousekeeping,
+	EmulatorThreadsNUMA,
+}
+
+func validateEmulatorThreadsPolicy(policy string) error {
+	if !slices.Contains(emulatorThreadsPolicies, policy) {
+		return fmt.Errorf("unknown emulator threads policy %q, must be one of %v", policy, emulatorThreadsPolicies)
+	}
+
+	return nil
+}
+
+// emulatorThreads returns the QEMU main loop, iothreads and vhost threads of the VM
+// and their CPUs according to the emulator threads policy.
+func (r *SchedulerHandler) emulatorThreads(vmID int, pid int, vcpuThreads []int, cpus cpuset.CPUSet, pinning PinningPolicy) ([]int, cpuset.CPUSet, error) {
+	policy := pinning.EmulatorThreads
+	if policy == "" || policy == EmulatorThreadsNone {
+		return nil, cpuset.New(), nil
+	}
+
+	emulatorCPUs := r.emulatorCPUs(vmID, policy, pinning.housekeepingCPUSet(), cpus)
+	if emulatorCPUs.IsEmpty() {
+		steeringSkippedTotal.WithLabelValues(steeringEmulator).Inc()
+
+		r.logger.Info("Warning: no CPUs left for emulator threads, skipping", "vmID", vmID, "policy", policy)
+
+		return nil, cpuset.New(), nil
+	}
+
+	threads, err := utilsys.GetProcessThreads(pid, "")
+	if err != nil {
+		return nil, cpuset.New(), err
+	}
+
+	threads = slices.DeleteFunc(threads, func(tid int) bool {
+		return slices.Contains(vcpuThreads, tid)
+	})
+
+	vhostThreads, err := utilsys.GetVhostThreads(pid)
+	if err != nil {
+		r.logger.Error(err, "Failed to get vhost threads", "vmID", vmID, "pid", pid)
+	}
+
+	return append(threads, vhostThreads...), emulatorCPUs, nil
+}
+
+// emulatorCPUs returns the CPUs for the auxiliary threads of the VM pinned to cpus,
+// the set is empty if the policy has no CPUs left.
+func (r *SchedulerHandler) emulatorCPUs(vmID int, policy string, housekeeping cpuset.CPUSet, cpus cpuset.CPUSet) cpuset.CPUSet {
+	res := cpuset.New()
+
+	switch policy {
+	case EmulatorThreadsSiblings:
+		if r.topology != nil {
+			cores := r.topology.CPUDetails.KeepOnly(cpus).Cores()
+			res = r.topology.CPUDetails.CPUsInCores(cores.UnsortedList()...).Difference(cpus)
+		}
+	case EmulatorThreadsHousekeeping:
+		if !housekeeping.IsEmpty() {
+			res = housekeeping
+		} else if r.topology != nil {
+			res = r.topology.CPUDetails.CPUs().Difference(r.pinnedCPUs(vmID)).Difference(cpus)
+		}
+	case EmulatorThreadsNUMA:
+		if r.topology != nil {
+			nodes := r.topology.CPUDetails.KeepOnly(cpus).NUMANodes()
+			res = r.topology.CPUDetails.CPUsInNUMANodes(nodes.UnsortedList()...).Difference(r.pinnedCPUs(vmID)).Difference(cpus)
+		}
+	}
+
+	return res
+}
+
+// pinnedCPUs returns the CPUs pinned to the other VMs
+func (r *SchedulerHandler) pinnedCPUs(vmID int) cpuset.CPUSet {
+	r.tracker.mu.RLock()
+	defer r.tracker.mu.RUnlock()
+
+	cpus := cpuset.New()
+
+	for id, vmInfo := range r.tracker.vms {
+		if id != vmID {
+			cpus = cpus.Union(vmInfo.AffinitySet)
+		}
+	}
+
+	return cpus
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+
+	"k8s.io/utils/cpuset"
+)
+
+// topoDualSocketHT has 2 sockets with a NUMA node each, 4 cores per socket and 2 threads per core.
+// The hyperthread sibling of the CPU N is the CPU N+8.
+var topoDualSocketHT = &topology.Topology{
+	CPUTopology: topology.CPUTopology{
+		NumCPUs:    16,
+		NumSockets: 2,
+		NumCores:   8,
+		CPUDetails: map[int]topology.CPUInfo{
+			0:  {CoreID: 0, SocketID: 0, NUMANodeID: 0},
+			1:  {CoreID: 1, SocketID: 0, NUMANodeID: 0},
+			2:  {CoreID: 2, SocketID: 0, NUMANodeID: 0},
+			3:  {CoreID: 3, SocketID: 0, NUMANodeID: 0},
+			4:  {CoreID: 4, SocketID: 1, NUMANodeID: 1},
+			5:  {CoreID: 5, SocketID: 1, NUMANodeID: 1},
+			6:  {CoreID: 6, SocketID: 1, NUMANodeID: 1},
+			7:  {CoreID: 7, SocketID: 1, NUMANodeID: 1},
+			8:  {CoreID: 0, SocketID: 0, NUMANodeID: 0},
+			9:  {CoreID: 1, SocketID: 0, NUMANodeID: 0},
+			10: {CoreID: 2, SocketID: 0, NUMANodeID: 0},
+			11: {CoreID: 3, SocketID: 0, NUMANodeID: 0},
+			12: {CoreID: 4, SocketID: 1, NUMANodeID: 1},
+			13: {CoreID: 5, SocketID: 1, NUMANodeID: 1},
+			14: {CoreID: 6, SocketID: 1, NUMANodeID: 1},
+			15: {CoreID: 7, SocketID: 1, NUMANodeID: 1},
+		},
+	},
+}
+
+func TestEmulatorCPUs(t *testing.T) {
+	tests := []struct {
+		name         string
+		topology     *topology.Topology
+		policy       string
+		housekeeping cpuset.CPUSet
+		cpus         cpuset.CPUSet
+		pinned       cpuset.CPUSet
+		expected     cpuset.CPUSet
+	}{
+		{
+			name:     "none",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsNone,
+			cpus:     cpuset.New(0, 1),
+			expected: cpuset.New(),
+		},
+		{
+			name:     "siblings",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsSiblings,
+			cpus:     cpuset.New(0, 1),
+			expected: cpuset.New(8, 9),
+		},
+		{
+			name:     "siblings-used-by-vm",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsSiblings,
+			cpus:     cpuset.New(0, 8),
+			expected: cpuset.New(),
+		},
+		{
+			name:     "siblings-without-topology",
+			policy:   EmulatorThreadsSiblings,
+			cpus:     cpuset.New(0, 1),
+			expected: cpuset.New(),
+		},
+		{
+			name:         "housekeeping",
+			topology:     topoDualSocketHT,
+			policy:       EmulatorThreadsHousekeeping,
+			housekeeping: cpuset.New(0, 8),
+			cpus:         cpuset.New(1, 2),
+			pinned:       cpuset.New(4, 5, 6, 7),
+			expected:     cpuset.New(0, 8),
+		},
+		{
+			name:     "housekeeping-free-cpus",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsHousekeeping,
+			cpus:     cpuset.New(0, 1),
+			pinned:   cpuset.New(4, 5, 6, 7, 12, 13, 14, 15),
+			expected: cpuset.New(2, 3, 8, 9, 10, 11),
+		},
+		{
+			name:     "housekeeping-no-free-cpus",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsHousekeeping,
+			cpus:     cpuset.New(0, 1, 8, 9),
+			pinned:   cpuset.New(2, 3, 4, 5, 6, 7, 10, 11, 12, 13, 14, 15),
+			expected: cpuset.New(),
+		},
+		{
+			name:     "numa",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsNUMA,
+			cpus:     cpuset.New(0, 1),
+			pinned:   cpuset.New(2, 3, 8, 9),
+			expected: cpuset.New(10, 11),
+		},
+		{
+			name:     "numa-two-nodes",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsNUMA,
+			cpus:     cpuset.New(3, 4),
+			pinned:   cpuset.New(5, 6, 7, 12, 13, 14, 15),
+			expected: cpuset.New(0, 1, 2, 8, 9, 10, 11),
+		},
+		{
+			name:     "numa-no-free-cpus",
+			topology: topoDualSocketHT,
+			policy:   EmulatorThreadsNUMA,
+			cpus:     cpuset.New(0, 1),
+			pinned:   cpuset.New(2, 3, 8, 9, 10, 11),
+			expected: cpuset.New(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &SchedulerHandler{
+				topology: tt.topology,
+				tracker: &VMTracker{
+					vms: map[int]*VMInfo{
+						100: {VMID: 100, AffinitySet: tt.cpus},
+						101: {VMID: 101, AffinitySet: tt.pinned},
+					},
+				},
+			}
+
+			assert.Equal(t, tt.expected.String(), r.emulatorCPUs(100, tt.policy, tt.housekeeping, tt.cpus).String())
+		})
+	}
+}
+
+func TestEmulatorThreadsNoCPUs(t *testing.T) {
+	r := &SchedulerHandler{
+		topology: topoDualSocketHT,
+		tracker: &VMTracker{
+			vms: map[int]*VMInfo{
+				100: {VMID: 100, AffinitySet: cpuset.New(0, 8)},
+			},
+		},
+	}
+
+	threads, cpus, err := r.emulatorThreads(100, 0, nil, cpuset.New(0, 8), PinningPolicy{EmulatorThreads: EmulatorThreadsSiblings})
+	assert.NoError(t, err)
+	assert.Empty(t, threads)
+	assert.True(t, cpus.IsEmpty())
+}
@@ -21,6 +21,8 @@ import "strings"
 const (
 	// FeatureKarpenter enables integration with Karpenter
 	FeatureKarpenter = "karpenter"
+	// FeatureTopology publishes the node topology in the Proxmox node description
+	FeatureTopology = "topology"
 )
 
 // FeatureFlags represents enabled feature flags
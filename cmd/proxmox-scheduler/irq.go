@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
+)
+
+// irqPlugin assigns the IRQs of the PCI devices passed through to the VM to the VM cores
+// and steers the network devices of the pinned VMs.
+type irqPlugin struct {
+	basePlugin
+
+	r *SchedulerHandler
+}
+
+func (p *irqPlugin) Name() string {
+	return PluginIRQ
+}
+
+func (p *irqPlugin) Enabled(policy *PluginsPolicy) bool {
+	return policy.IRQ.Enabled
+}
+
+func (p *irqPlugin) VMStart(_ context.Context, vm *VMContext) error {
+	if vm.CPUs.IsEmpty() || len(vm.Config.MergeHostPCIs()) == 0 || vm.CmdlineArgs == nil {
+		return nil
+	}
+
+	vfioPciDevices := vmconfig.ParseVfioPciDevices(vm.CmdlineArgs)
+	if len(vfioPciDevices) == 0 {
+		return nil
+	}
+
+	p.r.logger.Info("VM has PCI devices found", "vmID", vm.VMID, "devices", vfioPciDevices)
+
+	for _, device := range vfioPciDevices {
+		irqs, err := utilsys.GetPciDeviceIRQs(device.HostAddress)
+		if err != nil {
+			p.r.logger.Error(err, "Failed to find IRQs for PCI device", "vmID", vm.VMID, "device", device.HostAddress)
+
+			continue
+		}
+
+		if len(irqs) == 0 {
+			continue
+		}
+
+		p.r.logger.Info("VM setting IRQ affinity", "vmID", vm.VMID, "device", device.HostAddress, "irqs", irqs, "cpus", vm.CPUs.String())
+
+		if err := utilsys.SetPciIRQAffinity(vm.VMID, device.HostAddress, irqs, vm.CPUs); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorIRQ).Inc()
+
+			p.r.logger.Error(err, "Failed to set IRQ affinity for PCI device", "vmID", vm.VMID, "device", device.HostAddress)
+
+			continue
+		}
+
+		irqAffinityAssignmentsTotal.Add(float64(len(irqs)))
+	}
+
+	return nil
+}
+
+func (p *irqPlugin) Update(_ context.Context) error {
+	p.r.updateNetworkSteering()
+
+	return nil
+}
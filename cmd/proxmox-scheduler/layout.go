@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+
+	"k8s.io/utils/cpuset"
+)
+
+// vmThreadsLayout returns the placement of the VM threads, each vCPU thread is pinned to one of the VM cores.
+// The VM cgroup is constrained to the vCPU and emulator CPUs only if the emulator threads are pinned.
+func (r *SchedulerHandler) vmThreadsLayout(vmID int, pid int, cpus cpuset.CPUSet, pinning PinningPolicy) (*utilsys.ThreadsLayout, error) {
+	vcpuThreads, err := utilsys.GetProcessThreads(pid, "CPU")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vcpuThreads) != cpus.Size() {
+		return nil, fmt.Errorf("VM %d: thread count %d does not match core count %d", vmID, len(vcpuThreads), cpus.Size())
+	}
+
+	layout := &utilsys.ThreadsLayout{
+		Threads: make(map[int]cpuset.CPUSet, len(vcpuThreads)),
+	}
+
+	for i, cpu := range cpus.List() {
+		layout.Threads[vcpuThreads[i]] = cpuset.New(cpu)
+	}
+
+	threads, emulatorCPUs, err := r.emulatorThreads(vmID, pid, vcpuThreads, cpus, pinning)
+	if err != nil {
+		r.logger.Error(err, "Failed to get VM emulator threads", "vmID", vmID)
+	}
+
+	if len(threads) > 0 {
+		for _, tid := range threads {
+			layout.Threads[tid] = emulatorCPUs
+		}
+
+		layout.Cgroup = utilsys.VMCgroupPath(vmID)
+		layout.CPUs = cpus.Union(emulatorCPUs)
+
+		if r.topology != nil {
+			layout.Mems = r.topology.CPUDetails.KeepOnly(layout.CPUs).NUMANodes()
+		}
+	}
+
+	return layout, nil
+}
+
+// restoreVMThreadsLayout verifies the placement of the pinned VM threads and applies it again if it has drifted,
+// for example after a vCPU hot-plug or an incoming migration.
+func (r *SchedulerHandler) restoreVMThreadsLayout(vmID int, pid int, cpus cpuset.CPUSet, pinning PinningPolicy) error {
+	layout, err := r.vmThreadsLayout(vmID, pid, cpus, pinning)
+	if err != nil {
+		return err
+	}
+
+	err = layout.Verify()
+	if err == nil {
+		return nil
+	}
+
+	r.logger.Info("VM threads layout drifted, restoring", "vmID", vmID, "reason", err.Error())
+
+	if err := layout.Apply(); err != nil {
+		return err
+	}
+
+	return layout.Verify()
+}
+
+// pinningPlugin pins the vCPU threads of the VMs to their CPU affinity
+// and the auxiliary threads according to the emulator threads policy.
+type pinningPlugin struct {
+	basePlugin
+
+	r *SchedulerHandler
+}
+
+func (p *pinningPlugin) Name() string {
+	return PluginPinning
+}
+
+func (p *pinningPlugin) Enabled(policy *PluginsPolicy) bool {
+	return policy.Pinning.Enabled
+}
+
+func (p *pinningPlugin) VMStart(_ context.Context, vm *VMContext) error {
+	if !vm.Pinned() {
+		return nil
+	}
+
+	p.r.logger.Info("VM pinning CPU threads to cores", "vmID", vm.VMID, "cores", vm.CPUs.String())
+
+	layout, err := p.r.vmThreadsLayout(vm.VMID, vm.PID, vm.CPUs, vm.Policy.Pinning)
+	if err == nil {
+		if err = layout.Apply(); err == nil {
+			err = layout.Verify()
+		}
+	}
+
+	if err != nil {
+		pinningErrorsTotal.WithLabelValues(pinningErrorThreads).Inc()
+
+		return fmt.Errorf("failed to pin VM threads to cores: %w", err)
+	}
+
+	return nil
+}
+
+// Sync restores the threads layout of the pinned VMs
+func (p *pinningPlugin) Sync(_ context.Context) error {
+	r := p.r
+
+	for vmID, vmInfo := range r.pinnedVMs() {
+		policy := r.vmPolicy(vmInfo.Tags)
+		if !policy.Pinning.Enabled {
+			continue
+		}
+
+		if err := r.restoreVMThreadsLayout(vmID, vmInfo.PID, vmInfo.AffinitySet, policy.Pinning); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorLayout).Inc()
+			r.logger.Error(err, "Failed to restore VM threads layout", "vmID", vmID)
+		}
+	}
+
+	return nil
+}
@@ -18,8 +18,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -46,11 +49,35 @@ const (
 	resyncIntervalEnvVarName = "RESYNC_INTERVAL"
 	resyncIntervalFlagName   = "resync-interval"
 
+	metricsBindAddressEnvVarName = "METRICS_BIND_ADDRESS"
+	metricsBindAddressFlagName   = "metrics-bind-address"
+
 	cpuGovernorBusyEnvVarName = "CPU_GOVERNOR_BUSY"
 	cpuGovernorBusyFlagName   = "cpu-governor-busy"
 
 	cpuGovernorFreeEnvVarName = "CPU_GOVERNOR_FREE"
 	cpuGovernorFreeFlagName   = "cpu-governor-free"
+
+	emulatorThreadsEnvVarName = "EMULATOR_THREADS"
+	emulatorThreadsFlagName   = "emulator-threads"
+
+	housekeepingCPUsEnvVarName = "HOUSEKEEPING_CPUS"
+	housekeepingCPUsFlagName   = "housekeeping-cpus"
+
+	repairAffinityEnvVarName = "REPAIR_AFFINITY"
+	repairAffinityFlagName   = "repair-affinity"
+
+	rebalanceSharedCPUsEnvVarName = "REBALANCE_SHARED_CPUS"
+	rebalanceSharedCPUsFlagName   = "rebalance-shared-cpus"
+
+	sharedCPUsSlicesEnvVarName = "SHARED_CPUS_SLICES"
+	sharedCPUsSlicesFlagName   = "shared-cpus-slices"
+
+	networkSteeringEnvVarName = "NETWORK_STEERING"
+	networkSteeringFlagName   = "network-steering"
+
+	policyFileEnvVarName = "POLICY_FILE"
+	policyFileFlagName   = "policy-file"
 )
 
 var (
@@ -64,8 +91,19 @@ var (
 	maxRetries     = pflag.Int(maxRetriesFlagName, env.WithDefaultInt(maxRetriesEnvVarName, 5), "Maximum number of retry attempts")
 	resyncInterval = pflag.Duration(resyncIntervalFlagName, env.WithDefaultDuration(resyncIntervalEnvVarName, 60*time.Minute), "Resync interval")
 
+	policyFile = pflag.String(policyFileFlagName, env.WithDefaultString(policyFileEnvVarName, ""), "Path of the YAML policy file of the plugins, it is reloaded on change (flags are used if empty)")
+
+	metricsBindAddress = pflag.String(metricsBindAddressFlagName, env.WithDefaultString(metricsBindAddressEnvVarName, ""), "Address of the metrics, health probes and VM status endpoint, e.g. :9810 (disabled if empty)")
+
 	cpuGovernorBusy = pflag.String(cpuGovernorBusyFlagName, env.WithDefaultString(cpuGovernorBusyEnvVarName, "performance"), "CPU governor to set when CPU is busy")
 	cpuGovernorFree = pflag.String(cpuGovernorFreeFlagName, env.WithDefaultString(cpuGovernorFreeEnvVarName, "powersave"), "CPU governor to set when CPU is free")
+
+	emulatorThreadsPolicy = pflag.String(emulatorThreadsFlagName, env.WithDefaultString(emulatorThreadsEnvVarName, EmulatorThreadsNone), "Placement of QEMU emulator, iothreads and vhost threads of pinned VMs (none, siblings, housekeeping, numa)")
+	housekeepingCPUs      = pflag.String(housekeepingCPUsFlagName, env.WithDefaultString(housekeepingCPUsEnvVarName, ""), "Host CPUs for the emulator threads with the housekeeping policy, e.g. 0-1,32-33")
+	repairAffinity        = pflag.Bool(repairAffinityFlagName, env.WithDefaultBool(repairAffinityEnvVarName, false), "Re-plan the CPU affinity of VMs overlapping other VMs or out of the host topology")
+	rebalanceSharedCPUs   = pflag.Bool(rebalanceSharedCPUsFlagName, env.WithDefaultBool(rebalanceSharedCPUsEnvVarName, false), "Constrain VMs without CPU affinity to the CPUs not pinned to any VM")
+	sharedCPUsSlices      = pflag.String(sharedCPUsSlicesFlagName, env.WithDefaultString(sharedCPUsSlicesEnvVarName, ""), "Comma-separated cgroup v2 slices constrained to the shared CPUs, e.g. system.slice,user.slice")
+	networkSteeringPolicy = pflag.String(networkSteeringFlagName, env.WithDefaultString(networkSteeringEnvVarName, NetworkSteeringNone), "Steering of RPS, XPS and IRQs of the network devices of pinned VMs (none, siblings, numa)")
 )
 
 func main() {
@@ -100,15 +138,25 @@ func main() {
 
 	showServerInfo(logger, serverInfo, tp)
 
-	if featureFlags.IsEnabled(FeatureKarpenter) {
-		if tp != nil && serverInfo != nil {
-			if err := createProxmoxTopologyDiscoveryVM(logger, serverInfo, tp); err != nil {
-				logger.Error(err, "Failed to create Proxmox VM")
-			}
+	policyPath := *policyFile
+	if policyPath != "" {
+		if policyPath, err = filepath.Abs(policyPath); err != nil {
+			logger.Error(err, "Invalid policy file path")
+			os.Exit(1)
 		}
 	}
 
-	if err := scheduler(NewHandler(tp, logger), logger); err != nil {
+	handler, err := NewHandler(serverInfo, tp, policyPath, defaultPolicy(featureFlags), logger)
+	if err != nil {
+		logger.Error(err, "Failed to load policy")
+		os.Exit(1)
+	}
+
+	logger.Info("Plugins configured", "policyFile", policyPath, "plugins", pluginNames(handler.enabledPlugins()))
+
+	handler.initPlugins(context.Background())
+
+	if err := scheduler(handler, logger); err != nil {
 		logger.Error(err, "Reconciler encountered an error")
 		os.Exit(1)
 	}
@@ -127,6 +175,10 @@ func scheduler(handler *SchedulerHandler, logger logr.Logger) error {
 	config.WatchPath = *watchPath
 	config.SyncDelay = *resyncInterval
 
+	if handler.policyFile != "" {
+		config.ConfigPaths = []string{filepath.Dir(handler.policyFile)}
+	}
+
 	rec, err := reconciler.NewReconciler(ctx, cancel, config, handler)
 	if err != nil {
 		logger.Error(err, "Failed to create reconciler")
@@ -142,6 +194,20 @@ func scheduler(handler *SchedulerHandler, logger logr.Logger) error {
 
 	logger.Info("Reconciler started successfully")
 
+	var server *http.Server
+
+	if *metricsBindAddress != "" {
+		server = newServer(*metricsBindAddress, handler)
+
+		go func() {
+			logger.Info("Starting metrics server", "address", *metricsBindAddress)
+
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error(err, "Metrics server failed")
+			}
+		}()
+	}
+
 	select {
 	case sig := <-sigCh:
 		logger.Info("Received signal, shutting down gracefully", "signal", sig)
@@ -158,6 +224,12 @@ func scheduler(handler *SchedulerHandler, logger logr.Logger) error {
 	go func() {
 		defer close(done)
 
+		if server != nil {
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				logger.Error(err, "Failed to stop metrics server")
+			}
+		}
+
 		rec.Stop()
 	}()
 
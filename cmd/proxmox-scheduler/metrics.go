@@ -0,0 +1,191 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/reconciler"
+)
+
+const (
+	metricsNamespace = "proxmox_scheduler"
+
+	pinningErrorThreads   = "threads"
+	pinningErrorTopology  = "topology"
+	pinningErrorPower     = "power"
+	pinningErrorIRQ       = "irq"
+	pinningErrorLayout    = "layout"
+	pinningErrorShared    = "shared"
+	pinningErrorMigration = "migration"
+	pinningErrorNetwork   = "network"
+	pinningErrorAffinity  = "affinity"
+
+	steeringEmulator = "emulator"
+	steeringNetwork  = "network"
+
+	reloadResultSuccess = "success"
+	reloadResultFailed  = "failed"
+)
+
+var (
+	pinningErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "pinning_errors_total",
+			Help:      "Number of VM pinning errors by reason (threads, topology, power, irq, layout, shared, migration, network, affinity).",
+		},
+		[]string{"reason"},
+	)
+
+	steeringSkippedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "steering_skipped_total",
+			Help:      "Number of skipped steerings of the VM threads and network devices without CPUs left by the policy (emulator, network).",
+		},
+		[]string{"type"},
+	)
+
+	irqAffinityAssignmentsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "irq_affinity_assignments_total",
+			Help:      "Number of PCI device IRQs assigned to the VM cores.",
+		},
+	)
+
+	migrationReplansTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "migration_replans_total",
+			Help:      "Number of live-migrated VMs with the CPU affinity re-planned on this host.",
+		},
+	)
+
+	affinityConflictsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "affinity_conflicts_total",
+			Help:      "Number of detected VM CPU affinity conflicts by reason (overlap, topology).",
+		},
+		[]string{"reason"},
+	)
+
+	affinityRepairsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "affinity_repairs_total",
+			Help:      "Number of VMs with the conflicting CPU affinity re-planned on this host.",
+		},
+	)
+
+	policyReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "policy_reloads_total",
+			Help:      "Number of policy file reloads by result (success, failed).",
+		},
+		[]string{"result"},
+	)
+
+	trackedVMsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "vms"),
+		"Number of tracked VMs by CPU affinity (pinned, unpinned).",
+		[]string{"affinity"}, nil,
+	)
+
+	vcpusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "vcpus"),
+		"Number of vCPUs of the tracked VMs by CPU affinity (pinned, unpinned).",
+		[]string{"affinity"}, nil,
+	)
+
+	cpusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "cpus"),
+		"Number of host CPUs per NUMA node by state (used, shared).",
+		[]string{"numa_node", "state"}, nil,
+	)
+)
+
+// newMetricsRegistry returns the registry with the scheduler, reconciler and tracker metrics
+func newMetricsRegistry(handler *SchedulerHandler) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		pinningErrorsTotal,
+		steeringSkippedTotal,
+		irqAffinityAssignmentsTotal,
+		migrationReplansTotal,
+		affinityConflictsTotal,
+		affinityRepairsTotal,
+		policyReloadsTotal,
+		&trackerCollector{handler: handler},
+	)
+
+	reconciler.RegisterMetrics(registry)
+
+	return registry
+}
+
+// trackerCollector collects the VM tracker state on every scrape
+type trackerCollector struct {
+	handler *SchedulerHandler
+}
+
+func (c *trackerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- trackedVMsDesc
+	ch <- vcpusDesc
+	ch <- cpusDesc
+}
+
+func (c *trackerCollector) Collect(ch chan<- prometheus.Metric) {
+	r := c.handler
+
+	r.tracker.mu.RLock()
+	defer r.tracker.mu.RUnlock()
+
+	var pinnedVMs, unpinnedVMs, pinnedVCPUs, unpinnedVCPUs int
+
+	for _, vmInfo := range r.tracker.vms {
+		if vmInfo.AffinitySet.IsEmpty() {
+			unpinnedVMs++
+			unpinnedVCPUs += vmInfo.Cores
+		} else {
+			pinnedVMs++
+			pinnedVCPUs += vmInfo.Cores
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(trackedVMsDesc, prometheus.GaugeValue, float64(pinnedVMs), "pinned")
+	ch <- prometheus.MustNewConstMetric(trackedVMsDesc, prometheus.GaugeValue, float64(unpinnedVMs), "unpinned")
+	ch <- prometheus.MustNewConstMetric(vcpusDesc, prometheus.GaugeValue, float64(pinnedVCPUs), "pinned")
+	ch <- prometheus.MustNewConstMetric(vcpusDesc, prometheus.GaugeValue, float64(unpinnedVCPUs), "unpinned")
+
+	if r.topology == nil {
+		return
+	}
+
+	for _, node := range r.topology.CPUDetails.NUMANodes().List() {
+		cpus := r.topology.CPUDetails.CPUsInNUMANodes(node)
+		nodeID := strconv.Itoa(node)
+
+		ch <- prometheus.MustNewConstMetric(cpusDesc, prometheus.GaugeValue, float64(cpus.Intersection(r.tracker.usedCPUs).Size()), nodeID, "used")
+		ch <- prometheus.MustNewConstMetric(cpusDesc, prometheus.GaugeValue, float64(cpus.Intersection(r.tracker.sharedCPUs).Size()), nodeID, "shared")
+	}
+}
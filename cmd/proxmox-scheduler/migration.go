@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/luthermonson/go-proxmox"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/reconciler"
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// migrationLock is the VM config lock during the live migration
+	migrationLock = "migrate"
+
+	migrationPollInterval = 5 * time.Second
+	migrationTimeout      = time.Hour
+)
+
+// isMigrating returns true if the incoming live migration of the VM is not finished yet,
+// the VM config is moved to this host at the end of the migration.
+func isMigrating(vmConfig *proxmox.VirtualMachineConfig, err error) bool {
+	return err != nil || vmConfig.Lock == migrationLock
+}
+
+// waitForMigration waits for the incoming live migration of the VM to finish
+// and sends the VM start event again to pin the VM on this host.
+func (r *SchedulerHandler) waitForMigration(ctx context.Context, sender reconciler.EventSender, vmID int, pid int) {
+	r.tracker.mu.Lock()
+	if _, ok := r.tracker.migrations[vmID]; ok {
+		r.tracker.mu.Unlock()
+
+		return
+	}
+
+	r.tracker.migrations[vmID] = pid
+	r.tracker.mu.Unlock()
+
+	r.logger.Info("VM incoming live migration, waiting for it to finish", "vmID", vmID, "pid", pid)
+
+	go func() {
+		defer func() {
+			r.tracker.mu.Lock()
+			delete(r.tracker.migrations, vmID)
+			r.tracker.mu.Unlock()
+		}()
+
+		err := wait.PollUntilContextTimeout(ctx, migrationPollInterval, migrationTimeout, false, func(ctx context.Context) (bool, error) {
+			if !utilsys.ProcessExists(pid) {
+				return false, fmt.Errorf("VM %d process %d does not exist", vmID, pid)
+			}
+
+			return !isMigrating(vmconfig.LoadVMConfig(vmID)), nil
+		})
+		if err != nil {
+			r.logger.Error(err, "Failed to wait for VM live migration", "vmID", vmID, "pid", pid)
+
+			return
+		}
+
+		r.logger.Info("VM incoming live migration finished", "vmID", vmID, "pid", pid)
+
+		pidFile := filepath.Join(*watchPath, strconv.Itoa(vmID)+pidFileExtension)
+
+		sender.SendEvent(reconciler.Event{
+			Type: reconciler.FileEvent,
+			Key:  pidFile,
+			Data: fsnotify.Event{Name: pidFile, Op: fsnotify.Write},
+		})
+	}()
+}
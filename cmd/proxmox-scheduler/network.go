@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// NetworkSteeringNone leaves the network devices as is
+	NetworkSteeringNone = "none"
+	// NetworkSteeringSiblings steers the network processing to the hyperthread siblings of the VM cores
+	NetworkSteeringSiblings = "siblings"
+	// NetworkSteeringNUMA steers the network processing to the unpinned CPUs of the VM NUMA nodes
+	NetworkSteeringNUMA = "numa"
+)
+
+var networkSteeringPolicies = []string{
+	NetworkSteeringNone,
+	NetworkSteeringSiblings,
+	NetworkSteeringNUMA,
+}
+
+func validateNetworkSteeringPolicy(policy string) error {
+	if !slices.Contains(networkSteeringPolicies, policy) {
+		return fmt.Errorf("unknown network steering policy %q, must be one of %v", policy, networkSteeringPolicies)
+	}
+
+	return nil
+}
+
+// updateNetworkSteering steers RPS, XPS and IRQs of the tap devices of the pinned VMs and their physical uplinks
+// to the CPUs of the network steering policy, the uplinks shared by several VMs get the CPUs of all of them.
+// The network devices which are not used by the pinned VMs anymore get their original settings back.
+func (r *SchedulerHandler) updateNetworkSteering() {
+	devices := map[string]cpuset.CPUSet{}
+
+	for vmID, vmInfo := range r.pinnedVMs() {
+		policy := r.vmPolicy(vmInfo.Tags)
+		if !policy.IRQ.Enabled || policy.IRQ.NetworkSteering == "" || policy.IRQ.NetworkSteering == NetworkSteeringNone {
+			continue
+		}
+
+		taps, err := utilsys.GetVMTapInterfaces(vmID)
+		if err != nil {
+			r.logger.Error(err, "Failed to get VM network devices", "vmID", vmID)
+
+			continue
+		}
+
+		if len(taps) == 0 {
+			continue
+		}
+
+		cpus := r.emulatorCPUs(vmID, policy.IRQ.NetworkSteering, cpuset.New(), vmInfo.AffinitySet)
+		if cpus.IsEmpty() {
+			steeringSkippedTotal.WithLabelValues(steeringNetwork).Inc()
+
+			r.logger.Info("Warning: no CPUs left for network steering, skipping", "vmID", vmID, "policy", policy.IRQ.NetworkSteering)
+
+			continue
+		}
+
+		for _, tap := range taps {
+			devices[tap] = cpus.Union(devices[tap])
+
+			for _, uplink := range utilsys.GetNetworkUplinks(tap) {
+				devices[uplink] = cpus.Union(devices[uplink])
+			}
+		}
+
+		// The vhost threads follow the emulator threads policy if it is set
+		if !policy.Pinning.Enabled || policy.Pinning.EmulatorThreads == EmulatorThreadsNone {
+			if err := r.pinVhostThreads(vmID, vmInfo.PID, cpus); err != nil {
+				pinningErrorsTotal.WithLabelValues(pinningErrorNetwork).Inc()
+
+				r.logger.Error(err, "Failed to pin vhost threads", "vmID", vmID)
+			}
+		}
+	}
+
+	r.tracker.mu.Lock()
+	defer r.tracker.mu.Unlock()
+
+	for dev, cpus := range devices {
+		r.logger.V(1).Info("Network device steering to CPUs", "device", dev, "cores", cpus.String())
+
+		if err := r.tracker.steering.Steer(dev, cpus); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorNetwork).Inc()
+
+			r.logger.Error(err, "Failed to steer network device", "device", dev)
+		}
+	}
+
+	for _, dev := range r.tracker.steering.Devices() {
+		if _, ok := devices[dev]; ok {
+			continue
+		}
+
+		r.logger.V(1).Info("Network device restoring steering", "device", dev)
+
+		if err := r.tracker.steering.Restore(dev); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorNetwork).Inc()
+
+			r.logger.Error(err, "Failed to restore network device steering", "device", dev)
+		}
+	}
+}
+
+// pinVhostThreads sets the affinity of the vhost threads of the VM
+func (r *SchedulerHandler) pinVhostThreads(vmID int, pid int, cpus cpuset.CPUSet) error {
+	threads, err := utilsys.GetProcessThreads(pid, "vhost")
+	if err != nil {
+		return err
+	}
+
+	vhostThreads, err := utilsys.GetVhostThreads(pid)
+	if err != nil {
+		return err
+	}
+
+	return utilsys.SetThreadsAffinity(vmID, append(threads, vhostThreads...), cpus)
+}
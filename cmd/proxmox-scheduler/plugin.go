@@ -0,0 +1,173 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/luthermonson/go-proxmox"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	PluginPinning         = "pinning"
+	PluginGovernors       = "governors"
+	PluginIRQ             = "irq"
+	PluginCgroup          = "cgroup"
+	PluginTopologyPublish = "topology-publish"
+)
+
+// Plugin is a step of the scheduler pipeline, the plugins are called in order.
+type Plugin interface {
+	// Name returns the plugin name
+	Name() string
+	// Enabled returns true if the plugin is enabled by the policy
+	Enabled(policy *PluginsPolicy) bool
+	// Init is called once when the scheduler starts
+	Init(ctx context.Context) error
+	// VMStart is called when a VM starts, the VM is not tracked yet
+	VMStart(ctx context.Context, vm *VMContext) error
+	// VMStop is called when a VM stops, the VM is already removed from the tracker
+	VMStop(ctx context.Context, vm *VMInfo) error
+	// Sync is called after the tracker is rebuilt from the running VMs and after the policy reload
+	Sync(ctx context.Context) error
+	// Update is called after the tracked VMs have changed to apply the host-wide settings
+	Update(ctx context.Context) error
+}
+
+// VMContext is the state of the started VM passed to the plugins
+type VMContext struct {
+	VMID        int
+	PID         int
+	Config      *proxmox.VirtualMachineConfig
+	CmdlineArgs []string
+
+	// CPUs is the CPU affinity of the VM, it is empty if the VM has no affinity
+	CPUs cpuset.CPUSet
+	// Policy is the plugins policy of the VM with the overrides of the VM tags
+	Policy PluginsPolicy
+}
+
+// Pinned returns true if each vCPU of the VM has its own CPU core
+func (vm *VMContext) Pinned() bool {
+	return !vm.CPUs.IsEmpty() && vm.Config.Cores == vm.CPUs.Size()
+}
+
+// basePlugin implements the Plugin methods which do nothing
+type basePlugin struct{}
+
+func (basePlugin) Init(context.Context) error                { return nil }
+func (basePlugin) VMStart(context.Context, *VMContext) error { return nil }
+func (basePlugin) VMStop(context.Context, *VMInfo) error     { return nil }
+func (basePlugin) Sync(context.Context) error                { return nil }
+func (basePlugin) Update(context.Context) error              { return nil }
+
+// newPlugins returns the scheduler pipeline
+func newPlugins(r *SchedulerHandler, serverInfo *info.MachineInfo) []Plugin {
+	return []Plugin{
+		&pinningPlugin{r: r},
+		&governorsPlugin{r: r},
+		&irqPlugin{r: r},
+		&cgroupPlugin{r: r},
+		&topologyPublishPlugin{r: r, serverInfo: serverInfo},
+	}
+}
+
+// enabledPlugins returns the plugins enabled by the current policy
+func (r *SchedulerHandler) enabledPlugins() []Plugin {
+	policy := r.policy.Load()
+
+	plugins := make([]Plugin, 0, len(r.plugins))
+	for _, plugin := range r.plugins {
+		if plugin.Enabled(&policy.Plugins) {
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	return plugins
+}
+
+// initPlugins calls Init of the enabled plugins
+func (r *SchedulerHandler) initPlugins(ctx context.Context) {
+	for _, plugin := range r.enabledPlugins() {
+		if err := plugin.Init(ctx); err != nil {
+			r.logger.Error(err, "Failed to initialize plugin", "plugin", plugin.Name())
+		}
+	}
+}
+
+// startVMPlugins calls VMStart of the plugins enabled for the VM
+func (r *SchedulerHandler) startVMPlugins(ctx context.Context, vm *VMContext) {
+	for _, plugin := range r.plugins {
+		if !plugin.Enabled(&vm.Policy) {
+			continue
+		}
+
+		if err := plugin.VMStart(ctx, vm); err != nil {
+			r.logger.Error(err, "Plugin failed to handle VM start", "plugin", plugin.Name(), "vmID", vm.VMID)
+		}
+	}
+}
+
+// stopVMPlugins calls VMStop of the plugins enabled for the VM
+func (r *SchedulerHandler) stopVMPlugins(ctx context.Context, vm *VMInfo) {
+	policy := r.vmPolicy(vm.Tags)
+
+	for _, plugin := range r.plugins {
+		if !plugin.Enabled(&policy) {
+			continue
+		}
+
+		if err := plugin.VMStop(ctx, vm); err != nil {
+			r.logger.Error(err, "Plugin failed to handle VM stop", "plugin", plugin.Name(), "vmID", vm.VMID)
+		}
+	}
+}
+
+// syncPlugins calls Sync of the enabled plugins
+func (r *SchedulerHandler) syncPlugins(ctx context.Context) {
+	for _, plugin := range r.enabledPlugins() {
+		if err := plugin.Sync(ctx); err != nil {
+			r.logger.Error(err, "Plugin failed to sync", "plugin", plugin.Name())
+		}
+	}
+}
+
+// updatePlugins calls Update of the enabled plugins
+func (r *SchedulerHandler) updatePlugins(ctx context.Context) {
+	for _, plugin := range r.enabledPlugins() {
+		if err := plugin.Update(ctx); err != nil {
+			r.logger.Error(err, "Plugin failed to update", "plugin", plugin.Name())
+		}
+	}
+}
+
+// vmPolicy returns the plugins policy of the VM with the Proxmox tags
+func (r *SchedulerHandler) vmPolicy(tags string) PluginsPolicy {
+	return r.policy.Load().ForTags(tags)
+}
+
+func pluginNames(plugins []Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		names = append(names, plugin.Name())
+	}
+
+	return names
+}
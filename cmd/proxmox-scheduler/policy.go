@@ -0,0 +1,311 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"k8s.io/utils/cpuset"
+)
+
+// Policy is the configuration of the scheduler plugins, it is loaded from the policy file
+type Policy struct {
+	Plugins PluginsPolicy `yaml:"plugins"`
+	// Overrides change the plugin settings of the VMs with the Proxmox tag, they are applied in order
+	Overrides []PolicyOverride `yaml:"overrides,omitempty"`
+}
+
+// PolicyOverride is the plugin settings of the VMs with the tag
+type PolicyOverride struct {
+	Tag     string          `yaml:"tag"`
+	Plugins PluginsOverride `yaml:"plugins"`
+}
+
+// PluginsOverride is the configuration of each plugin in the override, the fields not set keep the policy settings
+type PluginsOverride struct {
+	Pinning         *PinningOverride         `yaml:"pinning"`
+	Governors       *GovernorsOverride       `yaml:"governors"`
+	IRQ             *IRQOverride             `yaml:"irq"`
+	Cgroup          *CgroupOverride          `yaml:"cgroup"`
+	TopologyPublish *TopologyPublishOverride `yaml:"topology-publish"`
+}
+
+// PinningOverride overrides the PinningPolicy settings
+type PinningOverride struct {
+	Enabled          *bool   `yaml:"enabled"`
+	EmulatorThreads  *string `yaml:"emulatorThreads"`
+	HousekeepingCPUs *string `yaml:"housekeepingCPUs"`
+	RepairAffinity   *bool   `yaml:"repairAffinity"`
+}
+
+// GovernorsOverride overrides the GovernorsPolicy settings
+type GovernorsOverride struct {
+	Enabled *bool   `yaml:"enabled"`
+	Busy    *string `yaml:"busy"`
+	Free    *string `yaml:"free"`
+}
+
+// IRQOverride overrides the IRQPolicy settings
+type IRQOverride struct {
+	Enabled         *bool   `yaml:"enabled"`
+	NetworkSteering *string `yaml:"networkSteering"`
+}
+
+// CgroupOverride overrides the CgroupPolicy settings
+type CgroupOverride struct {
+	Enabled          *bool    `yaml:"enabled"`
+	SharedCPUsSlices []string `yaml:"sharedCPUsSlices"`
+}
+
+// TopologyPublishOverride overrides the TopologyPublishPolicy settings
+type TopologyPublishOverride struct {
+	Enabled      *bool `yaml:"enabled"`
+	DiscoveryVM  *bool `yaml:"discoveryVM"`
+	NodeTopology *bool `yaml:"nodeTopology"`
+}
+
+// apply merges the override into the plugin settings
+func (o *PluginsOverride) apply(plugins *PluginsPolicy) {
+	if o.Pinning != nil {
+		setValue(&plugins.Pinning.Enabled, o.Pinning.Enabled)
+		setValue(&plugins.Pinning.EmulatorThreads, o.Pinning.EmulatorThreads)
+		setValue(&plugins.Pinning.HousekeepingCPUs, o.Pinning.HousekeepingCPUs)
+		setValue(&plugins.Pinning.RepairAffinity, o.Pinning.RepairAffinity)
+	}
+
+	if o.Governors != nil {
+		setValue(&plugins.Governors.Enabled, o.Governors.Enabled)
+		setValue(&plugins.Governors.Busy, o.Governors.Busy)
+		setValue(&plugins.Governors.Free, o.Governors.Free)
+	}
+
+	if o.IRQ != nil {
+		setValue(&plugins.IRQ.Enabled, o.IRQ.Enabled)
+		setValue(&plugins.IRQ.NetworkSteering, o.IRQ.NetworkSteering)
+	}
+
+	if o.Cgroup != nil {
+		setValue(&plugins.Cgroup.Enabled, o.Cgroup.Enabled)
+
+		if o.Cgroup.SharedCPUsSlices != nil {
+			plugins.Cgroup.SharedCPUsSlices = slices.Clone(o.Cgroup.SharedCPUsSlices)
+		}
+	}
+
+	if o.TopologyPublish != nil {
+		setValue(&plugins.TopologyPublish.Enabled, o.TopologyPublish.Enabled)
+		setValue(&plugins.TopologyPublish.DiscoveryVM, o.TopologyPublish.DiscoveryVM)
+		setValue(&plugins.TopologyPublish.NodeTopology, o.TopologyPublish.NodeTopology)
+	}
+}
+
+func setValue[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+// PluginsPolicy is the configuration of each plugin
+type PluginsPolicy struct {
+	Pinning         PinningPolicy         `yaml:"pinning"`
+	Governors       GovernorsPolicy       `yaml:"governors"`
+	IRQ             IRQPolicy             `yaml:"irq"`
+	Cgroup          CgroupPolicy          `yaml:"cgroup"`
+	TopologyPublish TopologyPublishPolicy `yaml:"topology-publish"`
+}
+
+// PinningPolicy pins the VM threads to the VM CPU affinity
+type PinningPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// EmulatorThreads is the placement of QEMU emulator, iothreads and vhost threads (none, siblings, housekeeping, numa)
+	EmulatorThreads string `yaml:"emulatorThreads"`
+	// HousekeepingCPUs are the host CPUs for the emulator threads with the housekeeping policy
+	HousekeepingCPUs string `yaml:"housekeepingCPUs"`
+	// RepairAffinity re-plans the CPU affinity of the VMs overlapping other VMs or out of the host topology
+	RepairAffinity bool `yaml:"repairAffinity"`
+}
+
+// GovernorsPolicy applies the power profiles to the VM CPUs
+type GovernorsPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Busy is the CPU governor of the CPUs pinned to VMs without a power profile
+	Busy string `yaml:"busy"`
+	// Free is the CPU governor of the CPUs not pinned to any VM
+	Free string `yaml:"free"`
+}
+
+// IRQPolicy assigns the PCI device IRQs to the VM CPUs and steers the VM network devices
+type IRQPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// NetworkSteering is the steering of RPS, XPS and IRQs of the VM network devices (none, siblings, numa)
+	NetworkSteering string `yaml:"networkSteering"`
+}
+
+// CgroupPolicy constrains the VMs without CPU affinity and the host slices to the shared CPUs
+type CgroupPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// SharedCPUsSlices are the cgroup v2 slices constrained to the shared CPUs
+	SharedCPUsSlices []string `yaml:"sharedCPUsSlices,omitempty"`
+}
+
+// TopologyPublishPolicy publishes the host topology for Karpenter on start
+type TopologyPublishPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// DiscoveryVM creates the node-capacity VM of the Karpenter discovery service
+	DiscoveryVM bool `yaml:"discoveryVM"`
+	// NodeTopology publishes the node topology in the Proxmox node description
+	NodeTopology bool `yaml:"nodeTopology"`
+}
+
+// defaultPolicy returns the policy from the command-line flags and the feature flags
+func defaultPolicy(featureFlags FeatureFlags) *Policy {
+	sharedSlices := []string{}
+
+	for slice := range strings.SplitSeq(*sharedCPUsSlices, ",") {
+		if slice = strings.TrimSpace(slice); slice != "" {
+			sharedSlices = append(sharedSlices, slice)
+		}
+	}
+
+	return &Policy{
+		Plugins: PluginsPolicy{
+			Pinning: PinningPolicy{
+				Enabled:          true,
+				EmulatorThreads:  *emulatorThreadsPolicy,
+				HousekeepingCPUs: *housekeepingCPUs,
+				RepairAffinity:   *repairAffinity,
+			},
+			Governors: GovernorsPolicy{
+				Enabled: true,
+				Busy:    *cpuGovernorBusy,
+				Free:    *cpuGovernorFree,
+			},
+			IRQ: IRQPolicy{
+				Enabled:         true,
+				NetworkSteering: *networkSteeringPolicy,
+			},
+			Cgroup: CgroupPolicy{
+				Enabled:          *rebalanceSharedCPUs,
+				SharedCPUsSlices: sharedSlices,
+			},
+			TopologyPublish: TopologyPublishPolicy{
+				Enabled:      featureFlags.IsEnabled(FeatureKarpenter) || featureFlags.IsEnabled(FeatureTopology),
+				DiscoveryVM:  featureFlags.IsEnabled(FeatureKarpenter),
+				NodeTopology: featureFlags.IsEnabled(FeatureTopology),
+			},
+		},
+	}
+}
+
+// loadPolicy reads the policy file, the settings missing in the file are taken from the defaults
+func loadPolicy(path string, defaults *Policy) (*Policy, error) {
+	policy := &Policy{Plugins: defaults.Plugins}
+	policy.Plugins.Cgroup.SharedCPUsSlices = slices.Clone(defaults.Plugins.Cgroup.SharedCPUsSlices)
+
+	if path == "" {
+		return policy, policy.Validate()
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// Validate checks the plugin settings and the overrides
+func (p *Policy) Validate() error {
+	if err := p.Plugins.Validate(); err != nil {
+		return err
+	}
+
+	for i, override := range p.Overrides {
+		if override.Tag == "" {
+			return fmt.Errorf("override #%d: tag is required", i+1)
+		}
+
+		plugins := p.Plugins
+		override.Plugins.apply(&plugins)
+
+		if err := plugins.Validate(); err != nil {
+			return fmt.Errorf("override %s: %w", override.Tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the plugin settings
+func (p *PluginsPolicy) Validate() error {
+	if err := validateEmulatorThreadsPolicy(p.Pinning.EmulatorThreads); err != nil {
+		return err
+	}
+
+	if _, err := cpuset.Parse(p.Pinning.HousekeepingCPUs); err != nil {
+		return fmt.Errorf("invalid housekeeping CPUs %q: %w", p.Pinning.HousekeepingCPUs, err)
+	}
+
+	return validateNetworkSteeringPolicy(p.IRQ.NetworkSteering)
+}
+
+// ForTags returns the plugin settings of the VM with the Proxmox tags separated by semicolons
+func (p *Policy) ForTags(tags string) PluginsPolicy {
+	plugins := p.Plugins
+	if len(p.Overrides) == 0 {
+		return plugins
+	}
+
+	vmTags := strings.Split(tags, ";")
+
+	for _, override := range p.Overrides {
+		if !slices.Contains(vmTags, override.Tag) {
+			continue
+		}
+
+		override.Plugins.apply(&plugins)
+	}
+
+	return plugins
+}
+
+// housekeepingCPUSet returns the housekeeping CPUs, they are validated on load
+func (p *PinningPolicy) housekeepingCPUSet() cpuset.CPUSet {
+	cpus, _ := cpuset.Parse(p.HousekeepingCPUs) //nolint:errcheck
+
+	return cpus
+}
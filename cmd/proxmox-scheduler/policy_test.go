@@ -0,0 +1,310 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func testPolicy() *Policy {
+	return &Policy{
+		Plugins: PluginsPolicy{
+			Pinning: PinningPolicy{
+				Enabled:         true,
+				EmulatorThreads: EmulatorThreadsNone,
+			},
+			Governors: GovernorsPolicy{
+				Enabled: true,
+				Busy:    "performance",
+				Free:    "powersave",
+			},
+			IRQ: IRQPolicy{
+				Enabled:         true,
+				NetworkSteering: NetworkSteeringNone,
+			},
+			Cgroup: CgroupPolicy{
+				SharedCPUsSlices: []string{"system.slice"},
+			},
+		},
+	}
+}
+
+func testOverride(t *testing.T, tag string, plugins string) PolicyOverride {
+	t.Helper()
+
+	override := PolicyOverride{Tag: tag}
+
+	decoder := yaml.NewDecoder(strings.NewReader(plugins))
+	decoder.KnownFields(true)
+	assert.NoError(t, decoder.Decode(&override.Plugins))
+
+	return override
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		expected  func(p *Policy)
+		expectErr string
+	}{
+		{
+			name:     "empty",
+			policy:   "",
+			expected: func(_ *Policy) {},
+		},
+		{
+			name: "plugins",
+			policy: `plugins:
+  pinning:
+    emulatorThreads: housekeeping
+    housekeepingCPUs: 0-1
+  governors:
+    enabled: false
+`,
+			expected: func(p *Policy) {
+				p.Plugins.Pinning.EmulatorThreads = EmulatorThreadsHousekeeping
+				p.Plugins.Pinning.HousekeepingCPUs = "0-1"
+				p.Plugins.Governors.Enabled = false
+			},
+		},
+		{
+			name: "unknown-plugin-field",
+			policy: `plugins:
+  pinning:
+    emulatorThread: housekeeping
+`,
+			expectErr: "field emulatorThread not found",
+		},
+		{
+			name: "invalid-emulator-threads",
+			policy: `plugins:
+  pinning:
+    emulatorThreads: cores
+`,
+			expectErr: "unknown emulator threads policy",
+		},
+		{
+			name: "override",
+			policy: `overrides:
+  - tag: latency
+    plugins:
+      irq:
+        networkSteering: siblings
+`,
+			expected: func(p *Policy) {
+				p.Overrides = []PolicyOverride{testOverride(t, "latency", "irq:\n  networkSteering: siblings\n")}
+			},
+		},
+		{
+			name: "override-unknown-field",
+			policy: `overrides:
+  - tag: latency
+    plugins:
+      irq:
+        networkSteer: siblings
+`,
+			expectErr: "field networkSteer not found",
+		},
+		{
+			name: "override-unknown-plugin",
+			policy: `overrides:
+  - tag: latency
+    plugins:
+      pinnig:
+        enabled: false
+`,
+			expectErr: "field pinnig not found",
+		},
+		{
+			name: "override-without-tag",
+			policy: `overrides:
+  - plugins:
+      irq:
+        enabled: false
+`,
+			expectErr: "override #1: tag is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.policy), 0o600))
+
+			policy, err := loadPolicy(path, testPolicy())
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			expected := testPolicy()
+			tt.expected(expected)
+
+			assert.Equal(t, expected.Plugins, policy.Plugins)
+			assert.Len(t, policy.Overrides, len(expected.Overrides))
+
+			for i := range expected.Overrides {
+				assert.Equal(t, expected.Overrides[i].Tag, policy.Overrides[i].Tag)
+				assert.Equal(t, expected.ForTags(expected.Overrides[i].Tag), policy.ForTags(policy.Overrides[i].Tag))
+			}
+		})
+	}
+}
+
+func TestLoadPolicyDefaults(t *testing.T) {
+	defaults := testPolicy()
+
+	policy, err := loadPolicy("", defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults.Plugins, policy.Plugins)
+
+	// The defaults are not changed by the policy
+	policy.Plugins.Cgroup.SharedCPUsSlices[0] = "user.slice"
+	assert.Equal(t, []string{"system.slice"}, defaults.Plugins.Cgroup.SharedCPUsSlices)
+
+	_, err = loadPolicy(filepath.Join(t.TempDir(), "policy.yaml"), defaults)
+	assert.ErrorContains(t, err, "failed to read policy file")
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		update    func(t *testing.T, p *Policy)
+		expectErr string
+	}{
+		{
+			name:   "default",
+			update: func(_ *testing.T, _ *Policy) {},
+		},
+		{
+			name: "invalid-housekeeping-cpus",
+			update: func(_ *testing.T, p *Policy) {
+				p.Plugins.Pinning.HousekeepingCPUs = "0-a"
+			},
+			expectErr: "invalid housekeeping CPUs",
+		},
+		{
+			name: "invalid-network-steering",
+			update: func(_ *testing.T, p *Policy) {
+				p.Plugins.IRQ.NetworkSteering = "cores"
+			},
+			expectErr: "unknown network steering policy",
+		},
+		{
+			name: "override",
+			update: func(t *testing.T, p *Policy) {
+				p.Overrides = []PolicyOverride{testOverride(t, "latency", "pinning:\n  emulatorThreads: numa\n")}
+			},
+		},
+		{
+			name: "override-invalid-value",
+			update: func(t *testing.T, p *Policy) {
+				p.Overrides = []PolicyOverride{testOverride(t, "latency", "pinning:\n  emulatorThreads: cores\n")}
+			},
+			expectErr: "override latency: unknown emulator threads policy",
+		},
+		{
+			name: "override-empty",
+			update: func(_ *testing.T, p *Policy) {
+				p.Overrides = []PolicyOverride{{Tag: "latency"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := testPolicy()
+			tt.update(t, policy)
+
+			err := policy.Validate()
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPolicyForTags(t *testing.T) {
+	policy := testPolicy()
+	policy.Overrides = []PolicyOverride{
+		testOverride(t, "latency", "pinning:\n  emulatorThreads: housekeeping\n  housekeepingCPUs: 0-1\nirq:\n  networkSteering: siblings\n"),
+		testOverride(t, "no-tuning", "governors:\n  enabled: false\nirq:\n  enabled: false\ncgroup:\n  sharedCPUsSlices: [machine.slice]\n"),
+	}
+	assert.NoError(t, policy.Validate())
+
+	tests := []struct {
+		name     string
+		tags     string
+		expected func(p *PluginsPolicy)
+	}{
+		{
+			name:     "no-tags",
+			tags:     "",
+			expected: func(_ *PluginsPolicy) {},
+		},
+		{
+			name:     "other-tags",
+			tags:     "k8s;lat",
+			expected: func(_ *PluginsPolicy) {},
+		},
+		{
+			name: "latency",
+			tags: "k8s;latency",
+			expected: func(p *PluginsPolicy) {
+				p.Pinning.EmulatorThreads = EmulatorThreadsHousekeeping
+				p.Pinning.HousekeepingCPUs = "0-1"
+				p.IRQ.NetworkSteering = NetworkSteeringSiblings
+			},
+		},
+		{
+			name: "latency-no-tuning",
+			tags: "no-tuning;latency",
+			expected: func(p *PluginsPolicy) {
+				p.Pinning.EmulatorThreads = EmulatorThreadsHousekeeping
+				p.Pinning.HousekeepingCPUs = "0-1"
+				p.Governors.Enabled = false
+				p.IRQ.Enabled = false
+				p.IRQ.NetworkSteering = NetworkSteeringSiblings
+				p.Cgroup.SharedCPUsSlices = []string{"machine.slice"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := testPolicy().Plugins
+			tt.expected(&expected)
+
+			assert.Equal(t, expected, policy.ForTags(tt.tags))
+		})
+	}
+
+	// The overrides do not change the base settings
+	assert.Equal(t, testPolicy().Plugins, policy.Plugins)
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/samber/lo"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+)
+
+const (
+	// powerProfileTagPrefix is the prefix of the VM tag which selects the power profile, e.g. power-latency
+	powerProfileTagPrefix = "power-"
+
+	powerProfileDefault = "default"
+)
+
+// PowerProfile is the power management policy of the CPUs pinned to a VM
+type PowerProfile struct {
+	// Governor is the CPU frequency governor, the busy CPU governor is used if empty
+	Governor string
+	// EPP is the energy performance preference of intel_pstate or amd-pstate drivers
+	EPP string
+	// MaxCState is the deepest allowed idle state, all idle states are allowed if negative
+	MaxCState int
+	// Turbo enables or disables the turbo boost of the CPU socket, it is not changed if nil
+	Turbo *bool
+	// UncoreMax keeps the uncore frequency of the CPU socket at maximum
+	UncoreMax bool
+}
+
+var powerProfiles = map[string]PowerProfile{
+	"latency": {
+		Governor:  "performance",
+		EPP:       "performance",
+		MaxCState: 0,
+		Turbo:     lo.ToPtr(true),
+		UncoreMax: true,
+	},
+	"performance": {
+		Governor:  "performance",
+		EPP:       "performance",
+		MaxCState: 1,
+		Turbo:     lo.ToPtr(true),
+	},
+	"balanced": {
+		EPP:       "balance_performance",
+		MaxCState: -1,
+	},
+	"powersave": {
+		Governor:  "powersave",
+		EPP:       "power",
+		MaxCState: -1,
+		Turbo:     lo.ToPtr(false),
+	},
+}
+
+// vmPowerProfileName returns the power profile name from the VM tags
+func vmPowerProfileName(vmConfig *proxmox.VirtualMachineConfig) string {
+	for _, tag := range strings.Split(vmConfig.Tags, ";") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(tag), powerProfileTagPrefix); ok {
+			return name
+		}
+	}
+
+	return powerProfileDefault
+}
+
+// getPowerProfile returns the power profile by name, the busy CPU governor is used for unknown profiles
+func getPowerProfile(name string, governors GovernorsPolicy) (PowerProfile, bool) {
+	if profile, ok := powerProfiles[name]; ok {
+		profile.Governor = lo.CoalesceOrEmpty(profile.Governor, governors.Busy)
+
+		return profile, true
+	}
+
+	return PowerProfile{Governor: governors.Busy, MaxCState: -1}, name == powerProfileDefault
+}
+
+// freePowerProfile is the power profile of the CPUs not pinned to any VM
+func freePowerProfile(governors GovernorsPolicy) PowerProfile {
+	return PowerProfile{Governor: governors.Free, EPP: "default", MaxCState: -1}
+}
+
+// applyPowerProfile sets the governor, energy performance preference and idle states of the CPUs
+func (r *SchedulerHandler) applyPowerProfile(vmID int, profile PowerProfile, cpus []int) error {
+	if len(cpus) == 0 {
+		return nil
+	}
+
+	if profile.Governor != "" {
+		if err := utilsys.SetCPUGovernor(vmID, cpus, profile.Governor); err != nil {
+			return err
+		}
+	}
+
+	if err := utilsys.SetCPUEnergyPerformancePreference(vmID, cpus, profile.EPP); err != nil {
+		return err
+	}
+
+	return utilsys.SetCPUIdleMaxState(vmID, cpus, profile.MaxCState)
+}
+
+// updateSocketPower sets the turbo boost and uncore frequency of each CPU socket
+// from the power profiles of the VMs pinned to the socket.
+// The turbo boost gets its original value back if no VM has a turbo preference.
+func (r *SchedulerHandler) updateSocketPower() {
+	if r.topology == nil {
+		return
+	}
+
+	r.tracker.mu.RLock()
+
+	var globalTurbo *bool
+
+	socketTurbo := map[int]*bool{}
+	socketUncore := map[int]bool{}
+
+	for _, vmInfo := range r.tracker.vms {
+		if vmInfo.AffinitySet.IsEmpty() {
+			continue
+		}
+
+		profile, _ := getPowerProfile(vmInfo.PowerProfile, GovernorsPolicy{})
+		sockets := r.topology.CPUDetails.KeepOnly(vmInfo.AffinitySet).Sockets()
+
+		for _, socket := range sockets.UnsortedList() {
+			socketTurbo[socket] = mergeTurbo(socketTurbo[socket], profile.Turbo)
+			socketUncore[socket] = socketUncore[socket] || profile.UncoreMax
+		}
+
+		globalTurbo = mergeTurbo(globalTurbo, profile.Turbo)
+	}
+
+	r.tracker.mu.RUnlock()
+
+	r.tracker.mu.Lock()
+	defer r.tracker.mu.Unlock()
+
+	perCPUBoost := true
+
+	for _, socket := range r.topology.CPUDetails.Sockets().List() {
+		cpus := r.topology.CPUDetails.CPUsInSockets(socket).List()
+
+		switch turbo := socketTurbo[socket]; {
+		case turbo == nil:
+			// No VM on the socket has a turbo preference, the original boost is restored
+			if err := r.tracker.boost.RestoreCPUs(cpus); err != nil {
+				r.logger.Error(err, "Failed to restore turbo boost", "socket", socket)
+			}
+		case perCPUBoost:
+			if err := r.tracker.boost.SetCPUs(cpus, *turbo); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					perCPUBoost = false
+				} else {
+					r.logger.Error(err, "Failed to set turbo boost", "socket", socket)
+				}
+			}
+		}
+
+		if err := utilsys.SetUncoreMaxFrequency(socket, socketUncore[socket]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			r.logger.Error(err, "Failed to set uncore frequency", "socket", socket)
+		}
+	}
+
+	if perCPUBoost || globalTurbo == nil {
+		if err := r.tracker.boost.RestoreGlobal(); err != nil {
+			r.logger.Error(err, "Failed to restore turbo boost")
+		}
+
+		return
+	}
+
+	if err := r.tracker.boost.SetGlobal(*globalTurbo); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.logger.Error(err, "Failed to set turbo boost")
+	}
+}
+
+// governorsPlugin applies the power profiles to the CPUs of the pinned VMs
+// and the socket-wide turbo boost and uncore frequency.
+type governorsPlugin struct {
+	basePlugin
+
+	r *SchedulerHandler
+}
+
+func (p *governorsPlugin) Name() string {
+	return PluginGovernors
+}
+
+func (p *governorsPlugin) Enabled(policy *PluginsPolicy) bool {
+	return policy.Governors.Enabled
+}
+
+func (p *governorsPlugin) VMStart(_ context.Context, vm *VMContext) error {
+	if !vm.Pinned() {
+		return nil
+	}
+
+	profileName := vmPowerProfileName(vm.Config)
+
+	profile, ok := getPowerProfile(profileName, vm.Policy.Governors)
+	if !ok {
+		p.r.logger.Info("Warning: unknown VM power profile, using the default profile", "vmID", vm.VMID, "profile", profileName, "profiles", powerProfileNames())
+	}
+
+	p.r.logger.Info("VM applying power profile to CPUs", "vmID", vm.VMID, "profile", profileName, "governor", profile.Governor, "cores", vm.CPUs.String())
+
+	if err := p.r.applyPowerProfile(vm.VMID, profile, vm.CPUs.List()); err != nil {
+		pinningErrorsTotal.WithLabelValues(pinningErrorPower).Inc()
+
+		return fmt.Errorf("failed to apply power profile: %w", err)
+	}
+
+	return nil
+}
+
+// VMStop applies the free power profile to the CPUs of the VM which are not pinned to other VMs
+func (p *governorsPlugin) VMStop(_ context.Context, vm *VMInfo) error {
+	cpus := vm.AffinitySet.Difference(p.r.pinnedCPUs(vm.VMID))
+	if cpus.IsEmpty() {
+		return nil
+	}
+
+	governors := p.r.vmPolicy(vm.Tags).Governors
+
+	p.r.logger.Info("VM applying free power profile to CPUs", "vmID", vm.VMID, "governor", governors.Free, "cores", cpus.String())
+
+	if err := p.r.applyPowerProfile(vm.VMID, freePowerProfile(governors), cpus.List()); err != nil {
+		pinningErrorsTotal.WithLabelValues(pinningErrorPower).Inc()
+
+		return fmt.Errorf("failed to apply free power profile: %w", err)
+	}
+
+	return nil
+}
+
+// Sync applies the power profiles of the pinned VMs again, for example after the policy reload
+func (p *governorsPlugin) Sync(_ context.Context) error {
+	for vmID, vmInfo := range p.r.pinnedVMs() {
+		policy := p.r.vmPolicy(vmInfo.Tags)
+		if !policy.Governors.Enabled {
+			continue
+		}
+
+		profile, _ := getPowerProfile(vmInfo.PowerProfile, policy.Governors)
+
+		if err := p.r.applyPowerProfile(vmID, profile, vmInfo.AffinitySet.List()); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorPower).Inc()
+			p.r.logger.Error(err, "Failed to apply power profile for VM", "vmID", vmID)
+		}
+	}
+
+	return nil
+}
+
+func (p *governorsPlugin) Update(_ context.Context) error {
+	p.r.updateSocketPower()
+
+	return nil
+}
+
+// mergeTurbo enables the turbo boost if any profile requires it
+func mergeTurbo(current, turbo *bool) *bool {
+	if turbo == nil {
+		return current
+	}
+
+	if current == nil || *turbo {
+		return lo.ToPtr(*turbo)
+	}
+
+	return current
+}
+
+func powerProfileNames() []string {
+	names := make([]string, 0, len(powerProfiles))
+	for name := range powerProfiles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
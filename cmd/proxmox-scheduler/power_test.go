@@ -0,0 +1,159 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTurbo(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  *bool
+		turbo    *bool
+		expected *bool
+	}{
+		{
+			name:     "no-preference",
+			current:  nil,
+			turbo:    nil,
+			expected: nil,
+		},
+		{
+			name:     "first-disabled",
+			current:  nil,
+			turbo:    lo.ToPtr(false),
+			expected: lo.ToPtr(false),
+		},
+		{
+			name:     "first-enabled",
+			current:  nil,
+			turbo:    lo.ToPtr(true),
+			expected: lo.ToPtr(true),
+		},
+		{
+			name:     "keep-without-preference",
+			current:  lo.ToPtr(false),
+			turbo:    nil,
+			expected: lo.ToPtr(false),
+		},
+		{
+			name:     "enabled-wins-over-disabled",
+			current:  lo.ToPtr(false),
+			turbo:    lo.ToPtr(true),
+			expected: lo.ToPtr(true),
+		},
+		{
+			name:     "disabled-does-not-override-enabled",
+			current:  lo.ToPtr(true),
+			turbo:    lo.ToPtr(false),
+			expected: lo.ToPtr(true),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mergeTurbo(tt.current, tt.turbo))
+		})
+	}
+}
+
+func TestGetPowerProfile(t *testing.T) {
+	governors := GovernorsPolicy{Enabled: true, Busy: "schedutil", Free: "powersave"}
+
+	tests := []struct {
+		name     string
+		profile  string
+		expected PowerProfile
+		known    bool
+	}{
+		{
+			name:     "default",
+			profile:  powerProfileDefault,
+			expected: PowerProfile{Governor: "schedutil", MaxCState: -1},
+			known:    true,
+		},
+		{
+			name:     "latency",
+			profile:  "latency",
+			expected: powerProfiles["latency"],
+			known:    true,
+		},
+		{
+			name:    "busy-governor",
+			profile: "balanced",
+			expected: PowerProfile{
+				Governor:  "schedutil",
+				EPP:       "balance_performance",
+				MaxCState: -1,
+			},
+			known: true,
+		},
+		{
+			name:     "unknown",
+			profile:  "turbo",
+			expected: PowerProfile{Governor: "schedutil", MaxCState: -1},
+			known:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, known := getPowerProfile(tt.profile, governors)
+
+			assert.Equal(t, tt.expected, profile)
+			assert.Equal(t, tt.known, known)
+		})
+	}
+
+	// The busy governor does not change the profiles
+	assert.Empty(t, powerProfiles["balanced"].Governor)
+}
+
+func TestVMPowerProfileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     string
+		expected string
+	}{
+		{
+			name:     "no-tags",
+			tags:     "",
+			expected: powerProfileDefault,
+		},
+		{
+			name:     "other-tags",
+			tags:     "k8s;karpenter",
+			expected: powerProfileDefault,
+		},
+		{
+			name:     "profile",
+			tags:     "k8s;power-latency",
+			expected: "latency",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, vmPowerProfileName(&proxmox.VirtualMachineConfig{Tags: tt.tags}))
+		})
+	}
+}
@@ -18,8 +18,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"slices"
 	"strings"
 	"time"
@@ -30,6 +33,8 @@ import (
 
 	goproxmox "github.com/sergelogvinov/go-proxmox"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/resourcemanager/settings"
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 )
@@ -38,24 +43,8 @@ func createProxmoxTopologyDiscoveryVM(logger logr.Logger, serverInfo *info.Machi
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 30*time.Second, false, func(ctx context.Context) (bool, error) {
-		ready, err := goproxmox.ClusterReadyLocal(ctx)
-		if err != nil {
-			logger.Error(err, "Failed to check Proxmox cluster quorum status, retrying...")
-
-			return false, nil //nolint:nilerr
-		}
-
-		if !ready {
-			logger.Info("Proxmox cluster has no quorum")
-
-			return false, nil
-		}
-
-		return true, nil
-	})
-	if err != nil {
-		return fmt.Errorf("failed to wait for Proxmox cluster quorum: %w", err)
+	if err := waitForClusterQuorum(ctx, logger); err != nil {
+		return err
 	}
 
 	vmID, vm, err := goproxmox.GetLocalVMConfigByFilter(func(v *proxmox.VirtualMachineConfig) (bool, error) {
@@ -94,6 +83,99 @@ func createProxmoxTopologyDiscoveryVM(logger logr.Logger, serverInfo *info.Machi
 	return nil
 }
 
+func publishProxmoxNodeTopology(logger logr.Logger, serverInfo *info.MachineInfo, tp *topology.Topology) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err := waitForClusterQuorum(ctx, logger); err != nil {
+		return err
+	}
+
+	nodeName, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get node name: %w", err)
+	}
+
+	nodeName, _, _ = strings.Cut(nodeName, ".")
+
+	devices, err := utilsys.GetPciDevices()
+	if err != nil {
+		logger.Error(err, "Failed to get PCI devices")
+	}
+
+	description, err := getLocalNodeDescription(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	updated, err := settings.EncodeNodeTopology(description, buildNodeTopology(serverInfo, tp, devices))
+	if err != nil {
+		return fmt.Errorf("failed to encode node topology: %w", err)
+	}
+
+	if updated == description {
+		return nil
+	}
+
+	logger.Info("Publishing node topology", "node", nodeName)
+
+	return setLocalNodeDescription(ctx, nodeName, updated)
+}
+
+func waitForClusterQuorum(ctx context.Context, logger logr.Logger) error {
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 30*time.Second, false, func(ctx context.Context) (bool, error) {
+		ready, err := goproxmox.ClusterReadyLocal(ctx)
+		if err != nil {
+			logger.Error(err, "Failed to check Proxmox cluster quorum status, retrying...")
+
+			return false, nil //nolint:nilerr
+		}
+
+		if !ready {
+			logger.Info("Proxmox cluster has no quorum")
+
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for Proxmox cluster quorum: %w", err)
+	}
+
+	return nil
+}
+
+func getLocalNodeDescription(ctx context.Context, nodeName string) (string, error) {
+	cmd := exec.CommandContext(ctx, "pvesh", "get", fmt.Sprintf("/nodes/%s/config", nodeName), "--output-format", "json")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get node %s config: %w", nodeName, err)
+	}
+
+	config := struct {
+		Description string `json:"description,omitempty"`
+	}{}
+
+	if err := json.Unmarshal(output, &config); err != nil {
+		return "", fmt.Errorf("failed to parse node %s config: %w", nodeName, err)
+	}
+
+	return config.Description, nil
+}
+
+func setLocalNodeDescription(ctx context.Context, nodeName, description string) error {
+	cmd := exec.CommandContext(ctx, "pvesh", "set", fmt.Sprintf("/nodes/%s/config", nodeName), "--description", description)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update node %s description: %w, output: %s", nodeName, err, string(output))
+	}
+
+	return nil
+}
+
 func buildVMOptions(serverInfo *info.MachineInfo, tp *topology.Topology) map[string]any {
 	totalCores := serverInfo.NumCores
 	totalMemoryMB := serverInfo.MemoryCapacity / (1024 * 1024)
@@ -146,3 +228,41 @@ func buildVMOptions(serverInfo *info.MachineInfo, tp *topology.Topology) map[str
 
 	return options
 }
+
+// topologyPublishPlugin publishes the host topology for Karpenter when the scheduler starts
+type topologyPublishPlugin struct {
+	basePlugin
+
+	r          *SchedulerHandler
+	serverInfo *info.MachineInfo
+}
+
+func (p *topologyPublishPlugin) Name() string {
+	return PluginTopologyPublish
+}
+
+func (p *topologyPublishPlugin) Enabled(policy *PluginsPolicy) bool {
+	return policy.TopologyPublish.Enabled
+}
+
+func (p *topologyPublishPlugin) Init(_ context.Context) error {
+	if p.r.topology == nil || p.serverInfo == nil {
+		return nil
+	}
+
+	policy := p.r.policy.Load().Plugins.TopologyPublish
+
+	if policy.DiscoveryVM {
+		if err := createProxmoxTopologyDiscoveryVM(p.r.logger, p.serverInfo, p.r.topology); err != nil {
+			p.r.logger.Error(err, "Failed to create Proxmox VM")
+		}
+	}
+
+	if policy.NodeTopology {
+		if err := publishProxmoxNodeTopology(p.r.logger, p.serverInfo, p.r.topology); err != nil {
+			p.r.logger.Error(err, "Failed to publish node topology")
+		}
+	}
+
+	return nil
+}
@@ -19,14 +19,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/syslog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-logr/logr"
+	info "github.com/google/cadvisor/info/v1"
 
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/reconciler"
@@ -38,13 +41,19 @@ import (
 
 // VMInfo holds information for a VM
 type VMInfo struct {
-	VMID  int
-	PID   int
-	Name  string
-	Cores int
+	VMID   int
+	PID    int
+	Name   string
+	Cores  int
+	Memory uint64
+	// Tags are the Proxmox tags of the VM separated by semicolons
+	Tags string
 
 	AffinitySet cpuset.CPUSet
 	AssignedSet cpuset.CPUSet
+
+	// PowerProfile is the power profile name of the VM CPUs
+	PowerProfile string
 }
 
 // VMTracker holds the tracking information for all VMs
@@ -57,6 +66,14 @@ type VMTracker struct {
 	// Used CPUs of VM with affinity assignments
 	usedCPUs cpuset.CPUSet
 
+	// migrations maps VM ID to PID of the incoming live migrations in progress
+	migrations map[int]int
+
+	// steering keeps the original settings of the steered network devices
+	steering *utilsys.NetworkSteering
+	// boost keeps the original turbo boost of the CPUs
+	boost *utilsys.TurboBoost
+
 	mu sync.RWMutex
 }
 
@@ -64,6 +81,21 @@ type SchedulerHandler struct {
 	topology *topology.Topology
 	tracker  *VMTracker
 
+	// plugins is the pipeline of the VM handling steps
+	plugins []Plugin
+	// policy is the current plugins policy, it is replaced on the policy file reload
+	policy atomic.Pointer[Policy]
+	// defaultPolicy is the policy from the command-line flags, the policy file overrides it
+	defaultPolicy *Policy
+	// policyFile is the path of the policy file, empty if not used
+	policyFile string
+
+	// syslog receives the structured warnings of the VM CPU affinity conflicts, nil if syslog is not available
+	syslog *syslog.Writer
+
+	// synced is true after the first successful sync of the running VMs
+	synced atomic.Bool
+
 	logger logr.Logger
 }
 
@@ -72,14 +104,30 @@ const (
 	pidFileExtension = ".pid"
 )
 
-func NewHandler(topology *topology.Topology, logger logr.Logger) *SchedulerHandler {
-	return &SchedulerHandler{
+func NewHandler(serverInfo *info.MachineInfo, topology *topology.Topology, policyFile string, defaultPolicy *Policy, logger logr.Logger) (*SchedulerHandler, error) {
+	policy, err := loadPolicy(policyFile, defaultPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &SchedulerHandler{
 		topology: topology,
 		tracker: &VMTracker{
-			vms: make(map[int]*VMInfo),
+			vms:        make(map[int]*VMInfo),
+			migrations: make(map[int]int),
+			steering:   utilsys.NewNetworkSteering(),
+			boost:      utilsys.NewTurboBoost(),
 		},
-		logger: logger,
+		defaultPolicy: defaultPolicy,
+		policyFile:    policyFile,
+		syslog:        newSyslogWriter(),
+		logger:        logger,
 	}
+
+	handler.policy.Store(policy)
+	handler.plugins = newPlugins(handler, serverInfo)
+
+	return handler, nil
 }
 
 func (r *SchedulerHandler) Reconcile(ctx context.Context, sender reconciler.EventSender, event reconciler.Event) error {
@@ -88,6 +136,14 @@ func (r *SchedulerHandler) Reconcile(ctx context.Context, sender reconciler.Even
 	if event.Type == reconciler.FileEvent {
 		fsEvent, ok := event.Data.(fsnotify.Event)
 		if ok {
+			if r.policyFile != "" && filepath.Clean(fsEvent.Name) == r.policyFile {
+				if fsEvent.Op == fsnotify.Remove {
+					return nil
+				}
+
+				return r.reloadPolicy(ctx)
+			}
+
 			vmIDStr, ok := strings.CutSuffix(fsEvent.Name, pidFileExtension)
 			if !ok { // Not a PID file, ignore
 				return nil //nolint:nilerr
@@ -112,7 +168,7 @@ func (r *SchedulerHandler) Reconcile(ctx context.Context, sender reconciler.Even
 				return fmt.Errorf("failed to read PID from file %s: %w", fsEvent.Name, err)
 			}
 
-			err = r.handleVMStart(ctx, vmID, pid)
+			err = r.handleVMStart(ctx, sender, vmID, pid)
 			if err != nil {
 				r.logger.Error(err, "Failed to handle VM start", "vmID", vmID, "pid", pid)
 
@@ -138,7 +194,7 @@ func (r *SchedulerHandler) Reconcile(ctx context.Context, sender reconciler.Even
 }
 
 // handleSyncEvent processes sync events to track VM information
-func (r *SchedulerHandler) handleSyncEvent(_ context.Context) error {
+func (r *SchedulerHandler) handleSyncEvent(ctx context.Context) error {
 	r.logger.V(1).Info("Starting VM tracking")
 
 	runningVMs, err := r.getRunningVMs()
@@ -166,11 +222,53 @@ func (r *SchedulerHandler) handleSyncEvent(_ context.Context) error {
 		}
 	}
 
+	r.validateAffinity(ctx)
+
+	r.syncPlugins(ctx)
+	r.updatePlugins(ctx)
+
+	r.synced.Store(true)
+
 	r.logVMStatus()
 
 	return nil
 }
 
+// reloadPolicy loads the policy file and applies it to the running VMs,
+// the current policy is kept if the policy file is invalid.
+func (r *SchedulerHandler) reloadPolicy(ctx context.Context) error {
+	policy, err := loadPolicy(r.policyFile, r.defaultPolicy)
+	if err != nil {
+		policyReloadsTotal.WithLabelValues(reloadResultFailed).Inc()
+		r.logger.Error(err, "Failed to reload policy, keeping the current policy")
+
+		return err
+	}
+
+	r.policy.Store(policy)
+
+	policyReloadsTotal.WithLabelValues(reloadResultSuccess).Inc()
+	r.logger.Info("Policy reloaded", "file", r.policyFile, "plugins", pluginNames(r.enabledPlugins()), "overrides", len(policy.Overrides))
+
+	return r.handleSyncEvent(ctx)
+}
+
+// pinnedVMs returns the tracked VMs with a dedicated CPU core for each vCPU
+func (r *SchedulerHandler) pinnedVMs() map[int]*VMInfo {
+	r.tracker.mu.RLock()
+	defer r.tracker.mu.RUnlock()
+
+	vms := map[int]*VMInfo{}
+
+	for vmID, vmInfo := range r.tracker.vms {
+		if vmInfo.AffinitySet.Size() > 0 && vmInfo.AffinitySet.Size() == vmInfo.Cores {
+			vms[vmID] = vmInfo
+		}
+	}
+
+	return vms
+}
+
 // getRunningVMs scans the PID directory and returns a map of VM ID to PID for running VMs
 func (r *SchedulerHandler) getRunningVMs() (map[int]int, error) {
 	runningVMs := make(map[int]int)
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// TrackerStatus is the JSON dump of the VM tracker
+type TrackerStatus struct {
+	UsedCPUs   string     `json:"usedcpus"`
+	SharedCPUs string     `json:"sharedcpus"`
+	VMs        []VMStatus `json:"vms"`
+}
+
+// VMStatus is the JSON dump of a tracked VM
+type VMStatus struct {
+	VMID     int    `json:"vmid"`
+	PID      int    `json:"pid"`
+	Name     string `json:"name"`
+	Cores    int    `json:"cores"`
+	Affinity string `json:"affinity,omitempty"`
+	Assigned string `json:"assigned,omitempty"`
+	Power    string `json:"power,omitempty"`
+}
+
+// newServer returns the HTTP server with the metrics, health probes and the VM tracker dump
+func newServer(addr string, handler *SchedulerHandler) *http.Server {
+	mux := http.NewServeMux()
+
+	mux.Handle("/metrics", promhttp.HandlerFor(newMetricsRegistry(handler), promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !handler.synced.Load() {
+			http.Error(w, "VM tracker is not synced yet", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Write([]byte("ok")) //nolint:errcheck
+	})
+	mux.HandleFunc("/debug/vms", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(handler.trackerStatus()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// trackerStatus returns the state of the VM tracker
+func (r *SchedulerHandler) trackerStatus() TrackerStatus {
+	r.tracker.mu.RLock()
+	defer r.tracker.mu.RUnlock()
+
+	status := TrackerStatus{
+		UsedCPUs:   r.tracker.usedCPUs.String(),
+		SharedCPUs: r.tracker.sharedCPUs.String(),
+		VMs:        make([]VMStatus, 0, len(r.tracker.vms)),
+	}
+
+	for vmID, vmInfo := range r.tracker.vms {
+		status.VMs = append(status.VMs, VMStatus{
+			VMID:     vmID,
+			PID:      vmInfo.PID,
+			Name:     vmInfo.Name,
+			Cores:    vmInfo.Cores,
+			Affinity: vmInfo.AffinitySet.String(),
+			Assigned: vmInfo.AssignedSet.String(),
+			Power:    vmInfo.PowerProfile,
+		})
+	}
+
+	slices.SortFunc(status.VMs, func(a, b VMStatus) int {
+		return a.VMID - b.VMID
+	})
+
+	return status
+}
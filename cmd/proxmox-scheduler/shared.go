@@ -0,0 +1,143 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+
+	"k8s.io/utils/cpuset"
+)
+
+// updateSharedCPUs recomputes the CPUs pinned to VMs and the CPUs shared among VMs without affinity.
+// The caller must hold the tracker lock.
+func (r *SchedulerHandler) updateSharedCPUs() {
+	usedCPUs := cpuset.New()
+	for _, vmInfo := range r.tracker.vms {
+		usedCPUs = usedCPUs.Union(vmInfo.AffinitySet)
+	}
+
+	r.tracker.usedCPUs = usedCPUs
+
+	if r.topology != nil {
+		r.tracker.sharedCPUs = r.topology.CPUDetails.CPUs().Difference(usedCPUs)
+	}
+}
+
+// rebalanceSharedCPUs constrains the threads of VMs without CPU affinity
+// and the host cgroup slices to the shared CPUs.
+func (r *SchedulerHandler) rebalanceSharedCPUs(ctx context.Context, cgroup CgroupPolicy) {
+	if r.topology == nil {
+		return
+	}
+
+	r.tracker.mu.RLock()
+
+	sharedCPUs := r.tracker.sharedCPUs
+	if sharedCPUs.IsEmpty() {
+		// All CPUs are pinned, the VMs without affinity have to compete with the pinned ones
+		sharedCPUs = r.topology.CPUDetails.CPUs()
+	}
+
+	vms := map[int]int{}
+
+	for vmID, vmInfo := range r.tracker.vms {
+		if vmInfo.AffinitySet.IsEmpty() && !vmInfo.AssignedSet.Equals(sharedCPUs) && r.vmPolicy(vmInfo.Tags).Cgroup.Enabled {
+			vms[vmID] = vmInfo.PID
+		}
+	}
+
+	r.tracker.mu.RUnlock()
+
+	for _, slice := range cgroup.SharedCPUsSlices {
+		if err := utilsys.SetCgroupCPUSet(slice, sharedCPUs, cpuset.New()); err != nil {
+			r.logger.Error(err, "Failed to set shared CPUs for cgroup slice", "slice", slice)
+		}
+	}
+
+	assigned := map[int]bool{}
+
+	for vmID, pid := range vms {
+		if err := r.pinSharedVM(ctx, vmID, pid, sharedCPUs); err != nil {
+			pinningErrorsTotal.WithLabelValues(pinningErrorShared).Inc()
+
+			r.logger.Error(err, "Failed to set shared CPUs for VM", "vmID", vmID)
+
+			continue
+		}
+
+		assigned[vmID] = true
+	}
+
+	if len(assigned) == 0 {
+		return
+	}
+
+	r.tracker.mu.Lock()
+	defer r.tracker.mu.Unlock()
+
+	for vmID := range assigned {
+		if vmInfo, ok := r.tracker.vms[vmID]; ok && vmInfo.AffinitySet.IsEmpty() {
+			vmInfo.AssignedSet = sharedCPUs
+		}
+	}
+}
+
+// pinSharedVM sets the cpuset of the VM scope and the affinity of all VM threads to the shared CPUs
+func (r *SchedulerHandler) pinSharedVM(ctx context.Context, vmID int, pid int, cpus cpuset.CPUSet) error {
+	r.logger.V(1).Info("VM constraining threads to shared CPUs", "vmID", vmID, "cores", cpus.String())
+
+	if err := utilsys.SetCgroupCPUSet(utilsys.VMCgroupPath(vmID), cpus, cpuset.New()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.logger.Error(err, "Failed to set VM cgroup cpuset", "vmID", vmID)
+	}
+
+	threads, err := utilsys.GetProcessThreads(pid, "")
+	if err != nil {
+		return err
+	}
+
+	vhostThreads, err := utilsys.GetVhostThreads(pid)
+	if err != nil {
+		r.logger.Error(err, "Failed to get vhost threads", "vmID", vmID, "pid", pid)
+	}
+
+	return utilsys.SetThreadsAffinity(vmID, append(threads, vhostThreads...), cpus)
+}
+
+// cgroupPlugin constrains the VMs without CPU affinity and the host cgroup slices to the shared CPUs
+type cgroupPlugin struct {
+	basePlugin
+
+	r *SchedulerHandler
+}
+
+func (p *cgroupPlugin) Name() string {
+	return PluginCgroup
+}
+
+func (p *cgroupPlugin) Enabled(policy *PluginsPolicy) bool {
+	return policy.Cgroup.Enabled
+}
+
+func (p *cgroupPlugin) Update(ctx context.Context) error {
+	p.r.rebalanceSharedCPUs(ctx, p.r.policy.Load().Plugins.Cgroup)
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"runtime"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/resourcemanager/settings"
+	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
+)
+
+func buildNodeTopology(serverInfo *info.MachineInfo, tp *topology.Topology, devices []utilsys.PciDevice) *settings.NodeTopology {
+	nodeTopology := &settings.NodeTopology{
+		Version:   settings.NodeTopologyVersion,
+		Arch:      runtime.GOARCH,
+		CPUs:      make([]settings.CPUTopology, 0, len(tp.CPUDetails)),
+		NUMANodes: make(map[int]settings.NUMATopology, len(serverInfo.Topology)),
+	}
+
+	for cpu, details := range tp.CPUDetails {
+		nodeTopology.CPUs = append(nodeTopology.CPUs, settings.CPUTopology{
+			ID:          cpu,
+			CoreID:      details.CoreID,
+			SocketID:    details.SocketID,
+			NUMANodeID:  details.NUMANodeID,
+			UncoreCache: details.UncoreCacheID,
+		})
+	}
+
+	for _, node := range serverInfo.Topology {
+		numa := settings.NUMATopology{
+			MemSize: node.Memory,
+		}
+
+		for _, hp := range node.HugePages {
+			if hp.NumPages == 0 {
+				continue
+			}
+
+			if numa.HugePages == nil {
+				numa.HugePages = make(map[uint64]uint64, len(node.HugePages))
+			}
+
+			numa.HugePages[hp.PageSize] = hp.NumPages
+		}
+
+		nodeTopology.NUMANodes[node.Id] = numa
+	}
+
+	if len(nodeTopology.NUMANodes) == 0 {
+		nodeTopology.NUMANodes[0] = settings.NUMATopology{
+			MemSize: serverInfo.MemoryCapacity,
+		}
+	}
+
+	for _, dev := range devices {
+		nodeTopology.PCIDevices = append(nodeTopology.PCIDevices, settings.PCIDevice{
+			Address:    dev.Address,
+			Vendor:     dev.Vendor,
+			Device:     dev.Device,
+			Class:      dev.Class,
+			NUMANodeID: dev.NUMANode,
+		})
+	}
+
+	return nodeTopology
+}
@@ -22,13 +22,14 @@ import (
 
 	"github.com/luthermonson/go-proxmox"
 
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/reconciler"
 	utilsys "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/sys"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
 
 	"k8s.io/utils/cpuset"
 )
 
-func (r *SchedulerHandler) handleVMStart(ctx context.Context, vmID int, pid int) error {
+func (r *SchedulerHandler) handleVMStart(ctx context.Context, sender reconciler.EventSender, vmID int, pid int) error {
 	if !utilsys.ProcessExists(pid) {
 		r.logger.Info("Warning: VM does not exist or is not accessible", "vmID", vmID, "pid", pid)
 
@@ -48,13 +49,35 @@ func (r *SchedulerHandler) handleVMStart(ctx context.Context, vmID int, pid int)
 		return fmt.Errorf("VM %d has no CPU threads yet", vmID)
 	}
 
+	cmdlineArgs, err := utilsys.GetProcessCmdline(pid)
+	if err != nil {
+		r.logger.Error(err, "Failed to get VM process cmdline", "vmID", vmID, "pid", pid)
+	}
+
+	incoming := vmconfig.IsIncomingMigration(cmdlineArgs)
+
 	vmConfig, err := vmconfig.LoadVMConfig(vmID)
+	if incoming && isMigrating(vmConfig, err) {
+		r.waitForMigration(ctx, sender, vmID, pid)
+
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
 
 	r.logger.Info("VM config loaded", "vmID", vmID, "name", vmConfig.Name,
-		"memoryMB", vmConfig.Memory, "cores", vmConfig.Cores)
+		"memoryMB", vmConfig.Memory, "cores", vmConfig.Cores, "threadCount", len(threads))
+
+	vm := &VMContext{
+		VMID:        vmID,
+		PID:         pid,
+		Config:      vmConfig,
+		CmdlineArgs: cmdlineArgs,
+		CPUs:        cpuset.New(),
+		Policy:      r.vmPolicy(vmConfig.Tags),
+	}
 
 	if vmConfig.Affinity != "" {
 		cpus, err := cpuset.Parse(vmConfig.Affinity)
@@ -65,109 +88,96 @@ func (r *SchedulerHandler) handleVMStart(ctx context.Context, vmID int, pid int)
 		}
 
 		if vmConfig.Cores == cpus.Size() {
-			r.logger.Info("VM pinning CPU threads to cores", "vmID", vmID, "threadCount", len(threads), "cores", cpus.String())
-
-			if r.topology != nil && r.topology.CPUDetails.CPUs().Intersection(cpus).Size() != cpus.Size() {
-				r.logger.Error(fmt.Errorf("topology mismatch"), "Failed to pin VM to cores",
-					"vmID", vmID,
-					"affinity", vmConfig.Affinity,
-					"topologyCPUs", r.topology.CPUDetails.CPUs().String(),
-				)
-
-				// Return nil to avoid retrying, as this is a configuration issue
-				// It can be fixed in the VM configuration when the VM is stopped and then restarted
-				return nil
-			}
+			if conflict := r.affinityConflict(vmID, cpus); conflict != nil {
+				repair := vm.Policy.Pinning.Enabled && vm.Policy.Pinning.RepairAffinity
 
-			err = utilsys.PinThreadsToCores(ctx, vmID, pid, threads, cpus.UnsortedList())
-			if err != nil {
-				r.logger.Error(err, "Failed to pin VM threads to cores", "vmID", vmID)
-			}
+				r.reportAffinityConflict(conflict, incoming || repair)
 
-			if *cpuGovernorBusy != "" {
-				r.logger.Info("VM governing CPUs", "vmID", vmID, "governor", *cpuGovernorBusy, "cores", cpus.String())
+				switch {
+				case incoming:
+					r.logger.Info("VM live-migrated with conflicting CPU affinity, re-planning", "vmID", vmID, "affinity", vmConfig.Affinity)
 
-				err = utilsys.SetCPUGovernor(vmID, cpus.List(), *cpuGovernorBusy)
-				if err != nil {
-					r.logger.Error(err, "Failed to set CPU governor for VM", "vmID", vmID)
-				}
-			}
-		}
+					newCPUs, err := r.replanVMAffinity(ctx, vmID, vmConfig)
+					if err != nil {
+						pinningErrorsTotal.WithLabelValues(pinningErrorMigration).Inc()
+
+						r.logger.Error(err, "Failed to re-plan CPU affinity for migrated VM", "vmID", vmID)
+					} else {
+						migrationReplansTotal.Inc()
 
-		pci := vmConfig.MergeHostPCIs()
-		if len(pci) > 0 {
-			cmdlineArgs, err := utilsys.GetProcessCmdline(pid)
-			if err != nil {
-				r.logger.Error(err, "Failed to get VM process cmdline", "vmID", vmID, "pid", pid)
-			} else {
-				vfioPciDevices := vmconfig.ParseVfioPciDevices(cmdlineArgs)
-				if len(vfioPciDevices) > 0 {
-					r.logger.Info("VM has PCI devices found", "vmID", vmID, "devices", vfioPciDevices)
-
-					for _, device := range vfioPciDevices {
-						irqs, err := utilsys.GetPciDeviceIRQs(device.HostAddress)
-						if err != nil {
-							r.logger.Error(err, "Failed to find IRQs for PCI device", "vmID", vmID, "device", device.HostAddress)
-
-							continue
-						}
-
-						if len(irqs) > 0 {
-							r.logger.Info("VM setting IRQ affinity", "vmID", vmID, "device", device.HostAddress, "irqs", irqs, "cpus", cpus.String())
-
-							err = utilsys.SetPciIRQAffinity(vmID, device.HostAddress, irqs, cpus)
-							if err != nil {
-								r.logger.Error(err, "Failed to set IRQ affinity for PCI device", "vmID", vmID, "device", device.HostAddress)
-							}
-						}
+						r.logger.Info("VM CPU affinity re-planned", "vmID", vmID, "oldAffinity", vmConfig.Affinity, "affinity", newCPUs.String())
+
+						cpus = newCPUs
+						vmConfig.Affinity = cpus.String()
+					}
+				case repair:
+					if newCPUs, err := r.repairAffinity(ctx, vmID, vmConfig); err == nil {
+						cpus = newCPUs
 					}
 				}
 			}
 		}
+
+		if vmConfig.Cores == cpus.Size() && r.topology != nil && !cpus.IsSubsetOf(r.topology.CPUDetails.CPUs()) {
+			pinningErrorsTotal.WithLabelValues(pinningErrorTopology).Inc()
+
+			r.logger.Error(fmt.Errorf("topology mismatch"), "Failed to pin VM to cores",
+				"vmID", vmID,
+				"affinity", vmConfig.Affinity,
+				"topologyCPUs", r.topology.CPUDetails.CPUs().String(),
+			)
+
+			// Return nil to avoid retrying, as this is a configuration issue
+			// It can be fixed in the VM configuration when the VM is stopped and then restarted
+			return nil
+		}
+
+		vm.CPUs = cpus
 	}
 
+	r.startVMPlugins(ctx, vm)
+
 	if err := r.updateVMInfo(vmID, pid, vmConfig); err != nil {
 		r.logger.Error(err, "Failed to update VM info", "vmID", vmID)
 	}
 
+	r.updatePlugins(ctx)
+
 	return nil
 }
 
 // handleVMStop handles when a VM stops (PID file removed)
-func (r *SchedulerHandler) handleVMStop(_ context.Context, vmID int) error {
+func (r *SchedulerHandler) handleVMStop(ctx context.Context, vmID int) error {
 	r.logger.Info("Handling VM stop", "vmID", vmID)
 
-	r.tracker.mu.Lock()
-	defer r.tracker.mu.Unlock()
-
-	if _, ok := r.tracker.vms[vmID]; !ok {
+	vmInfo := r.removeVMInfo(vmID)
+	if vmInfo == nil {
 		r.logger.Info("VM not found in tracker, skipping cleanup", "vmID", vmID)
 
 		return nil
 	}
 
-	cpus := r.tracker.vms[vmID].AffinitySet
-	for id, vmInfo := range r.tracker.vms {
-		if id == vmID {
-			continue
-		}
+	r.stopVMPlugins(ctx, vmInfo)
+	r.updatePlugins(ctx)
 
-		if vmInfo.AffinitySet.Size() > 0 {
-			cpus = cpus.Difference(vmInfo.AffinitySet)
-		}
-	}
+	return nil
+}
 
-	if cpus.Size() > 0 && *cpuGovernorFree != "" {
-		r.logger.Info("VM governing CPUs", "vmID", vmID, "governor", *cpuGovernorFree, "cores", cpus.String())
+// removeVMInfo updates the tracker when a VM stops, it returns nil if the VM is not tracked
+func (r *SchedulerHandler) removeVMInfo(vmID int) *VMInfo {
+	r.tracker.mu.Lock()
+	defer r.tracker.mu.Unlock()
 
-		if err := utilsys.SetCPUGovernor(vmID, cpus.List(), *cpuGovernorFree); err != nil {
-			r.logger.Error(err, "Failed to set CPU governor for VM", "vmID", vmID)
-		}
+	vmInfo, ok := r.tracker.vms[vmID]
+	if !ok {
+		return nil
 	}
 
 	delete(r.tracker.vms, vmID)
 
-	return nil
+	r.updateSharedCPUs()
+
+	return vmInfo
 }
 
 // updateVMInfo updates the tracker when a VM starts
@@ -176,10 +186,14 @@ func (r *SchedulerHandler) updateVMInfo(vmID int, pid int, vmConfig *proxmox.Vir
 	defer r.tracker.mu.Unlock()
 
 	vmInfo := &VMInfo{
-		VMID:  vmID,
-		PID:   pid,
-		Cores: vmConfig.Cores,
-		Name:  vmConfig.Name,
+		VMID:   vmID,
+		PID:    pid,
+		Cores:  vmConfig.Cores,
+		Name:   vmConfig.Name,
+		Memory: uint64(vmConfig.Memory) * 1024 * 1024,
+		Tags:   vmConfig.Tags,
+
+		PowerProfile: vmPowerProfileName(vmConfig),
 	}
 
 	if vmConfig.Affinity != "" {
@@ -190,15 +204,12 @@ func (r *SchedulerHandler) updateVMInfo(vmID int, pid int, vmConfig *proxmox.Vir
 
 		if affinitySet.Size() > 0 {
 			vmInfo.AffinitySet = affinitySet
-			r.tracker.usedCPUs = r.tracker.usedCPUs.Union(affinitySet)
 		}
 	}
 
 	r.tracker.vms[vmID] = vmInfo
 
-	if r.topology != nil {
-		r.tracker.sharedCPUs = r.topology.CPUDetails.CPUs().Difference(r.tracker.usedCPUs)
-	}
+	r.updateSharedCPUs()
 
 	return nil
 }
@@ -49,6 +49,15 @@ const (
 	// AnnotationProxmoxCloudInitToken is the annotation key for the kubelet bootstrap token id
 	AnnotationProxmoxCloudInitToken = apis.Group + "/proxmoxcloudinit-token"
 
+	// AnnotationBootstrapTokenNodeName is the annotation key for the node name the bootstrap token is bound to
+	AnnotationBootstrapTokenNodeName = apis.Group + "/bootstrap-token-node-name"
+
+	// AnnotationBootstrapTokenProviderID is the annotation key for the provider ID the bootstrap token is bound to
+	AnnotationBootstrapTokenProviderID = apis.Group + "/bootstrap-token-provider-id"
+
+	// AnnotationProxmoxInstanceAddresses is the annotation key for the static IP addresses of the instance
+	AnnotationProxmoxInstanceAddresses = apis.Group + "/instance-addresses"
+
 	// AnnotationProxmoxNodeInPlaceUpdateHash is the annotation key for the hash of the in-place update
 	AnnotationProxmoxNodeInPlaceUpdateHash = apis.Group + "/proxmoxnodeinplaceupdate-hash"
 )
@@ -31,9 +31,21 @@ const (
 	LabelInstanceCPUType = apis.Group + "/instance-cpu-type" // host, kvm64, Broadwell, Skylake
 	// LabelInstanceImageID is the image ID
 	LabelInstanceImageID = apis.Group + "/instance-image-id" // image ID
+	// LabelInstanceNUMANodes is the number of host NUMA nodes the VM CPUs and memory are bound to
+	LabelInstanceNUMANodes = apis.Group + "/instance-numa-nodes" // 1, 2
+	// LabelInstanceNUMAAligned is true if the VM CPUs and memory are bound to a single host NUMA node
+	LabelInstanceNUMAAligned = apis.Group + "/instance-numa-aligned" // true, false
 
 	// LabelBootstrapToken is the bootstrap token name used to join the node to the cluster
 	LabelBootstrapToken = apis.Group + "/bootstrap-token" // bootstrap token
+	// LabelBootstrapTokenNodeClaim is the hash of the NodeClaim name the bootstrap token is issued for,
+	// the NodeClaim name can be longer than a label value
+	LabelBootstrapTokenNodeClaim = apis.Group + "/bootstrap-token-nodeclaim"
+
+	// LabelInstanceMetadata is the label of secrets which store the instance metadata for the metadata service
+	LabelInstanceMetadata = apis.Group + "/instance-metadata"
+	// LabelInstanceUUID is the Proxmox VM UUID
+	LabelInstanceUUID = apis.Group + "/instance-uuid"
 
 	// LabelNodeViewer is the label used by github.com/awslabs/eks-node-viewer
 	LabelNodeViewer = "eks-node-viewer/instance-price"
@@ -45,5 +57,7 @@ func init() {
 		LabelInstanceFamily,
 		LabelInstanceCPUType,
 		LabelInstanceImageID,
+		LabelInstanceNUMANodes,
+		LabelInstanceNUMAAligned,
 	)
 }
@@ -32,6 +32,31 @@ const (
 	PlacementStrategyAvailabilityFirst = "AvailabilityFirst"
 	// PlacementStrategyBalanced strategy prioritizes even distribution across zones
 	PlacementStrategyBalanced = "Balanced"
+	// PlacementStrategyScored strategy places nodes to the zone with the best score of memory, CPU, storage and NodePool spread
+	PlacementStrategyScored = "Scored"
+
+	// ZoneSpreadDoNotSchedule does not use zones which exceed the max skew
+	ZoneSpreadDoNotSchedule = "DoNotSchedule"
+	// ZoneSpreadScheduleAnyway uses zones which exceed the max skew as the last resort
+	ZoneSpreadScheduleAnyway = "ScheduleAnyway"
+
+	// MetadataOptionsTypeNone does not expose the instance metadata
+	MetadataOptionsTypeNone = "none"
+	// MetadataOptionsTypeCDRom attaches the instance metadata as an ISO image
+	MetadataOptionsTypeCDRom = "cdrom"
+	// MetadataOptionsTypeSnippets stores the instance metadata on a snippets storage and references it by cicustom
+	MetadataOptionsTypeSnippets = "snippets"
+	// MetadataOptionsTypeHTTP serves the instance metadata by the built-in NoCloud HTTP metadata service
+	MetadataOptionsTypeHTTP = "http"
+	// MetadataOptionsTypeFWCfg passes the Ignition config to the VMs by QEMU fw_cfg
+	MetadataOptionsTypeFWCfg = "fwcfg"
+
+	// MetadataOptionsFormatCloudInit renders cloud-init user-data
+	MetadataOptionsFormatCloudInit = "cloud-init"
+	// MetadataOptionsFormatIgnition renders Ignition config, used by Flatcar and Fedora CoreOS
+	MetadataOptionsFormatIgnition = "ignition"
+	// MetadataOptionsFormatTalos renders Talos machine config
+	MetadataOptionsFormatTalos = "talos"
 
 	// ResourceZones names for ProxmoxNodeClass status
 	ResourceZones corev1.ResourceName = "zones"
@@ -61,6 +86,7 @@ type ProxmoxNodeClass struct {
 }
 
 // ProxmoxNodeClassSpec defines the desired state of ProxmoxNodeClass
+// +kubebuilder:validation:XValidation:rule="!(has(self.memoryBalloon) && has(self.hugepages))",message="memoryBalloon cannot be used with hugepages"
 type ProxmoxNodeClassSpec struct {
 	// Region is the Proxmox Cloud region where nodes will be created
 	// +kubebuilder:validation:MinLength=1
@@ -72,6 +98,27 @@ type ProxmoxNodeClassSpec struct {
 	// +optional
 	PlacementStrategy *PlacementStrategy `json:"placementStrategy,omitempty"`
 
+	// ZoneSelector restricts the zones (Proxmox nodes) where the nodes can be created.
+	// If not specified, all zones with the instance template and boot device storage are used.
+	// +optional
+	ZoneSelector *ZoneSelector `json:"zoneSelector,omitempty" hash:"ignore"`
+
+	// Overcommit limits the host overcommit tolerated by the VMs of the node class.
+	// The host overcommit is configured by the node settings, the node class cannot increase it.
+	// +optional
+	Overcommit *Overcommit `json:"overcommit,omitempty" hash:"ignore"`
+
+	// MemoryBalloon enables the balloon device of the VMs.
+	// Only the minimum memory is committed on the host, the rest is shared with other VMs.
+	// +optional
+	MemoryBalloon *MemoryBalloon `json:"memoryBalloon,omitempty"`
+
+	// Hugepages backs the VM memory by the host hugepages of the size in MiB.
+	// The hugepages must be preallocated on the Proxmox nodes.
+	// +kubebuilder:validation:Enum="2";"1024";"any"
+	// +optional
+	Hugepages string `json:"hugepages,omitempty"`
+
 	// InstanceTemplateRef is the template reference for the VM template
 	// +required
 	InstanceTemplateRef *InstanceTemplateClassReference `json:"instanceTemplateRef,omitempty"`
@@ -119,10 +166,140 @@ type PlacementStrategy struct {
 	// Valid values are:
 	// - "Balanced" (default) - Nodes are evenly distributed across zones
 	// - "AvailabilityFirst" - Prioritize zone availability over even distribution
+	// - "Scored" - Nodes are placed to the zone with the best score, see ScoreWeights
 	// +kubebuilder:default=Balanced
-	// +kubebuilder:validation:Enum=Balanced;AvailabilityFirst
+	// +kubebuilder:validation:Enum=Balanced;AvailabilityFirst;Scored
 	// +optional
 	ZoneBalance string `json:"zoneBalance,omitempty"`
+
+	// ScoreWeights are the weights of the signals used by the Scored strategy.
+	// +optional
+	ScoreWeights *PlacementScoreWeights `json:"scoreWeights,omitempty" hash:"ignore"`
+
+	// ZoneSpread spreads the nodes of the same NodePool (or the same label set) across zones.
+	// +optional
+	ZoneSpread *ZoneSpread `json:"zoneSpread,omitempty" hash:"ignore"`
+
+	// ZoneWeights is the relative weight of the zones, the default weight of a zone is 100.
+	// Zones with a higher weight are preferred, a zone with weight 200 is considered
+	// as loaded half as much as a zone with the default weight.
+	// +kubebuilder:validation:MaxProperties:=64
+	// +kubebuilder:validation:XValidation:rule="self.all(k, self[k] >= 1 && self[k] <= 1000)",message="zone weight must be between 1 and 1000"
+	// +optional
+	ZoneWeights map[string]int32 `json:"zoneWeights,omitempty" hash:"ignore"`
+}
+
+// Overcommit defines the maximum host overcommit ratios in percent, 100 means no overcommit.
+type Overcommit struct {
+	// CPU is the maximum ratio of virtual CPUs to physical CPUs of the host in percent.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=1600
+	// +optional
+	CPU *int32 `json:"cpu,omitempty"`
+
+	// Memory is the maximum ratio of VM memory to host memory in percent.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=400
+	// +optional
+	Memory *int32 `json:"memory,omitempty"`
+}
+
+// MemoryBalloon defines the balloon device settings of the VMs.
+type MemoryBalloon struct {
+	// MinMemory is the guaranteed memory of the VM in percent of the instance type memory.
+	// +kubebuilder:validation:Minimum=10
+	// +kubebuilder:validation:Maximum=100
+	// +required
+	MinMemory int32 `json:"minMemory"`
+}
+
+// ZoneSpread defines how nodes of the same group are spread across zones.
+type ZoneSpread struct {
+	// MaxSkew is the maximum allowed difference of the number of nodes
+	// between the zone and the zone with the least number of nodes.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+
+	// LabelKeys is the list of node label keys which define the group of nodes.
+	// Nodes with the same label values as the NodeClaim are counted.
+	// If not specified, the nodes of the same NodePool are counted.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	LabelKeys []string `json:"labelKeys,omitempty"`
+
+	// WhenUnsatisfiable indicates how to deal with the zones which exceed the skew.
+	// Valid values are:
+	// - "DoNotSchedule" (default) - the zones are not used
+	// - "ScheduleAnyway" - the zones are used only if other zones fail
+	// +kubebuilder:default=DoNotSchedule
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +optional
+	WhenUnsatisfiable string `json:"whenUnsatisfiable,omitempty"`
+}
+
+// PlacementScoreWeights defines the weights of the zone score signals, a zero weight disables the signal.
+type PlacementScoreWeights struct {
+	// Memory is the weight of the free memory which can be allocated for new VMs.
+	// +kubebuilder:default=40
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Memory *int32 `json:"memory,omitempty"`
+
+	// HostMemory is the weight of the host memory usage, including VMs not managed by Karpenter.
+	// +kubebuilder:default=20
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HostMemory *int32 `json:"hostMemory,omitempty"`
+
+	// HostCPU is the weight of the host CPU usage.
+	// +kubebuilder:default=20
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HostCPU *int32 `json:"hostCPU,omitempty"`
+
+	// Storage is the weight of the free space of the boot device storage.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Storage *int32 `json:"storage,omitempty"`
+
+	// NodePool is the weight of the number of nodes of the same NodePool in the zone.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	NodePool *int32 `json:"nodePool,omitempty"`
+}
+
+// ZoneSelector defines which zones (Proxmox nodes) can be used by the node class.
+// All specified rules must match the zone.
+type ZoneSelector struct {
+	// Include is the list of zones allowed for the node class.
+	// +kubebuilder:validation:MaxItems:=64
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is the list of zones which cannot be used by the node class.
+	// It takes precedence over the other rules.
+	// +kubebuilder:validation:MaxItems:=64
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// NodeTags is the list of Proxmox node tags, the zone must have all of them.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	NodeTags []string `json:"nodeTags,omitempty"`
+
+	// HAGroups is the list of Proxmox HA groups, the zone must be a member of at least one of them.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	HAGroups []string `json:"haGroups,omitempty"`
 }
 
 // KubeletConfiguration defines args to be used when configuring kubelet on provisioned nodes.
@@ -246,17 +423,34 @@ type InstanceTemplateClassReference struct {
 
 // MetadataOptions contains parameters for specifying the exposure of the
 // Instance Metadata Service to provisioned VMs.
+// +kubebuilder:validation:XValidation:rule="!(self.type in ['snippets', 'fwcfg']) || has(self.snippetsStorage)",message="snippetsStorage is required when type is snippets or fwcfg"
+// +kubebuilder:validation:XValidation:rule="self.type != 'fwcfg' || (has(self.format) && self.format == 'ignition')",message="type fwcfg supports only ignition format"
 type MetadataOptions struct {
-	// If specified, the instance metadata will be exposed to the VMs by CDRom
-	// or virtual machine template.
+	// If specified, the instance metadata will be exposed to the VMs by CDRom,
+	// snippets storage, HTTP metadata service, QEMU fw_cfg or virtual machine template.
 	// +kubebuilder:default=none
-	// +kubebuilder:validation:Enum:={none,cdrom}
+	// +kubebuilder:validation:Enum:={none,cdrom,snippets,http,fwcfg}
 	// +optional
 	Type string `json:"type,omitempty"`
 
+	// Format is the bootstrap format of the rendered user-data.
+	// Valid values are:
+	// - "cloud-init" (default) - cloud-init user-data
+	// - "ignition" - Ignition config for Flatcar and Fedora CoreOS
+	// - "talos" - Talos machine config
+	// +kubebuilder:default=cloud-init
+	// +kubebuilder:validation:Enum:={cloud-init,ignition,talos}
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// SnippetsStorage is the Proxmox storage ID with the snippets content type.
+	// It is used if the type is `snippets` or `fwcfg`, the storage must be shared across all zones.
+	// +optional
+	SnippetsStorage string `json:"snippetsStorage,omitempty"`
+
 	// templatesRef is a reference to the secret that contains cloud-init metadata templates.
 	// Secret must contain the following keys, each key is optional:
-	// - `user-data` - Userdata for cloud-init
+	// - `user-data` - Userdata for cloud-init, Ignition config or Talos machine config depending on the format
 	// - `meta-data` - Metadata for cloud-init
 	// - `network-config` - Network configuration for cloud-init
 	// +optional
@@ -265,6 +459,15 @@ type MetadataOptions struct {
 	// valuesRef is a reference to the secret that contains cloud-init custom template values.
 	// +optional
 	ValuesRef *corev1.SecretReference `json:"valuesRef,omitempty"`
+
+	// BootstrapTokenTTL is the lifetime of the kubelet bootstrap token.
+	// The token is revoked earlier, after the node registration or the NodeClaim deletion.
+	// +kubebuilder:default="1h"
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m') && duration(self) <= duration('24h')",message="bootstrapTokenTTL must be between 5m and 24h"
+	// +optional
+	BootstrapTokenTTL *metav1.Duration `json:"bootstrapTokenTTL,omitempty"`
 }
 
 // SecurityGroups defines a term to apply security groups
@@ -281,6 +484,59 @@ type SecurityGroups struct {
 	Name string `json:"name,omitempty"`
 }
 
+// Matches checks the zone with its Proxmox node tags and HA groups against the selector.
+func (in *ZoneSelector) Matches(zone string, tags, haGroups []string) bool {
+	if in == nil {
+		return true
+	}
+
+	if lo.Contains(in.Exclude, zone) {
+		return false
+	}
+
+	if len(in.Include) > 0 && !lo.Contains(in.Include, zone) {
+		return false
+	}
+
+	if len(in.NodeTags) > 0 && !lo.Every(tags, in.NodeTags) {
+		return false
+	}
+
+	if len(in.HAGroups) > 0 && !lo.Some(haGroups, in.HAGroups) {
+		return false
+	}
+
+	return true
+}
+
+// GetMinMemory returns the guaranteed memory in bytes for the VM memory, aligned to MiB.
+// Zero means the balloon device is disabled.
+func (in *MemoryBalloon) GetMinMemory(memory uint64) uint64 {
+	if in == nil || in.MinMemory <= 0 {
+		return 0
+	}
+
+	return min(memory, memory/100*uint64(in.MinMemory)) &^ (1024*1024 - 1)
+}
+
+// GetCPURatio returns the CPU overcommit ratio of the VMs, zero means the host ratio is used.
+func (in *Overcommit) GetCPURatio() float64 {
+	if in == nil {
+		return 0
+	}
+
+	return float64(lo.FromPtr(in.CPU)) / 100
+}
+
+// GetMemoryRatio returns the memory overcommit ratio of the VMs, zero means the host ratio is used.
+func (in *Overcommit) GetMemoryRatio() float64 {
+	if in == nil {
+		return 0
+	}
+
+	return float64(lo.FromPtr(in.Memory)) / 100
+}
+
 type inPlaceUpdateFields struct {
 	SecurityGroups []SecurityGroups `json:"securityGroups,omitempty"`
 	Tags           []string         `json:"tags,omitempty"`
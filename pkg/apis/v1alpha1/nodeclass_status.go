@@ -41,7 +41,7 @@ type ProxmoxNodeClassStatus struct {
 	Resources corev1.ResourceList `json:"resources,omitempty"`
 
 	// SelectedZones is a list of nodes that match this node class
-	// It depends on instanceTemplate and region.
+	// It depends on instanceTemplate, region and zoneSelector.
 	// This field is populated by the controller and should not be set manually.
 	// +optional
 	SelectedZones []string `json:"selectedZones,omitempty"`
@@ -23,6 +23,8 @@ import (
 	"github.com/samber/lo"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
 // ProxmoxTemplate is the Schema for the ProxmoxTemplate API
@@ -48,6 +50,10 @@ type ProxmoxTemplate struct {
 }
 
 // ProxmoxTemplateSpec defines the desired state of ProxmoxTemplateSpec
+// +kubebuilder:validation:XValidation:rule="!has(self.arch) || self.arch != 'arm64' || !has(self.machine) || self.machine == 'virt'",message="arm64 templates support only the virt machine type"
+// +kubebuilder:validation:XValidation:rule="!has(self.arch) || self.arch != 'arm64' || !has(self.bios) || self.bios == 'ovmf'",message="arm64 templates support only the ovmf bios"
+// +kubebuilder:validation:XValidation:rule="(has(self.arch) && self.arch == 'arm64') || !has(self.machine) || self.machine != 'virt'",message="virt machine type is supported only by arm64 templates"
+// +kubebuilder:validation:XValidation:rule="!has(self.cpu) || !has(self.cpu.type) || self.cpu.type in ['host', 'max'] || ((has(self.arch) && self.arch == 'arm64') != (self.cpu.type in ['kvm64', 'x86-64-v2', 'x86-64-v2-AES', 'x86-64-v3', 'x86-64-v4']))",message="cpu type does not match the template architecture"
 type ProxmoxTemplateSpec struct {
 	// Region is the Proxmox Cloud region where VM template will be created
 	// +kubebuilder:validation:MinLength=1
@@ -59,6 +65,14 @@ type ProxmoxTemplateSpec struct {
 	// +optional
 	Description string `json:"description,omitempty"`
 
+	// Arch is the CPU architecture of the VM template.
+	// The template is created only in the zones with the same architecture,
+	// so the source image must be built for this architecture.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +kubebuilder:default=amd64
+	// +optional
+	Arch string `json:"arch,omitempty"`
+
 	// SourceImage defines the source image for the VM boot disk.
 	// +required
 	SourceImage *SourceImage `json:"sourceImage" hash:"ignore"`
@@ -70,14 +84,14 @@ type ProxmoxTemplateSpec struct {
 	StorageIDs []string `json:"storageIDs" hash:"ignore"`
 
 	// Machine for the VM machine type.
-	// +kubebuilder:validation:Enum=pc;q35
-	// +kubebuilder:default=q35
+	// Defaults to q35 for amd64 and virt for arm64 templates.
+	// +kubebuilder:validation:Enum=pc;q35;virt
 	// +optional
 	Machine string `json:"machine,omitempty"`
 
 	// BIOS type for the VM template.
+	// Defaults to seabios for amd64 and ovmf for arm64 templates.
 	// +kubebuilder:validation:Enum=seabios;ovmf
-	// +kubebuilder:default=seabios
 	// +optional
 	Bios string `json:"bios,omitempty" hash:"ignore"`
 
@@ -90,7 +104,6 @@ type ProxmoxTemplateSpec struct {
 	QemuGuestAgent *QemuGuestAgent `json:"agent,omitempty"`
 
 	// CPU configuration
-	// +kubebuilder:default={"type":"x86-64-v2-AES"}
 	// +optional
 	CPU *CPU `json:"cpu,omitempty"`
 
@@ -177,8 +190,8 @@ type QemuGuestAgent struct {
 // CPU defines the CPU configuration for the VM template
 type CPU struct {
 	// Emulated CPU type.
-	// +kubebuilder:validation:Enum=host;kvm64;x86-64-v2;x86-64-v2-AES;x86-64-v3;x86-64-v4
-	// +kubebuilder:default=x86-64-v2-AES
+	// Defaults to x86-64-v2-AES for amd64 and host for arm64 templates.
+	// +kubebuilder:validation:Enum=host;max;kvm64;x86-64-v2;x86-64-v2-AES;x86-64-v3;x86-64-v4;cortex-a57;cortex-a72;cortex-a76;neoverse-n1;neoverse-n2;neoverse-v1
 	// +optional
 	Type string `json:"type,omitempty"`
 
@@ -299,6 +312,7 @@ type PCIDevice struct {
 }
 
 type hashFields struct {
+	Arch        string       `json:"arch,omitempty"`
 	SourceImage *SourceImage `json:"sourceImage"`
 	StorageIDs  []string     `json:"storageIDs"`
 	Bios        string       `json:"bios,omitempty"`
@@ -308,9 +322,10 @@ type hashFields struct {
 // Hash computes a hash of the fields that require recreation of the template
 func (in *ProxmoxTemplate) Hash() string {
 	hashStruct := &hashFields{
+		Arch:        lo.Ternary(in.GetArch() == karpv1.ArchitectureAmd64, "", in.GetArch()),
 		SourceImage: in.Spec.SourceImage,
 		StorageIDs:  in.Spec.StorageIDs,
-		Bios:        in.Spec.Bios,
+		Bios:        in.GetBios(),
 		TPM:         in.Spec.TPM,
 	}
 
@@ -321,6 +336,42 @@ func (in *ProxmoxTemplate) Hash() string {
 	})))
 }
 
+// GetArch returns the CPU architecture of the template.
+func (in *ProxmoxTemplate) GetArch() string {
+	if in.Spec.Arch == "" {
+		return karpv1.ArchitectureAmd64
+	}
+
+	return in.Spec.Arch
+}
+
+// GetMachine returns the machine type of the template, the default depends on the architecture.
+func (in *ProxmoxTemplate) GetMachine() string {
+	if in.Spec.Machine != "" {
+		return in.Spec.Machine
+	}
+
+	return lo.Ternary(in.GetArch() == karpv1.ArchitectureArm64, "virt", "q35")
+}
+
+// GetBios returns the BIOS type of the template, the default depends on the architecture.
+func (in *ProxmoxTemplate) GetBios() string {
+	if in.Spec.Bios != "" {
+		return in.Spec.Bios
+	}
+
+	return lo.Ternary(in.GetArch() == karpv1.ArchitectureArm64, "ovmf", "seabios")
+}
+
+// GetCPUType returns the emulated CPU type of the template, the default depends on the architecture.
+func (in *ProxmoxTemplate) GetCPUType() string {
+	if in.Spec.CPU != nil && in.Spec.CPU.Type != "" {
+		return in.Spec.CPU.Type
+	}
+
+	return lo.Ternary(in.GetArch() == karpv1.ArchitectureArm64, "host", "x86-64-v2-AES")
+}
+
 // InPlaceHash computes a hash of the fields that can be updated in place
 func (in *ProxmoxTemplate) InPlaceHash() string {
 	return fmt.Sprint(lo.Must(hashstructure.Hash(in.Spec, hashstructure.FormatV2, &hashstructure.HashOptions{
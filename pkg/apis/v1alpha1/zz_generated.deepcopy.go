@@ -162,6 +162,21 @@ func (in *KubeletConfiguration) DeepCopy() *KubeletConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryBalloon) DeepCopyInto(out *MemoryBalloon) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryBalloon.
+func (in *MemoryBalloon) DeepCopy() *MemoryBalloon {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryBalloon)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataOptions) DeepCopyInto(out *MetadataOptions) {
 	*out = *in
@@ -175,6 +190,11 @@ func (in *MetadataOptions) DeepCopyInto(out *MetadataOptions) {
 		*out = new(corev1.SecretReference)
 		**out = **in
 	}
+	if in.BootstrapTokenTTL != nil {
+		in, out := &in.BootstrapTokenTTL, &out.BootstrapTokenTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataOptions.
@@ -223,6 +243,31 @@ func (in *Network) DeepCopy() *Network {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Overcommit) DeepCopyInto(out *Overcommit) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Overcommit.
+func (in *Overcommit) DeepCopy() *Overcommit {
+	if in == nil {
+		return nil
+	}
+	out := new(Overcommit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PCIDevice) DeepCopyInto(out *PCIDevice) {
 	*out = *in
@@ -248,9 +293,66 @@ func (in *PCIDevice) DeepCopy() *PCIDevice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementScoreWeights) DeepCopyInto(out *PlacementScoreWeights) {
+	*out = *in
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HostMemory != nil {
+		in, out := &in.HostMemory, &out.HostMemory
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HostCPU != nil {
+		in, out := &in.HostCPU, &out.HostCPU
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodePool != nil {
+		in, out := &in.NodePool, &out.NodePool
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementScoreWeights.
+func (in *PlacementScoreWeights) DeepCopy() *PlacementScoreWeights {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementScoreWeights)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementStrategy) DeepCopyInto(out *PlacementStrategy) {
 	*out = *in
+	if in.ScoreWeights != nil {
+		in, out := &in.ScoreWeights, &out.ScoreWeights
+		*out = new(PlacementScoreWeights)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneSpread != nil {
+		in, out := &in.ZoneSpread, &out.ZoneSpread
+		*out = new(ZoneSpread)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneWeights != nil {
+		in, out := &in.ZoneWeights, &out.ZoneWeights
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementStrategy.
@@ -328,6 +430,21 @@ func (in *ProxmoxNodeClassSpec) DeepCopyInto(out *ProxmoxNodeClassSpec) {
 	if in.PlacementStrategy != nil {
 		in, out := &in.PlacementStrategy, &out.PlacementStrategy
 		*out = new(PlacementStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneSelector != nil {
+		in, out := &in.ZoneSelector, &out.ZoneSelector
+		*out = new(ZoneSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Overcommit != nil {
+		in, out := &in.Overcommit, &out.Overcommit
+		*out = new(Overcommit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemoryBalloon != nil {
+		in, out := &in.MemoryBalloon, &out.MemoryBalloon
+		*out = new(MemoryBalloon)
 		**out = **in
 	}
 	if in.InstanceTemplateRef != nil {
@@ -736,3 +853,58 @@ func (in *VGA) DeepCopy() *VGA {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneSelector) DeepCopyInto(out *ZoneSelector) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeTags != nil {
+		in, out := &in.NodeTags, &out.NodeTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HAGroups != nil {
+		in, out := &in.HAGroups, &out.HAGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSelector.
+func (in *ZoneSelector) DeepCopy() *ZoneSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneSpread) DeepCopyInto(out *ZoneSpread) {
+	*out = *in
+	if in.LabelKeys != nil {
+		in, out := &in.LabelKeys, &out.LabelKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSpread.
+func (in *ZoneSpread) DeepCopy() *ZoneSpread {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneSpread)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -59,6 +59,9 @@ type CloudProvider struct {
 	instanceTemplateProvider instancetemplate.Provider
 	cloudcapacityProvider    cloudcapacity.Provider
 
+	// memoryPressure keeps the NodeClaims drifted by the host memory pressure
+	memoryPressure *memoryPressureTracker
+
 	log logr.Logger
 }
 
@@ -80,6 +83,7 @@ func NewCloudProvider(
 		instanceTemplateProvider: instanceTemplateProvider,
 		instanceTypeProvider:     instanceTypeProvider,
 		cloudcapacityProvider:    cloudcapacityProvider,
+		memoryPressure:           newMemoryPressureTracker(),
 		log:                      log,
 	}
 }
@@ -129,9 +133,15 @@ func (c CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 
 	log.Info("Successfully created instance", "providerID", node.Spec.ProviderID)
 
-	instanceType, err := c.resolveInstanceTypeFromNode(ctx, node)
-	if err != nil {
-		log.Error(err, "Failed to resolve instance type from node", "node", node.Name)
+	// The instance types of the NodeClass contain the NodeClass specific overhead
+	instanceType, ok := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
+		return i.Name == node.Labels[corev1.LabelInstanceTypeStable]
+	})
+	if !ok {
+		instanceType, err = c.resolveInstanceTypeFromNode(ctx, node)
+		if err != nil {
+			log.Error(err, "Failed to resolve instance type from node", "node", node.Name)
+		}
 	}
 
 	nc, err := c.nodeToNodeClaim(ctx, instanceType, node)
@@ -18,6 +18,9 @@ package proxmox
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/samber/lo"
 
@@ -26,7 +29,11 @@ import (
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
@@ -34,12 +41,20 @@ import (
 const (
 	NodeClassDrift cloudprovider.DriftReason = "NodeClassDrift"
 	ImageDrift     cloudprovider.DriftReason = "ImageDrift"
+	InstanceDrift  cloudprovider.DriftReason = "InstanceDrift"
+
+	MemoryPressureDrift cloudprovider.DriftReason = "MemoryPressureDrift"
+
+	// memoryPressureDriftInterval is the interval between the NodeClaims drifted on the same host under memory pressure
+	memoryPressureDriftInterval = 5 * time.Minute
 )
 
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (cloudprovider.DriftReason, error) {
 	checks := []func(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (cloudprovider.DriftReason, error){
 		c.areStaticFieldsDrifted,
 		c.isTemplateDrifted,
+		c.isInstanceDrifted,
+		c.isMemoryPressureDrifted,
 	}
 
 	for _, check := range checks {
@@ -103,3 +118,194 @@ func (c *CloudProvider) isTemplateDrifted(ctx context.Context, nodeClaim *karpv1
 
 	return "", nil
 }
+
+// isInstanceDrifted compares the live VM configuration with the expected one.
+// Fields which support in-place update are repaired by the inplaceupdate controller,
+// so we only reset the in-place hash of the NodeClaim to trigger it.
+func (c *CloudProvider) isInstanceDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (cloudprovider.DriftReason, error) {
+	if nodeClaim.Status.ProviderID == "" || !nodeClaim.DeletionTimestamp.IsZero() {
+		return "", nil
+	}
+
+	drift, err := c.instanceProvider.GetInstanceDrift(ctx, nodeClaim, nodeClass)
+	if err != nil {
+		return "", fmt.Errorf("getting instance drift, %w", err)
+	}
+
+	if len(drift.Static) > 0 {
+		c.log.WithName("isInstanceDrifted()").Info("Instance configuration drifted", "nodeClaim", nodeClaim.Name, "fields", drift.Static)
+
+		return InstanceDrift, nil
+	}
+
+	if len(drift.InPlace) > 0 {
+		if _, ok := nodeClaim.Annotations[v1alpha1.AnnotationProxmoxNodeInPlaceUpdateHash]; !ok {
+			return "", nil
+		}
+
+		c.log.WithName("isInstanceDrifted()").Info("Repairing instance configuration in place", "nodeClaim", nodeClaim.Name, "fields", drift.InPlace)
+
+		stored := nodeClaim.DeepCopy()
+		delete(stored.Annotations, v1alpha1.AnnotationProxmoxNodeInPlaceUpdateHash)
+
+		if err := c.kubeClient.Patch(ctx, stored, client.MergeFrom(nodeClaim)); err != nil {
+			return "", client.IgnoreNotFound(err)
+		}
+	}
+
+	return "", nil
+}
+
+// isMemoryPressureDrifted replaces the nodes with memory balloon first when the host memory is under pressure.
+// The balloon does not guarantee the memory above the minimum, so such nodes are disrupted before others.
+// One node per host is drifted in the interval, the node with the largest balloon memory is the first.
+func (c *CloudProvider) isMemoryPressureDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (cloudprovider.DriftReason, error) {
+	if nodeClass.Spec.MemoryBalloon == nil || nodeClaim.Status.ProviderID == "" || !nodeClaim.DeletionTimestamp.IsZero() {
+		return "", nil
+	}
+
+	_, region, err := provider.ParseProviderID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		return "", nil //nolint: nilerr
+	}
+
+	zone := nodeClaim.Labels[corev1.LabelTopologyZone]
+
+	key := fmt.Sprintf("%s/%s", region, zone)
+
+	usage := c.cloudcapacityProvider.GetZoneUsage(region, zone)
+	if usage == nil || !usage.MemoryPressure {
+		c.memoryPressure.Reset(key)
+
+		return "", nil
+	}
+
+	drifted, err := c.memoryPressure.Drifted(key, nodeClaim.Name, func(drifted sets.Set[string]) (string, error) {
+		return c.memoryPressureCandidate(ctx, region, zone, drifted)
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting memory pressure candidate, %w", err)
+	}
+
+	if drifted {
+		c.log.WithName("isMemoryPressureDrifted()").Info("Host memory is under pressure", "nodeClaim", nodeClaim.Name, "zone", zone, "memoryUsage", usage.MemoryUsage)
+
+		return MemoryPressureDrift, nil
+	}
+
+	return "", nil
+}
+
+// memoryPressureCandidate returns the NodeClaim on the host with the largest memory above the balloon minimum,
+// the NodeClaims drifted before are skipped.
+func (c *CloudProvider) memoryPressureCandidate(ctx context.Context, region, zone string, drifted sets.Set[string]) (string, error) {
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{corev1.LabelTopologyZone: zone}); err != nil {
+		return "", err
+	}
+
+	nodeClasses := map[string]*v1alpha1.ProxmoxNodeClass{}
+
+	candidate := ""
+	candidateGap := uint64(0)
+
+	for _, nodeClaim := range nodeClaims.Items {
+		if drifted.Has(nodeClaim.Name) || nodeClaim.Status.ProviderID == "" || !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if _, r, err := provider.ParseProviderID(nodeClaim.Status.ProviderID); err != nil || r != region {
+			continue
+		}
+
+		ref := nodeClaim.Spec.NodeClassRef
+		if ref == nil {
+			continue
+		}
+
+		nodeClass, ok := nodeClasses[ref.Name]
+		if !ok {
+			nodeClass = &v1alpha1.ProxmoxNodeClass{}
+			if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: ref.Name}, nodeClass); err != nil {
+				if !errors.IsNotFound(err) {
+					return "", err
+				}
+
+				nodeClass = nil
+			}
+
+			nodeClasses[ref.Name] = nodeClass
+		}
+
+		if nodeClass == nil || nodeClass.Spec.MemoryBalloon == nil {
+			continue
+		}
+
+		memory := uint64(nodeClaim.Status.Capacity.Memory().Value())
+		gap := memory - min(memory, nodeClass.Spec.MemoryBalloon.GetMinMemory(memory))
+
+		if candidate == "" || gap > candidateGap || (gap == candidateGap && nodeClaim.Name < candidate) {
+			candidate, candidateGap = nodeClaim.Name, gap
+		}
+	}
+
+	return candidate, nil
+}
+
+// memoryPressureTracker keeps the NodeClaims drifted by the memory pressure of the hosts.
+type memoryPressureTracker struct {
+	mu    sync.Mutex
+	zones map[string]*zoneMemoryPressure
+}
+
+type zoneMemoryPressure struct {
+	// nodeClaims are the names of the drifted NodeClaims on the host
+	nodeClaims sets.Set[string]
+	// lastDrift is the time when the last NodeClaim was drifted
+	lastDrift time.Time
+}
+
+func newMemoryPressureTracker() *memoryPressureTracker {
+	return &memoryPressureTracker{
+		zones: map[string]*zoneMemoryPressure{},
+	}
+}
+
+// Drifted reports whether the NodeClaim is drifted on the host under memory pressure.
+// The next candidate of the host is drifted only after the interval since the last drift.
+func (t *memoryPressureTracker) Drifted(key, name string, candidate func(drifted sets.Set[string]) (string, error)) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	zone, ok := t.zones[key]
+	if !ok {
+		zone = &zoneMemoryPressure{nodeClaims: sets.New[string]()}
+		t.zones[key] = zone
+	}
+
+	if zone.nodeClaims.Has(name) {
+		return true, nil
+	}
+
+	if time.Since(zone.lastDrift) < memoryPressureDriftInterval {
+		return false, nil
+	}
+
+	next, err := candidate(zone.nodeClaims)
+	if err != nil || next == "" {
+		return false, err
+	}
+
+	zone.nodeClaims.Insert(next)
+	zone.lastDrift = time.Now()
+
+	return next == name, nil
+}
+
+// Reset forgets the drifted NodeClaims of the host when the memory pressure is over.
+func (t *memoryPressureTracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.zones, key)
+}
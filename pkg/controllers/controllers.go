@@ -21,6 +21,7 @@ import (
 
 	"github.com/awslabs/operatorpkg/controller"
 
+	nodecsr "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/node/csr"
 	nodeipamctl "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/node/ipam"
 	nodeclaiminplaceupdate "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/nodeclaim/inplaceupdate"
 	nodeclaimlifecycle "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/nodeclaim/lifecycle"
@@ -32,8 +33,10 @@ import (
 	nodetemplateclasstermination "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/nodetemplateclass/termination"
 	nodetemplateunmanagedclasshash "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/nodetemplateunmanagedclass/hash"
 	nodetemplateunmanagedclassstatus "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/nodetemplateunmanagedclass/status"
+	cloudcapacityallocation "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/providers/cloudcapacity/allocation"
 	cloudcapacitynode "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/providers/cloudcapacity/node"
 	cloudcapacitynodeload "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/controllers/providers/cloudcapacity/nodeload"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/bootstrap"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance"
@@ -71,9 +74,14 @@ func NewControllers(ctx context.Context, mgr manager.Manager, clk clock.Clock,
 		nodetemplateunmanagedclasshash.NewController(kubeClient),
 		nodetemplateunmanagedclassstatus.NewController(kubeClient, instanceTemplateProvider),
 		cloudcapacitynode.NewController(cloudCapacityProvider),
+		cloudcapacityallocation.NewController(cloudCapacityProvider),
 		cloudcapacitynodeload.NewController(cloudCapacityProvider, instanceTypeProvider),
 		nodeipamctl.NewController(kubeClient, nodeIpamProvider),
 	}
 
+	if options.FromContext(ctx).CSRApprover {
+		controllers = append(controllers, nodecsr.NewController(kubeClient, kubernetesBootstrapProvider, instanceProvider))
+	}
+
 	return controllers
 }
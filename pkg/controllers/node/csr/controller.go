@@ -0,0 +1,282 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+
+	proxmox "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/cloudprovider"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/bootstrap"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+)
+
+const (
+	bootstrapUserPrefix = "system:bootstrap:"
+	nodeUserPrefix      = "system:node:"
+
+	csrRepeatPeriod = 10 * time.Second
+
+	approveReason = "KarpenterProxmoxApprove"
+	denyReason    = "KarpenterProxmoxDeny"
+)
+
+// Controller approves the kubelet certificate signing requests of the Karpenter nodes.
+// The client certificate request must be signed by the bootstrap token bound to the node name,
+// the serving certificate request must match the virtual machine UUID and IP addresses.
+type Controller struct {
+	kubeClient                  client.Client
+	kubernetesBootstrapProvider bootstrap.Provider
+	instanceProvider            instance.Provider
+}
+
+// NewController constructs a controller instance
+func NewController(kubeClient client.Client, kubernetesBootstrapProvider bootstrap.Provider, instanceProvider instance.Provider) *Controller {
+	return &Controller{
+		kubeClient:                  kubeClient,
+		kubernetesBootstrapProvider: kubernetesBootstrapProvider,
+		instanceProvider:            instanceProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "node.csr"
+}
+
+// Reconcile executes a control loop for the resource
+func (c *Controller) Reconcile(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+	log := log.FromContext(ctx).WithValues("csr", csr.Name, "username", csr.Spec.Username)
+
+	if isFinished(csr) {
+		return reconcile.Result{}, nil
+	}
+
+	var err error
+
+	switch csr.Spec.SignerName {
+	case certificatesv1.KubeAPIServerClientKubeletSignerName:
+		err = c.verifyClientRequest(ctx, csr)
+	case certificatesv1.KubeletServingSignerName:
+		err = c.verifyServingRequest(ctx, csr)
+	default:
+		return reconcile.Result{}, nil
+	}
+
+	switch {
+	case err == nil:
+		log.V(1).Info("Approve certificate signing request")
+
+		return reconcile.Result{}, c.updateApproval(ctx, csr, certificatesv1.CertificateApproved, approveReason, "Approved by Karpenter Proxmox")
+	case errors.Is(err, errSkipRequest):
+		log.V(4).Info("Skip certificate signing request", "reason", err.Error())
+
+		return reconcile.Result{}, nil
+	case errors.Is(err, errRetryRequest):
+		log.V(1).Info("Certificate signing request is not ready to approve", "reason", err.Error())
+
+		return reconcile.Result{RequeueAfter: csrRepeatPeriod}, nil
+	case errors.Is(err, errDenyRequest):
+		log.Info("Deny certificate signing request", "reason", err.Error())
+
+		return reconcile.Result{}, c.updateApproval(ctx, csr, certificatesv1.CertificateDenied, denyReason, err.Error())
+	default:
+		return reconcile.Result{}, err
+	}
+}
+
+// Register registers the controller with the manager
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&certificatesv1.CertificateSigningRequest{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			csr, ok := o.(*certificatesv1.CertificateSigningRequest)
+			if !ok {
+				return false
+			}
+
+			return !isFinished(csr) &&
+				(csr.Spec.SignerName == certificatesv1.KubeAPIServerClientKubeletSignerName || csr.Spec.SignerName == certificatesv1.KubeletServingSignerName)
+		}))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func (c *Controller) verifyClientRequest(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) error {
+	req, err := parseCSR(csr.Spec.Request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errDenyRequest, err)
+	}
+
+	var nodeName string
+
+	switch {
+	case strings.HasPrefix(csr.Spec.Username, bootstrapUserPrefix):
+		binding, err := c.kubernetesBootstrapProvider.GetTokenBinding(ctx, strings.TrimPrefix(csr.Spec.Username, bootstrapUserPrefix))
+		if err != nil {
+			if errors.Is(err, bootstrap.ErrTokenNotFound) {
+				return fmt.Errorf("%w: bootstrap token is not managed by Karpenter", errSkipRequest)
+			}
+
+			return err
+		}
+
+		nodeName = binding.NodeName
+	case strings.HasPrefix(csr.Spec.Username, nodeUserPrefix):
+		// The client certificate renewal
+		nodeName = strings.TrimPrefix(csr.Spec.Username, nodeUserPrefix)
+	default:
+		return fmt.Errorf("%w: unknown requestor", errSkipRequest)
+	}
+
+	if _, err := c.getNodeClaim(ctx, nodeName); err != nil {
+		if strings.HasPrefix(csr.Spec.Username, bootstrapUserPrefix) && errors.Is(err, errSkipRequest) {
+			return fmt.Errorf("%w: nodeClaim %s of the bootstrap token does not exist", errDenyRequest, nodeName)
+		}
+
+		return err
+	}
+
+	if err := validateClientCertificate(req, csr.Spec.Usages, nodeName); err != nil {
+		return fmt.Errorf("%w: %v", errDenyRequest, err)
+	}
+
+	return nil
+}
+
+func (c *Controller) verifyServingRequest(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) error {
+	if !strings.HasPrefix(csr.Spec.Username, nodeUserPrefix) {
+		return fmt.Errorf("%w: unknown requestor", errSkipRequest)
+	}
+
+	nodeName := strings.TrimPrefix(csr.Spec.Username, nodeUserPrefix)
+
+	nodeClaim, err := c.getNodeClaim(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	req, err := parseCSR(csr.Spec.Request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errDenyRequest, err)
+	}
+
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: node %s does not exist", errRetryRequest, nodeName)
+		}
+
+		return err
+	}
+
+	identity, err := c.instanceProvider.GetInstanceIdentity(ctx, nodeClaim)
+	if err != nil {
+		return fmt.Errorf("failed to get instance identity of nodeClaim %s: %w", nodeClaim.Name, err)
+	}
+
+	if !strings.EqualFold(node.Status.NodeInfo.SystemUUID, identity.UUID) {
+		return fmt.Errorf("%w: node system UUID %s does not match the instance UUID %s", errDenyRequest, node.Status.NodeInfo.SystemUUID, identity.UUID)
+	}
+
+	if len(req.IPAddresses) > 0 && len(identity.Addresses) == 0 {
+		return fmt.Errorf("%w: instance has no static IP addresses to verify", errSkipRequest)
+	}
+
+	if err := validateServingCertificate(req, csr.Spec.Usages, nodeName, identity.Addresses); err != nil {
+		return fmt.Errorf("%w: %v", errDenyRequest, err)
+	}
+
+	return nil
+}
+
+// getNodeClaim returns the Proxmox NodeClaim of the node, the node name is the same as the NodeClaim name.
+func (c *Controller) getNodeClaim(ctx context.Context, nodeName string) (*karpv1.NodeClaim, error) {
+	nodeClaim := &karpv1.NodeClaim{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeName}, nodeClaim); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: nodeClaim %s does not exist", errSkipRequest, nodeName)
+		}
+
+		return nil, err
+	}
+
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil, fmt.Errorf("%w: nodeClaim %s is being deleted", errDenyRequest, nodeName)
+	}
+
+	if !strings.HasPrefix(nodeClaim.Status.ProviderID, proxmox.ProxmoxProviderPrefix) {
+		return nil, fmt.Errorf("%w: nodeClaim %s is not launched", errRetryRequest, nodeName)
+	}
+
+	return nodeClaim, nil
+}
+
+func (c *Controller) updateApproval(ctx context.Context, csr *certificatesv1.CertificateSigningRequest, conditionType certificatesv1.RequestConditionType, reason, message string) error {
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           conditionType,
+		Status:         corev1.ConditionTrue,
+		Reason:         reason,
+		Message:        message,
+		LastUpdateTime: metav1.Now(),
+	})
+
+	if err := c.kubeClient.SubResource("approval").Update(ctx, csr); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to update approval of csr %s: %w", csr.Name, err)
+	}
+
+	return nil
+}
+
+func isFinished(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certificatesv1.CertificateApproved || c.Type == certificatesv1.CertificateDenied || c.Type == certificatesv1.CertificateFailed {
+			return true
+		}
+	}
+
+	return false
+}
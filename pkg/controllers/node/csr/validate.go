@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+)
+
+var (
+	// errSkipRequest is returned when the request is not managed by Karpenter, it is left to other approvers
+	errSkipRequest = errors.New("skip request")
+	// errRetryRequest is returned when the request can not be verified yet
+	errRetryRequest = errors.New("retry request")
+	// errDenyRequest is returned when the request does not match the node identity
+	errDenyRequest = errors.New("deny request")
+)
+
+var (
+	clientUsages = []certificatesv1.KeyUsage{
+		certificatesv1.UsageDigitalSignature,
+		certificatesv1.UsageKeyEncipherment,
+		certificatesv1.UsageClientAuth,
+	}
+
+	servingUsages = []certificatesv1.KeyUsage{
+		certificatesv1.UsageDigitalSignature,
+		certificatesv1.UsageKeyEncipherment,
+		certificatesv1.UsageServerAuth,
+	}
+)
+
+const nodesGroup = "system:nodes"
+
+func parseCSR(data []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("PEM block type must be CERTIFICATE REQUEST")
+	}
+
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// validateClientCertificate checks the kubelet client certificate request of the node.
+func validateClientCertificate(req *x509.CertificateRequest, usages []certificatesv1.KeyUsage, nodeName string) error {
+	if err := validateSubject(req, nodeName); err != nil {
+		return err
+	}
+
+	if len(req.DNSNames) > 0 || len(req.IPAddresses) > 0 || len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return fmt.Errorf("client certificate must not have subject alternative names")
+	}
+
+	return validateUsages(usages, clientUsages)
+}
+
+// validateServingCertificate checks the kubelet serving certificate request of the node.
+func validateServingCertificate(req *x509.CertificateRequest, usages []certificatesv1.KeyUsage, nodeName string, addresses []string) error {
+	if err := validateSubject(req, nodeName); err != nil {
+		return err
+	}
+
+	if len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return fmt.Errorf("serving certificate must not have email or URI subject alternative names")
+	}
+
+	if len(req.DNSNames) == 0 && len(req.IPAddresses) == 0 {
+		return fmt.Errorf("serving certificate must have at least one subject alternative name")
+	}
+
+	for _, name := range req.DNSNames {
+		if name != nodeName && !strings.HasPrefix(name, nodeName+".") {
+			return fmt.Errorf("DNS name %s does not match the node name %s", name, nodeName)
+		}
+	}
+
+	for _, ip := range req.IPAddresses {
+		if !slices.ContainsFunc(addresses, func(addr string) bool {
+			return ip.Equal(net.ParseIP(addr))
+		}) {
+			return fmt.Errorf("IP address %s does not belong to the instance", ip.String())
+		}
+	}
+
+	return validateUsages(usages, servingUsages)
+}
+
+func validateSubject(req *x509.CertificateRequest, nodeName string) error {
+	if req.Subject.CommonName != nodeUserPrefix+nodeName {
+		return fmt.Errorf("common name %s does not match the node %s", req.Subject.CommonName, nodeName)
+	}
+
+	if len(req.Subject.Organization) != 1 || req.Subject.Organization[0] != nodesGroup {
+		return fmt.Errorf("organization must be %s", nodesGroup)
+	}
+
+	return nil
+}
+
+func validateUsages(usages []certificatesv1.KeyUsage, allowed []certificatesv1.KeyUsage) error {
+	for _, usage := range usages {
+		if !slices.Contains(allowed, usage) {
+			return fmt.Errorf("key usage %s is not allowed", usage)
+		}
+	}
+
+	return nil
+}
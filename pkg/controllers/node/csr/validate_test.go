@@ -0,0 +1,166 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+)
+
+func newCSR(t *testing.T, template *x509.CertificateRequest) *x509.CertificateRequest {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	assert.NoError(t, err)
+
+	req, err := parseCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+	assert.NoError(t, err)
+
+	return req
+}
+
+func TestValidateClientCertificate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *x509.CertificateRequest
+		usages  []certificatesv1.KeyUsage
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req: &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			},
+			usages: []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+		},
+		{
+			name: "other-node",
+			req: &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "system:node:node-2", Organization: []string{"system:nodes"}},
+			},
+			usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+			wantErr: true,
+		},
+		{
+			name: "extra-organization",
+			req: &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes", "system:masters"}},
+			},
+			usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+			wantErr: true,
+		},
+		{
+			name: "with-san",
+			req: &x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+				DNSNames: []string{"node-1"},
+			},
+			usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+			wantErr: true,
+		},
+		{
+			name: "server-usage",
+			req: &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			},
+			usages:  []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateClientCertificate(newCSR(t, tt.req), tt.usages, "node-1")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateServingCertificate(t *testing.T) {
+	addresses := []string{"192.168.0.10", "2001:db8::10"}
+	usages := []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageServerAuth}
+
+	tests := []struct {
+		name    string
+		req     *x509.CertificateRequest
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req: &x509.CertificateRequest{
+				Subject:     pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+				DNSNames:    []string{"node-1", "node-1.example.com"},
+				IPAddresses: []net.IP{net.ParseIP("192.168.0.10"), net.ParseIP("2001:db8::10")},
+			},
+		},
+		{
+			name: "foreign-ip",
+			req: &x509.CertificateRequest{
+				Subject:     pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+				IPAddresses: []net.IP{net.ParseIP("192.168.0.11")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "foreign-dns",
+			req: &x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+				DNSNames: []string{"kubernetes.default"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "without-san",
+			req: &x509.CertificateRequest{
+				Subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateServingCertificate(newCSR(t, tt.req), usages, "node-1", addresses)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
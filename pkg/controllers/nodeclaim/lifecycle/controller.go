@@ -22,6 +22,7 @@ import (
 	"github.com/awslabs/operatorpkg/reasonable"
 	"go.uber.org/multierr"
 
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/bootstrap"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance"
 
@@ -46,17 +47,19 @@ type nodeClaimLifecycleReconciler interface {
 }
 
 type Controller struct {
-	kubeClient         client.Client
-	cloudProvider      cloudprovider.CloudProvider
-	instanceProvider   instance.Provider
-	instanceRegistered *InstanceRegistered
+	kubeClient                  client.Client
+	kubernetesBootstrapProvider bootstrap.Provider
+	cloudProvider               cloudprovider.CloudProvider
+	instanceProvider            instance.Provider
+	instanceRegistered          *InstanceRegistered
 }
 
 func NewController(kubeClient client.Client, kubernetesBootstrapProvider bootstrap.Provider, cloudProvider cloudprovider.CloudProvider, instanceProvider instance.Provider) *Controller {
 	return &Controller{
-		kubeClient:       kubeClient,
-		cloudProvider:    cloudProvider,
-		instanceProvider: instanceProvider,
+		kubeClient:                  kubeClient,
+		kubernetesBootstrapProvider: kubernetesBootstrapProvider,
+		cloudProvider:               cloudProvider,
+		instanceProvider:            instanceProvider,
 		instanceRegistered: &InstanceRegistered{
 			kubeClient:                  kubeClient,
 			kubernetesBootstrapProvider: kubernetesBootstrapProvider,
@@ -73,6 +76,13 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	ctx = injection.WithControllerName(ctx, c.Name())
 
 	if !nodeClaim.GetDeletionTimestamp().IsZero() {
+		// Revoke the bootstrap token of the node which has not joined the cluster
+		if _, ok := nodeClaim.Annotations[v1alpha1.AnnotationProxmoxCloudInitStatus]; !ok {
+			if err := c.kubernetesBootstrapProvider.DeleteNodeClaimTokens(ctx, nodeClaim.Name); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+
 		return reconcile.Result{}, nil
 	}
 
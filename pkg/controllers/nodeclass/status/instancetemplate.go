@@ -75,6 +75,10 @@ func (i *InstanceTemplate) Reconcile(ctx context.Context, nodeClass *v1alpha1.Pr
 		}
 
 		for _, z := range storage.Zones {
+			if !i.zoneSelected(nodeClass.Spec.ZoneSelector, region, z) {
+				continue
+			}
+
 			key := fmt.Sprintf("%s/%s/", region, z)
 
 			if zone, ok := lo.Find(zones, func(item string) bool {
@@ -91,6 +95,12 @@ func (i *InstanceTemplate) Reconcile(ctx context.Context, nodeClass *v1alpha1.Pr
 	nodeClass.Status.SelectedZones = availableZones
 
 	if len(availableZones) == 0 {
+		if nodeClass.Spec.ZoneSelector != nil {
+			nodeClass.StatusConditions().SetFalse(v1alpha1.ConditionInstanceTemplateReady, "ZonesNotSelected", "Proxmox Template did not match the node class requirements or zone selector")
+
+			return reconcile.Result{RequeueAfter: templateScanPeriod}, nil
+		}
+
 		nodeClass.StatusConditions().SetFalse(v1alpha1.ConditionInstanceTemplateReady, "TemplatesNotFound", "Proxmox Template did not match the node class requirements")
 
 		return reconcile.Result{RequeueAfter: templateScanPeriod}, nil
@@ -101,6 +111,21 @@ func (i *InstanceTemplate) Reconcile(ctx context.Context, nodeClass *v1alpha1.Pr
 	return reconcile.Result{RequeueAfter: templateScanPeriod}, nil
 }
 
+// zoneSelected checks the zone against the node class zone selector.
+func (i *InstanceTemplate) zoneSelected(selector *v1alpha1.ZoneSelector, region, zone string) bool {
+	if selector == nil {
+		return true
+	}
+
+	var tags, haGroups []string
+	if info := i.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil {
+		tags = info.Tags
+		haGroups = info.HAGroups
+	}
+
+	return selector.Matches(zone, tags, haGroups)
+}
+
 func (i *InstanceTemplate) resolveProxmoxTemplateFromNodeClass(ctx context.Context, nodeClass *v1alpha1.ProxmoxNodeClass) (v1alpha1.ProxmoxCommonTemplate, error) {
 	ref := nodeClass.Spec.InstanceTemplateRef
 	if ref == nil {
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -39,15 +41,46 @@ type MetadataOptions struct {
 }
 
 func (i *MetadataOptions) Reconcile(ctx context.Context, nodeClass *v1alpha1.ProxmoxNodeClass) (reconcile.Result, error) {
-	if nodeClass.Spec.MetadataOptions.Type == "cdrom" {
+	metadataType := nodeClass.Spec.MetadataOptions.Type
+
+	switch {
+	case (metadataType == v1alpha1.MetadataOptionsTypeSnippets || metadataType == v1alpha1.MetadataOptionsTypeFWCfg) && options.FromContext(ctx).SnippetsStoragePath == "":
+		nodeClass.StatusConditions().SetFalse(
+			v1alpha1.ConditionInstanceMetadataOptionsReady,
+			"SnippetsStorageNotConfigured",
+			fmt.Sprintf("snippets storage path is required when metadataOptions.Type is '%s'", metadataType),
+		)
+
+		return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
+	case (metadataType == v1alpha1.MetadataOptionsTypeSnippets || metadataType == v1alpha1.MetadataOptionsTypeFWCfg) &&
+		nodeClass.Spec.MetadataOptions.SnippetsStorage != options.FromContext(ctx).SnippetsStorage:
+		nodeClass.StatusConditions().SetFalse(
+			v1alpha1.ConditionInstanceMetadataOptionsReady,
+			"SnippetsStorageMismatch",
+			fmt.Sprintf("metadataOptions.snippetsStorage '%s' is not the mounted snippets storage '%s'",
+				nodeClass.Spec.MetadataOptions.SnippetsStorage, options.FromContext(ctx).SnippetsStorage),
+		)
+
+		return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
+	case metadataType == v1alpha1.MetadataOptionsTypeHTTP && options.FromContext(ctx).MetadataServiceAddress == "":
+		nodeClass.StatusConditions().SetFalse(
+			v1alpha1.ConditionInstanceMetadataOptionsReady,
+			"MetadataServiceNotConfigured",
+			"metadata service address is required when metadataOptions.Type is 'http'",
+		)
+
+		return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
+	}
+
+	if metadataType != v1alpha1.MetadataOptionsTypeNone {
 		if nodeClass.Spec.MetadataOptions.TemplatesRef == nil || nodeClass.Spec.MetadataOptions.TemplatesRef.Name == "" || nodeClass.Spec.MetadataOptions.TemplatesRef.Namespace == "" {
 			nodeClass.StatusConditions().SetFalse(
 				v1alpha1.ConditionInstanceMetadataOptionsReady,
 				"MetadataOptionsNotFound",
-				"metadataOptions.TemplatesRef is required when metadataOptions.Type is 'cdrom'",
+				fmt.Sprintf("metadataOptions.TemplatesRef is required when metadataOptions.Type is '%s'", metadataType),
 			)
 
-			return reconcile.Result{}, fmt.Errorf("metadataOptions.TemplatesRef is required when metadataOptions.Type is 'cdrom'")
+			return reconcile.Result{}, fmt.Errorf("metadataOptions.TemplatesRef is required when metadataOptions.Type is '%s'", metadataType)
 		}
 
 		secret := &corev1.Secret{}
@@ -65,6 +98,36 @@ func (i *MetadataOptions) Reconcile(ctx context.Context, nodeClass *v1alpha1.Pro
 
 			return reconcile.Result{}, err
 		}
+
+		values := map[string]string{}
+		if valuesRef := nodeClass.Spec.MetadataOptions.ValuesRef; valuesRef != nil && valuesRef.Name != "" && valuesRef.Namespace != "" {
+			valuesSecret := &corev1.Secret{}
+			if err := i.kubeClient.Get(ctx, client.ObjectKey{Name: valuesRef.Name, Namespace: valuesRef.Namespace}, valuesSecret); err != nil {
+				if apierrors.IsNotFound(err) {
+					nodeClass.StatusConditions().SetFalse(v1alpha1.ConditionInstanceMetadataOptionsReady, "MetadataOptionsNotFound", "Metadata ValuesRef secret resource not found")
+
+					return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
+				}
+
+				return reconcile.Result{}, err
+			}
+
+			for k, v := range valuesSecret.Data {
+				values[k] = string(v)
+			}
+		}
+
+		// Render the templates for a hypothetical instance to catch errors before any VM is provisioned.
+		if _, err := cloudinit.RenderDryRun(nodeClass, secret.Data, values, nil, "", ""); err != nil {
+			nodeClass.StatusConditions().SetFalse(v1alpha1.ConditionInstanceMetadataOptionsReady, "MetadataTemplatesInvalid", err.Error())
+
+			return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
+		}
+
+		nodeClass.StatusConditions().SetTrue(v1alpha1.ConditionInstanceMetadataOptionsReady)
+
+		// The secrets are not watched, so the templates are validated periodically.
+		return reconcile.Result{RequeueAfter: metadataScanPeriod}, nil
 	}
 
 	nodeClass.StatusConditions().SetTrue(v1alpha1.ConditionInstanceMetadataOptionsReady)
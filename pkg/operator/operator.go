@@ -27,6 +27,7 @@ import (
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetype"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/metadata"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/nodeipam"
 	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
 
@@ -46,6 +47,7 @@ type Operator struct {
 	InstanceProvider            instance.Provider
 	InstanceTemplateProvider    instancetemplate.Provider
 	InstanceTypeProvider        instancetype.Provider
+	MetadataProvider            metadata.Provider
 	NodeIpamController          nodeipam.Provider
 }
 
@@ -72,6 +74,10 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	cloudCapacityProvider.SyncNodeCapacity(ctx)
 	cloudCapacityProvider.SyncNodeStorageCapacity(ctx)
 
+	if err = operator.Manager.AddMetricsServerExtraHandler("/debug/capacity", cloudCapacityProvider.DebugHandler()); err != nil {
+		log.FromContext(ctx).Error(err, "failed to add capacity debug handler")
+	}
+
 	nodeIpamController := nodeipam.NewDefaultProvider(ctx, operator.KubernetesInterface, cloudCapacityProvider)
 	nodeIpamController.UpdateNodeCIDR(ctx)
 
@@ -91,14 +97,32 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		os.Exit(1)
 	}
 
+	metadataProvider := metadata.NewProvider(ctx, operator.KubernetesInterface)
+
+	if addr := options.FromContext(ctx).MetadataServiceAddress; addr != "" {
+		if err = operator.Manager.Add(metadataProvider); err != nil {
+			log.FromContext(ctx).Error(err, "failed to add metadata cache")
+
+			os.Exit(1)
+		}
+
+		if err = operator.Manager.Add(metadata.NewServer(ctx, addr, metadataProvider)); err != nil {
+			log.FromContext(ctx).Error(err, "failed to add metadata service")
+
+			os.Exit(1)
+		}
+	}
+
 	instanceProvider, err := instance.NewProvider(
 		ctx,
+		operator.GetClient(),
 		operator.KubernetesInterface,
 		kubernetesBootstrapProvider,
 		pxPool,
 		cloudCapacityProvider,
 		nodeIpamController,
 		instanceTemplateProvider,
+		metadataProvider,
 	)
 	if err != nil {
 		log.FromContext(ctx).Error(err, "failed creating instance provider")
@@ -114,6 +138,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		InstanceTemplateProvider:    instanceTemplateProvider,
 		InstanceTypeProvider:        instanceTypeProvider,
 		InstanceProvider:            instanceProvider,
+		MetadataProvider:            metadataProvider,
 		NodeIpamController:          nodeIpamController,
 	}
 }
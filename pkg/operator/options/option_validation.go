@@ -25,6 +25,8 @@ import (
 func (o *Options) Validate() error {
 	return multierr.Combine(
 		o.validateRequiredFields(),
+		o.validateMemoryPressureThreshold(),
+		o.validateSnippetsStorage(),
 	)
 }
 
@@ -39,3 +41,19 @@ func (o *Options) validateRequiredFields() error {
 
 	return nil
 }
+
+func (o *Options) validateMemoryPressureThreshold() error {
+	if o.MemoryPressureThreshold < 1 || o.MemoryPressureThreshold > 100 {
+		return fmt.Errorf("%s must be between 1 and 100", memoryPressureThresholdFlagName)
+	}
+
+	return nil
+}
+
+func (o *Options) validateSnippetsStorage() error {
+	if (o.SnippetsStoragePath == "") != (o.SnippetsStorage == "") {
+		return fmt.Errorf("%s and %s must be set together", snippetsStorageFlagName, snippetsStoragePathFlagName)
+	}
+
+	return nil
+}
@@ -42,6 +42,24 @@ const (
 
 	proxmoxVMIDEnvVarName = "PROXMOX_VMID"
 	proxmoxVMIDFlagName   = "proxmox-vmid"
+
+	snippetsStoragePathEnvVarName = "SNIPPETS_STORAGE_PATH"
+	snippetsStoragePathFlagName   = "snippets-storage-path"
+
+	snippetsStorageEnvVarName = "SNIPPETS_STORAGE"
+	snippetsStorageFlagName   = "snippets-storage"
+
+	metadataServiceAddressEnvVarName = "METADATA_SERVICE_ADDRESS"
+	metadataServiceAddressFlagName   = "metadata-service-address"
+
+	metadataServiceURLEnvVarName = "METADATA_SERVICE_URL"
+	metadataServiceURLFlagName   = "metadata-service-url"
+
+	csrApproverEnvVarName = "CSR_APPROVER"
+	csrApproverFlagName   = "csr-approver"
+
+	memoryPressureThresholdEnvVarName = "MEMORY_PRESSURE_THRESHOLD"
+	memoryPressureThresholdFlagName   = "memory-pressure-threshold"
 )
 
 func init() {
@@ -56,6 +74,15 @@ type Options struct {
 	NodeSettingFilePath   string
 	NodePolicy            string
 	ProxmoxVMID           int
+
+	SnippetsStorage        string
+	SnippetsStoragePath    string
+	MetadataServiceAddress string
+	MetadataServiceURL     string
+
+	CSRApprover bool
+
+	MemoryPressureThreshold int
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -64,6 +91,12 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.StringVar(&o.NodeSettingFilePath, nodeSettingFileFlagName, env.WithDefaultString(nodeSettingFileEnvVarName, ""), "Path to the node setting file.")
 	fs.StringVar(&o.NodePolicy, nodePolicyFlagName, env.WithDefaultString(nodePolicyEnvVarName, "simple"), "Node CPU policy to use.")
 	fs.IntVar(&o.ProxmoxVMID, proxmoxVMIDFlagName, env.WithDefaultInt(proxmoxVMIDEnvVarName, 20000), "This value is used as the minimum ID when creating a VM.")
+	fs.StringVar(&o.SnippetsStoragePath, snippetsStoragePathFlagName, env.WithDefaultString(snippetsStoragePathEnvVarName, ""), "Path to the mounted Proxmox snippets storage.")
+	fs.StringVar(&o.SnippetsStorage, snippetsStorageFlagName, env.WithDefaultString(snippetsStorageEnvVarName, ""), "The Proxmox storage ID mounted at the snippets storage path.")
+	fs.StringVar(&o.MetadataServiceAddress, metadataServiceAddressFlagName, env.WithDefaultString(metadataServiceAddressEnvVarName, ""), "The address the metadata service binds to, e.g. ':8090'. Empty disables the service.")
+	fs.StringVar(&o.MetadataServiceURL, metadataServiceURLFlagName, env.WithDefaultString(metadataServiceURLEnvVarName, ""), "The URL of the metadata service reachable by the VMs, e.g. 'http://metadata.example.com:8090'. The VMs get the datasource URL with the instance token in the SMBIOS serial.")
+	fs.BoolVar(&o.CSRApprover, csrApproverFlagName, env.WithDefaultBool(csrApproverEnvVarName, false), "Approve kubelet certificate signing requests of the Karpenter nodes.")
+	fs.IntVar(&o.MemoryPressureThreshold, memoryPressureThresholdFlagName, env.WithDefaultInt(memoryPressureThresholdEnvVarName, 90), "Host memory usage in percent at which the nodes with memory balloon are drifted.")
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
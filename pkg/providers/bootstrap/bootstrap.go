@@ -18,6 +18,8 @@ package bootstrap
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -36,11 +38,24 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
-const BootstrapUserPostfix = "karpenter:proxmox"
+const (
+	BootstrapUserPostfix = "karpenter:proxmox"
+
+	// DefaultTokenTTL is the lifetime of the bootstrap token if it is not set in the NodeClass
+	DefaultTokenTTL = time.Hour
+)
+
+// TokenBinding is the node identity the bootstrap token is issued for.
+type TokenBinding struct {
+	NodeName   string
+	ProviderID string
+}
 
 type Provider interface {
-	CreateToken(context.Context, *karpv1.NodeClaim) (string, error)
+	CreateToken(ctx context.Context, nodeClaim *karpv1.NodeClaim, providerID string, ttl time.Duration) (string, error)
+	GetTokenBinding(ctx context.Context, tokenID string) (*TokenBinding, error)
 	DeleteToken(context.Context, string) error
+	DeleteNodeClaimTokens(context.Context, string) error
 	DeleteExpiredTokens(context.Context) error
 }
 
@@ -57,7 +72,8 @@ func NewProvider(
 	}
 }
 
-func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.NodeClaim) (string, error) {
+// CreateToken creates the bootstrap token bound to the NodeClaim name and the provider ID.
+func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.NodeClaim, providerID string, ttl time.Duration) (string, error) {
 	token, err := bootstraputil.GenerateBootstrapToken()
 	if err != nil {
 		return "", err
@@ -66,7 +82,12 @@ func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.Nod
 	t := strings.Split(token, ".")
 	tokenID := t[0]
 	tokenSecret := t[1]
-	tokenExpiredTime := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	tokenExpiredTime := time.Now().UTC().Add(ttl).Format(time.RFC3339)
 
 	secret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -77,7 +98,12 @@ func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.Nod
 			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
 			Namespace: metav1.NamespaceSystem,
 			Labels: map[string]string{
-				v1alpha1.LabelBootstrapToken: "true",
+				v1alpha1.LabelBootstrapToken:          "true",
+				v1alpha1.LabelBootstrapTokenNodeClaim: nodeClaimHash(nodeClaim.Name),
+			},
+			Annotations: map[string]string{
+				v1alpha1.AnnotationBootstrapTokenNodeName:   nodeClaim.Name,
+				v1alpha1.AnnotationBootstrapTokenProviderID: providerID,
 			},
 		},
 		Type: bootstrapapi.SecretTypeBootstrapToken,
@@ -86,7 +112,7 @@ func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.Nod
 			bootstrapapi.BootstrapTokenSecretKey:           tokenSecret,
 			bootstrapapi.BootstrapTokenUsageAuthentication: "true",
 			bootstrapapi.BootstrapTokenExtraGroupsKey:      fmt.Sprintf("%s:%s", bootstrapapi.BootstrapDefaultGroup, BootstrapUserPostfix),
-			bootstrapapi.BootstrapTokenDescriptionKey:      "Karpenter Proxmox Bootstrap Token",
+			bootstrapapi.BootstrapTokenDescriptionKey:      fmt.Sprintf("Karpenter Proxmox Bootstrap Token for %s", nodeClaim.Name),
 			bootstrapapi.BootstrapTokenExpirationKey:       tokenExpiredTime,
 		},
 	}
@@ -102,6 +128,27 @@ func (p *DefaultProvider) CreateToken(ctx context.Context, nodeClaim *karpv1.Nod
 	return token, nil
 }
 
+// GetTokenBinding returns the node identity of the bootstrap token created by Karpenter.
+func (p *DefaultProvider) GetTokenBinding(ctx context.Context, tokenID string) (*TokenBinding, error) {
+	secret, err := p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).Get(ctx, bootstraputil.BootstrapTokenSecretName(tokenID), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	if secret.Labels[v1alpha1.LabelBootstrapToken] != "true" || secret.Annotations[v1alpha1.AnnotationBootstrapTokenNodeName] == "" {
+		return nil, ErrTokenNotFound
+	}
+
+	return &TokenBinding{
+		NodeName:   secret.Annotations[v1alpha1.AnnotationBootstrapTokenNodeName],
+		ProviderID: secret.Annotations[v1alpha1.AnnotationBootstrapTokenProviderID],
+	}, nil
+}
+
 func (p *DefaultProvider) DeleteToken(ctx context.Context, tokenID string) error {
 	if tokenID == "" {
 		return fmt.Errorf("tokenID is required")
@@ -118,6 +165,40 @@ func (p *DefaultProvider) DeleteToken(ctx context.Context, tokenID string) error
 	return nil
 }
 
+// DeleteNodeClaimTokens revokes all bootstrap tokens issued for the NodeClaim.
+func (p *DefaultProvider) DeleteNodeClaimTokens(ctx context.Context, nodeClaimName string) error {
+	if nodeClaimName == "" {
+		return fmt.Errorf("nodeClaim name is required")
+	}
+
+	secrets, err := p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true,%s=%s", v1alpha1.LabelBootstrapToken, v1alpha1.LabelBootstrapTokenNodeClaim, nodeClaimHash(nodeClaimName)),
+	})
+	if err != nil {
+		return fmt.Errorf("listing bootstrap tokens: %w", err)
+	}
+
+	for _, secret := range secrets.Items {
+		if secret.Annotations[v1alpha1.AnnotationBootstrapTokenNodeName] != nodeClaimName {
+			continue
+		}
+
+		if err := p.DeleteToken(ctx, strings.TrimPrefix(secret.Name, bootstrapapi.BootstrapTokenSecretPrefix)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodeClaimHash returns the label value of the NodeClaim name,
+// the name can be up to 253 characters, but the label value is limited to 63.
+func nodeClaimHash(name string) string {
+	sum := sha256.Sum256([]byte(name))
+
+	return hex.EncodeToString(sum[:16])
+}
+
 func (p *DefaultProvider) DeleteExpiredTokens(ctx context.Context) error {
 	secrets, err := p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
 		LabelSelector: v1alpha1.LabelBootstrapToken + "=true",
@@ -19,6 +19,7 @@ package cloudcapacity
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
@@ -27,12 +28,10 @@ import (
 	"github.com/go-logr/logr"
 	proxmox "github.com/luthermonson/go-proxmox"
 
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
 	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
 
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
-
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -49,17 +48,29 @@ type Provider interface {
 
 	// UpdateNodeLoad updates the node CPU load information for all regions.
 	UpdateNodeLoad(ctx context.Context) error
+	// ReconcileNodeAllocations applies the VMs created or deleted out-of-band to the node allocations.
+	ReconcileNodeAllocations(ctx context.Context) error
 
 	// Regions returns a list of regions available.
 	Regions() []string
 	// Zones returns a list of zones available in the specified region.
 	Zones(region string) []string
 
-	GetAvailableZonesInRegion(region string, req corev1.ResourceList) []string
+	// GetZoneInfo returns the capacity information of the zone, or nil if the zone is unknown.
+	GetZoneInfo(region, zone string) *NodeCapacityInfo
+	// GetZoneUsage returns the resource usage of the zone, or nil if the zone is unknown.
+	GetZoneUsage(region, zone string) *NodeUsageInfo
+
+	// GetAvailableZonesInRegion returns the zones where the resources can be allocated.
+	GetAvailableZonesInRegion(region string, req *resources.VMResources) []string
 	SortZonesByCPULoad(region string, zones []string) []string
-	FitInZone(region, zone string, req corev1.ResourceList) bool
+	// FitInZone reports whether the resources can be allocated in the zone,
+	// the overcommit ratios and the balloon memory of the request are taken into account.
+	FitInZone(region, zone string, req *resources.VMResources) bool
 
 	GetStorage(region string, storage string, filter ...func(*NodeStorageCapacityInfo) bool) *NodeStorageCapacityInfo
+	// GetStorageInZone returns the storage information with the storage size in the specified zone.
+	GetStorageInZone(region, zone, storage string) *NodeStorageCapacityInfo
 	GetNetwork(region string, node string, filter ...func(*NodeNetworkIfaceInfo) bool) *NodeNetworkIfaceInfo
 }
 
@@ -178,6 +189,11 @@ func (p *DefaultProvider) SyncNodeCapacity(ctx context.Context) error {
 		nodes := make([]string, 0, len(ns))
 
 		// Permission: Sys.Audit
+		haGroups, err := getHAGroups(ctx, cl)
+		if err != nil {
+			log.V(1).Info("Failed to get HA groups", "region", region, "error", err.Error())
+		}
+
 		for _, item := range ns {
 			log.V(4).Info("Processing node", "node", item.Node, "region", region, "maxCPU", item.MaxCPU, "maxMem", item.MaxMem)
 
@@ -185,13 +201,16 @@ func (p *DefaultProvider) SyncNodeCapacity(ctx context.Context) error {
 
 			nodes = append(nodes, item.Node)
 
-			nodeCapacity, err := getNodeCapacity(ctx, cl, region, item)
+			nodeCapacity, err := getNodeCapacity(ctx, cl, region, item, p.capacityInfo[key].ResourceManager)
 			if err != nil {
 				log.Error(err, "Failed to get capacity for node", "node", item.Node, "region", region)
 
 				continue
 			}
 
+			nodeCapacity.HAGroups = haGroups[item.Node]
+			nodeCapacity.MemoryPressure = memoryPressure(p.capacityInfo[key].MemoryPressure, nodeCapacity.MemoryUsage, options.FromContext(ctx).MemoryPressureThreshold)
+
 			capacityInfo[key] = nodeCapacity
 
 			nodeIfaces, err := getNodeNetwork(ctx, cl, region, item)
@@ -229,6 +248,8 @@ func (p *DefaultProvider) SyncNodeCapacity(ctx context.Context) error {
 func (p *DefaultProvider) UpdateNodeLoad(ctx context.Context) error {
 	log := p.log.WithName("UpdateNodeLoad()")
 
+	threshold := options.FromContext(ctx).MemoryPressureThreshold
+
 	p.muCapacityInfo.Lock()
 	defer p.muCapacityInfo.Unlock()
 
@@ -255,7 +276,19 @@ func (p *DefaultProvider) UpdateNodeLoad(ctx context.Context) error {
 			key := fmt.Sprintf("%s/%s", region, item.Node)
 
 			if info, ok := p.capacityInfo[key]; ok {
+				usage := memoryUsage(item)
+				pressure := memoryPressure(info.MemoryPressure, usage, threshold)
+
+				switch {
+				case pressure && !info.MemoryPressure:
+					log.Info("Node memory is under pressure, nodes with memory balloon will be replaced", "region", region, "node", item.Node, "memoryUsage", usage)
+				case !pressure && info.MemoryPressure:
+					log.Info("Node memory pressure is over", "region", region, "node", item.Node, "memoryUsage", usage)
+				}
+
 				info.CPULoad = int(item.CPU * 100)
+				info.MemoryUsage = usage
+				info.MemoryPressure = pressure
 				p.capacityInfo[key] = info
 
 				log.V(4).Info("Syncing capacity for region", "region", region, "node", item.Node, "cpuLoad", info.CPULoad)
@@ -266,6 +299,55 @@ func (p *DefaultProvider) UpdateNodeLoad(ctx context.Context) error {
 	return nil
 }
 
+func (p *DefaultProvider) ReconcileNodeAllocations(ctx context.Context) error {
+	log := p.log.WithName("ReconcileNodeAllocations()")
+
+	p.muCapacityInfo.RLock()
+	capacityInfo := maps.Clone(p.capacityInfo)
+	p.muCapacityInfo.RUnlock()
+
+	for key, info := range capacityInfo {
+		if info.ResourceManager == nil {
+			continue
+		}
+
+		cl, err := p.pool.GetProxmoxCluster(info.Region)
+		if err != nil {
+			log.Error(err, "Failed to get proxmox cluster", "region", info.Region)
+
+			continue
+		}
+
+		if err := info.ResourceManager.RefreshSharedMemory(ctx); err != nil {
+			log.Error(err, "Failed to refresh KSM shared memory", "node", info.Name, "region", info.Region)
+		}
+
+		observedAt := info.ResourceManager.Generation()
+
+		vms, err := getNodeVMResources(ctx, cl, info.Name, info.ResourceManager.Allocations())
+		if err != nil {
+			log.Error(err, "Failed to get VM resources", "node", info.Name, "region", info.Region)
+
+			continue
+		}
+
+		p.muCapacityInfo.Lock()
+
+		// The resource manager can be replaced by SyncNodeCapacity in the meantime
+		if current, ok := p.capacityInfo[key]; ok && current.ResourceManager == info.ResourceManager {
+			if err := info.ResourceManager.Reconcile(observedAt, vms); err != nil {
+				log.Error(err, "Failed to reconcile allocations", "node", info.Name, "region", info.Region)
+			}
+
+			log.V(4).Info("Reconciled allocations", "node", info.Name, "region", info.Region, "resourceStatus", info.ResourceManager.Status())
+		}
+
+		p.muCapacityInfo.Unlock()
+	}
+
+	return nil
+}
+
 func (p *DefaultProvider) SyncNodeStorageCapacity(ctx context.Context) error {
 	log := p.log.WithName("SyncNodeStorageCapacity()")
 
@@ -309,6 +391,8 @@ func (p *DefaultProvider) SyncNodeStorageCapacity(ctx context.Context) error {
 				Shared:       item.Shared == 1,
 				Type:         item.PluginType,
 				Capabilities: strings.Split(item.Content, ","),
+				Size:         item.MaxDisk,
+				Used:         item.Disk,
 			}
 
 			capacityInfo[key] = info
@@ -371,6 +455,17 @@ func (p *DefaultProvider) GetStorage(region string, storage string, filter ...fu
 	return nil
 }
 
+func (p *DefaultProvider) GetStorageInZone(region, zone, storage string) *NodeStorageCapacityInfo {
+	p.muStorageInfo.RLock()
+	defer p.muStorageInfo.RUnlock()
+
+	if info, ok := p.storageInfo[fmt.Sprintf("%s/%s/%s", region, storage, zone)]; ok {
+		return &info
+	}
+
+	return nil
+}
+
 func (p *DefaultProvider) GetNetwork(region string, node string, filter ...func(*NodeNetworkIfaceInfo) bool) *NodeNetworkIfaceInfo {
 	p.muNetworkInfo.RLock()
 	defer p.muNetworkInfo.RUnlock()
@@ -412,9 +507,43 @@ func (p *DefaultProvider) Zones(region string) []string {
 	return p.zoneList[region]
 }
 
+func (p *DefaultProvider) GetZoneInfo(region, zone string) *NodeCapacityInfo {
+	p.muCapacityInfo.RLock()
+	defer p.muCapacityInfo.RUnlock()
+
+	if info, ok := p.capacityInfo[fmt.Sprintf("%s/%s", region, zone)]; ok {
+		return &info
+	}
+
+	return nil
+}
+
+func (p *DefaultProvider) GetZoneUsage(region, zone string) *NodeUsageInfo {
+	p.muCapacityInfo.RLock()
+	defer p.muCapacityInfo.RUnlock()
+
+	info, ok := p.capacityInfo[fmt.Sprintf("%s/%s", region, zone)]
+	if !ok {
+		return nil
+	}
+
+	usage := &NodeUsageInfo{
+		CPULoad:        info.CPULoad,
+		MemoryUsage:    info.MemoryUsage,
+		MemoryPressure: info.MemoryPressure,
+	}
+
+	if info.ResourceManager != nil {
+		usage.AvailableCPUs = info.ResourceManager.AvailableCPUs()
+		usage.AvailableMemory = info.ResourceManager.AvailableMemory()
+	}
+
+	return usage
+}
+
 // FIXME: optimize this functions
 
-func (p *DefaultProvider) GetAvailableZonesInRegion(region string, req corev1.ResourceList) []string {
+func (p *DefaultProvider) GetAvailableZonesInRegion(region string, req *resources.VMResources) []string {
 	p.muCapacityInfo.RLock()
 	defer p.muCapacityInfo.RUnlock()
 
@@ -425,15 +554,7 @@ func (p *DefaultProvider) GetAvailableZonesInRegion(region string, req corev1.Re
 			continue
 		}
 
-		cpu := info.ResourceManager.AvailableCPUs()
-		mem := info.ResourceManager.AvailableMemory()
-
-		allocatable := corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%d", cpu)),
-			corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%d", mem)),
-		}
-
-		if allocatable.Cpu().Cmp(*req.Cpu()) >= 0 && allocatable.Memory().Cmp(*req.Memory()) >= 0 {
+		if info.ResourceManager.Fits(req) {
 			zones = append(zones, info.Name)
 		}
 	}
@@ -441,25 +562,13 @@ func (p *DefaultProvider) GetAvailableZonesInRegion(region string, req corev1.Re
 	return zones
 }
 
-func (p *DefaultProvider) FitInZone(region, zone string, req corev1.ResourceList) bool {
+func (p *DefaultProvider) FitInZone(region, zone string, req *resources.VMResources) bool {
 	p.muCapacityInfo.RLock()
 	defer p.muCapacityInfo.RUnlock()
 
 	key := fmt.Sprintf("%s/%s", region, zone)
-	if info, ok := p.capacityInfo[key]; ok {
-		if info.ResourceManager == nil {
-			return false
-		}
-
-		cpu := info.ResourceManager.AvailableCPUs()
-		mem := info.ResourceManager.AvailableMemory()
-
-		allocatable := corev1.ResourceList{
-			corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%d", cpu)),
-			corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%d", mem)),
-		}
-
-		return allocatable.Cpu().Cmp(*req.Cpu()) >= 0 && allocatable.Memory().Cmp(*req.Memory()) >= 0
+	if info, ok := p.capacityInfo[key]; ok && info.ResourceManager != nil {
+		return info.ResourceManager.Fits(req)
 	}
 
 	return false
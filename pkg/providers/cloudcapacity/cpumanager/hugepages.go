@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpumanager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
+)
+
+// hugePages tracks the free host hugepages per NUMA node.
+type hugePages struct {
+	// total is a map from NUMA node ID to the page size in KiB to the number of preallocated pages
+	total map[int]map[uint64]uint64
+	// free is a map from NUMA node ID to the page size in KiB to the number of free pages
+	free map[int]map[uint64]uint64
+}
+
+func newHugePages(numaNodes map[int]map[uint64]uint64) *hugePages {
+	h := &hugePages{
+		total: make(map[int]map[uint64]uint64, len(numaNodes)),
+		free:  make(map[int]map[uint64]uint64, len(numaNodes)),
+	}
+
+	for i, pages := range numaNodes {
+		h.total[i] = maps.Clone(pages)
+		h.free[i] = maps.Clone(pages)
+	}
+
+	return h
+}
+
+// vmHugePages returns the page size in KiB and the number of hugepages backing the VM memory.
+// The memory must be a multiple of the page size.
+func vmHugePages(op *resources.VMResources) (uint64, uint64, error) {
+	if op.HugePageSize == 0 {
+		return 0, 0, nil
+	}
+
+	pageSize := op.HugePageSize * 1024 * 1024
+	if op.Memory%pageSize != 0 {
+		return 0, 0, fmt.Errorf("memory %dM is not a multiple of the hugepage size %dM", op.Memory/1024/1024, op.HugePageSize)
+	}
+
+	return op.HugePageSize * 1024, op.Memory / pageSize, nil
+}
+
+// Free returns the number of free pages of the size in KiB on the NUMA nodes, on all nodes if none are given.
+func (h *hugePages) Free(pageSize uint64, nodes ...int) uint64 {
+	if len(nodes) == 0 {
+		nodes = slices.Collect(maps.Keys(h.free))
+	}
+
+	var free uint64
+	for _, i := range nodes {
+		free += h.free[i][pageSize]
+	}
+
+	return free
+}
+
+// Take takes the pages from the NUMA node, the free pages never go below zero.
+func (h *hugePages) Take(node int, pageSize, pages uint64) {
+	if h.free[node] == nil {
+		return
+	}
+
+	h.free[node][pageSize] -= min(pages, h.free[node][pageSize])
+}
+
+// TakeAny takes the pages from the NUMA nodes in order of their IDs.
+func (h *hugePages) TakeAny(pageSize, pages uint64) {
+	for _, i := range slices.Sorted(maps.Keys(h.free)) {
+		n := min(pages, h.free[i][pageSize])
+		h.Take(i, pageSize, n)
+
+		pages -= n
+	}
+}
+
+// Put returns the pages to the NUMA node, the free pages never exceed the preallocated pages.
+func (h *hugePages) Put(node int, pageSize, pages uint64) {
+	if h.free[node] == nil {
+		return
+	}
+
+	h.free[node][pageSize] = min(h.free[node][pageSize]+pages, h.total[node][pageSize])
+}
+
+// PutAny returns the pages to the NUMA nodes in order of their IDs.
+func (h *hugePages) PutAny(pageSize, pages uint64) {
+	for _, i := range slices.Sorted(maps.Keys(h.free)) {
+		n := min(pages, h.total[i][pageSize]-h.free[i][pageSize])
+		h.Put(i, pageSize, n)
+
+		pages -= n
+	}
+}
+
+// TakeVM takes the hugepages of the VM from its NUMA nodes, from any NUMA nodes if the VM is not bound to them.
+// The hugepages of running VMs are taken even if the memory is not aligned to the page size.
+func (h *hugePages) TakeVM(op *resources.VMResources) {
+	if op.HugePageSize == 0 {
+		return
+	}
+
+	pageSize := op.HugePageSize * 1024
+
+	if len(op.NUMANodes) == 0 {
+		h.TakeAny(pageSize, op.Memory/1024/1024/op.HugePageSize)
+	}
+
+	for i, node := range op.NUMANodes {
+		h.Take(i, pageSize, node.Memory/op.HugePageSize)
+	}
+}
+
+// PutVM returns the hugepages of the VM to its NUMA nodes.
+func (h *hugePages) PutVM(op *resources.VMResources) {
+	if op.HugePageSize == 0 {
+		return
+	}
+
+	pageSize := op.HugePageSize * 1024
+
+	if len(op.NUMANodes) == 0 {
+		h.PutAny(pageSize, op.Memory/1024/1024/op.HugePageSize)
+	}
+
+	for i, node := range op.NUMANodes {
+		h.Put(i, pageSize, node.Memory/op.HugePageSize)
+	}
+}
@@ -26,6 +26,8 @@ type Policy interface {
 
 	AvailableCPUs() int
 	AvailableMemory() uint64
+	// Fits reports whether the resources can be allocated, the VM overcommit ratios are taken into account.
+	Fits(op *resources.VMResources) bool
 
 	Allocate(op *resources.VMResources) error
 	AllocateOrUpdate(op *resources.VMResources) error
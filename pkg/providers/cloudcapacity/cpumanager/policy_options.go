@@ -33,3 +33,24 @@ type StaticPolicyOptions struct {
 	// As long as there are CPUs available, pods will be admitted if the condition is not met.
 	PreferAlignByUncoreCacheOption bool
 }
+
+// DefaultStaticPolicyOptions returns the options of the static policy used by the controller.
+func DefaultStaticPolicyOptions() StaticPolicyOptions {
+	return StaticPolicyOptions{
+		PreferAlignByUncoreCacheOption: true,
+	}
+}
+
+// SimplePolicyOptions holds the options of the simple policy.
+type SimplePolicyOptions struct {
+	// CPUOvercommitRatio is the ratio of virtual CPUs to physical CPUs for the VMs without CPU affinity.
+	// Values less than 1 disable the overcommit.
+	CPUOvercommitRatio float64
+	// MemoryOvercommitRatio is the ratio of VM memory to host memory.
+	// The memory of VMs with CPU affinity is never overcommitted.
+	// Values less than 1 disable the overcommit.
+	MemoryOvercommitRatio float64
+	// SharedMemory is the memory in bytes deduplicated by KSM, it is added to the memory capacity
+	// of the VMs without CPU affinity.
+	SharedMemory uint64
+}
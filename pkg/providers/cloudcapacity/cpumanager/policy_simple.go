@@ -40,8 +40,15 @@ type simplePolicy struct {
 
 	// Available memory for allocation
 	availableMemory uint64
-	// Assigned memory of all VMs
+	// Assigned memory of VMs without affinity assignments
 	assignedMemory uint64
+	// Assigned memory of VMs with affinity assignments, it cannot be overcommitted
+	pinnedMemory uint64
+	// Free hugepages of the host, the VMs are not bound to the NUMA nodes
+	hugePages *hugePages
+
+	// options allow to fine-tune the behavior of the policy
+	options SimplePolicyOptions
 
 	mu sync.Mutex
 }
@@ -54,6 +61,11 @@ var _ Policy = &simplePolicy{}
 
 // NewSimplePolicy returns a resource manager policy that handles both CPU and memory allocation
 func NewSimplePolicy(sysTopology *topology.Topology, reservedCPUs []int, reservedMemory uint64) (Policy, error) {
+	return NewSimplePolicyWithOptions(sysTopology, reservedCPUs, reservedMemory, SimplePolicyOptions{})
+}
+
+// NewSimplePolicyWithOptions returns a simple policy with the overcommit options
+func NewSimplePolicyWithOptions(sysTopology *topology.Topology, reservedCPUs []int, reservedMemory uint64, options SimplePolicyOptions) (Policy, error) {
 	if sysTopology == nil {
 		return nil, fmt.Errorf("system topology must be provided for %s policy", string(PolicySimple))
 	}
@@ -75,6 +87,8 @@ func NewSimplePolicy(sysTopology *topology.Topology, reservedCPUs []int, reserve
 		usedCPUs:        cpuset.New(),
 		reservedCPUs:    reservedCPUSet,
 		availableMemory: sysTopology.TotalMemory - reservedMemory,
+		hugePages:       newHugePages(sysTopology.HugePages),
+		options:         options,
 	}, nil
 }
 
@@ -86,18 +100,77 @@ func (p *simplePolicy) AvailableCPUs() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	return max(0, p.allCPUs.Size()-p.reservedCPUs.Size()-p.usedCPUs.Size()-p.assignedCPUs)
+	return p.freeCPUs(p.options.CPUOvercommitRatio)
 }
 
 func (p *simplePolicy) AvailableMemory() uint64 {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.assignedMemory >= p.availableMemory {
+	return p.freeMemory(p.options.MemoryOvercommitRatio)
+}
+
+func (p *simplePolicy) Fits(op *resources.VMResources) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pageSize, pages, err := vmHugePages(op); err != nil || p.hugePages.Free(pageSize) < pages {
+		return false
+	}
+
+	return op.CommittedMemory() <= p.freeMemory(overcommitRatio(p.options.MemoryOvercommitRatio, op.MemoryOvercommitRatio)) &&
+		op.CPUs <= p.freeCPUs(overcommitRatio(p.options.CPUOvercommitRatio, op.CPUOvercommitRatio))
+}
+
+// SetSharedMemory updates the memory deduplicated by KSM which can be assigned on top of the host memory.
+func (p *simplePolicy) SetSharedMemory(memory uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.options.SharedMemory = memory
+}
+
+// freeCPUs returns the number of CPUs which can be assigned with the overcommit ratio.
+func (p *simplePolicy) freeCPUs(ratio float64) int {
+	return max(0, overcommit(p.availableCPUs.Size(), ratio)-p.assignedCPUs)
+}
+
+// freeMemory returns the memory which can be assigned with the overcommit ratio.
+// The memory of pinned VMs is excluded from the overcommitted capacity.
+func (p *simplePolicy) freeMemory(ratio float64) uint64 {
+	capacity := p.memoryCapacity(p.pinnedMemory, ratio)
+	if p.assignedMemory >= capacity {
 		return 0
 	}
 
-	return p.availableMemory - p.assignedMemory
+	return capacity - p.assignedMemory
+}
+
+// memoryCapacity returns the memory capacity of VMs without affinity assignments.
+func (p *simplePolicy) memoryCapacity(pinnedMemory uint64, ratio float64) uint64 {
+	if pinnedMemory >= p.availableMemory {
+		return p.options.SharedMemory
+	}
+
+	return overcommit(p.availableMemory-pinnedMemory, ratio) + p.options.SharedMemory
+}
+
+// overcommitRatio returns the lowest ratio of the host and the VM ratios,
+// the VM cannot increase the host overcommit.
+func overcommitRatio(host, vm float64) float64 {
+	if vm >= 1 {
+		return min(max(host, 1), vm)
+	}
+
+	return host
+}
+
+func overcommit[T int | uint64](capacity T, ratio float64) T {
+	if ratio <= 1 {
+		return capacity
+	}
+
+	return T(float64(capacity) * ratio)
 }
 
 //nolint:dupl
@@ -109,21 +182,29 @@ func (p *simplePolicy) Allocate(op *resources.VMResources) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.assignedMemory+op.Memory > p.availableMemory {
-		available := uint64(0)
-		if p.assignedMemory < p.availableMemory {
-			available = p.availableMemory - p.assignedMemory
-		}
+	// Ballooned VMs commit only the minimum memory
+	memory := op.CommittedMemory()
+
+	if available := p.freeMemory(overcommitRatio(p.options.MemoryOvercommitRatio, op.MemoryOvercommitRatio)); memory > available {
+		return fmt.Errorf("not enough memory available: requested=%d, available=%d", memory, available)
+	}
 
-		return fmt.Errorf("not enough memory available: requested=%d, available=%d", op.Memory, available)
+	if available := p.freeCPUs(overcommitRatio(p.options.CPUOvercommitRatio, op.CPUOvercommitRatio)); op.CPUs > available {
+		return fmt.Errorf("not enough CPUs available: requested=%d, available=%d", op.CPUs, available)
 	}
 
-	if p.assignedCPUs+op.CPUs > p.availableCPUs.Size() {
-		return fmt.Errorf("not enough CPUs available: requested=%d, available=%d", op.CPUs, p.availableCPUs.Size()-p.assignedCPUs)
+	pageSize, pages, err := vmHugePages(op)
+	if err != nil {
+		return err
 	}
 
+	if available := p.hugePages.Free(pageSize); pages > available {
+		return fmt.Errorf("not enough hugepages available: requested=%d, available=%d", pages, available)
+	}
+
+	p.hugePages.TakeVM(op)
 	p.assignedCPUs += op.CPUs
-	p.assignedMemory += op.Memory
+	p.assignedMemory += memory
 
 	return nil
 }
@@ -137,25 +218,33 @@ func (p *simplePolicy) AllocateOrUpdate(op *resources.VMResources) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.assignedMemory+op.Memory > p.availableMemory {
-		available := uint64(0)
-		if p.assignedMemory < p.availableMemory {
-			available = p.availableMemory - p.assignedMemory
-		}
-
-		return fmt.Errorf("not enough memory available: requested=%d, available=%d", op.Memory, available)
-	}
+	memory := op.CommittedMemory()
 
 	if !op.CPUSet.IsEmpty() {
 		if op.CPUSet.Size() > p.allCPUs.Size() {
 			return fmt.Errorf("not enough CPUs available: requested=%d, available=%d", op.CPUSet.Size(), p.allCPUs.Size())
 		}
 
+		// Pinned VMs are never overcommitted, they keep the full memory
+		if p.pinnedMemory+op.Memory > p.availableMemory || p.memoryCapacity(p.pinnedMemory+op.Memory, p.options.MemoryOvercommitRatio) < p.assignedMemory {
+			return fmt.Errorf("not enough memory available for pinned VM: requested=%d, available=%d", op.Memory, p.availableMemory-p.pinnedMemory)
+		}
+
 		pinned := op.CPUSet.Difference(p.reservedCPUs)
 		p.usedCPUs = p.usedCPUs.Union(pinned)
 		p.availableCPUs = p.availableCPUs.Difference(pinned)
-	} else if op.CPUs > 0 {
-		available := p.availableCPUs.Size() - p.assignedCPUs
+		p.pinnedMemory += op.Memory
+		p.hugePages.TakeVM(op)
+
+		return nil
+	}
+
+	if available := p.freeMemory(p.options.MemoryOvercommitRatio); memory > available {
+		return fmt.Errorf("not enough memory available: requested=%d, available=%d", memory, available)
+	}
+
+	if op.CPUs > 0 {
+		available := p.freeCPUs(p.options.CPUOvercommitRatio)
 		if op.CPUs > available {
 			return fmt.Errorf("not enough CPUs available: requested=%d, available=%d", op.CPUs, available)
 		}
@@ -163,7 +252,8 @@ func (p *simplePolicy) AllocateOrUpdate(op *resources.VMResources) error {
 		p.assignedCPUs += op.CPUs
 	}
 
-	p.assignedMemory += op.Memory
+	p.assignedMemory += memory
+	p.hugePages.TakeVM(op)
 
 	return nil
 }
@@ -177,15 +267,27 @@ func (p *simplePolicy) Release(op *resources.VMResources) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.assignedMemory < op.Memory {
-		return fmt.Errorf("cannot release memory: requested=%d, assigned=%d", op.Memory, p.assignedMemory)
-	}
-
 	if !op.CPUSet.IsEmpty() {
+		if p.pinnedMemory < op.Memory {
+			return fmt.Errorf("cannot release memory: requested=%d, assigned=%d", op.Memory, p.pinnedMemory)
+		}
+
 		freed := op.CPUSet.Difference(p.reservedCPUs)
 		p.usedCPUs = p.usedCPUs.Difference(freed)
 		p.availableCPUs = p.availableCPUs.Union(freed)
-	} else if op.CPUs > 0 {
+		p.pinnedMemory -= op.Memory
+		p.hugePages.PutVM(op)
+
+		return nil
+	}
+
+	memory := op.CommittedMemory()
+
+	if p.assignedMemory < memory {
+		return fmt.Errorf("cannot release memory: requested=%d, assigned=%d", memory, p.assignedMemory)
+	}
+
+	if op.CPUs > 0 {
 		if p.assignedCPUs < op.CPUs {
 			return fmt.Errorf("cannot release CPUs: requested=%d, assigned=%d", op.CPUs, p.assignedCPUs)
 		}
@@ -193,7 +295,8 @@ func (p *simplePolicy) Release(op *resources.VMResources) error {
 		p.assignedCPUs -= op.CPUs
 	}
 
-	p.assignedMemory -= op.Memory
+	p.assignedMemory -= memory
+	p.hugePages.PutVM(op)
 
 	return nil
 }
@@ -202,12 +305,12 @@ func (p *simplePolicy) Status() string {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	availableCPUs := max(0, p.allCPUs.Size()-p.reservedCPUs.Size()-p.usedCPUs.Size()-p.assignedCPUs)
-	availableMemory := uint64(0)
-	if p.assignedMemory < p.availableMemory {
-		availableMemory = p.availableMemory - p.assignedMemory
+	status := fmt.Sprintf("CPU: Free: %d, Static: [%v], Common: [%v], Reserved: [%v], Mem: %dM",
+		p.freeCPUs(p.options.CPUOvercommitRatio), p.usedCPUs, p.availableCPUs, p.reservedCPUs, p.freeMemory(p.options.MemoryOvercommitRatio)/1024/1024)
+
+	if p.options.CPUOvercommitRatio > 1 || p.options.MemoryOvercommitRatio > 1 {
+		status += fmt.Sprintf(", Overcommit: CPU x%.2f, Mem x%.2f", max(p.options.CPUOvercommitRatio, 1), max(p.options.MemoryOvercommitRatio, 1))
 	}
 
-	return fmt.Sprintf("CPU: Free: %d, Static: [%v], Common: [%v], Reserved: [%v], Mem: %dM",
-		availableCPUs, p.usedCPUs, p.availableCPUs, p.reservedCPUs, availableMemory/1024/1024)
+	return status
 }
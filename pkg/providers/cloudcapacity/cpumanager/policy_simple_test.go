@@ -378,3 +378,217 @@ func TestSimpleAllocateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleOvercommit(t *testing.T) {
+	t.Parallel()
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+		},
+	}
+
+	options := SimplePolicyOptions{
+		CPUOvercommitRatio:    4,
+		MemoryOvercommitRatio: 1.5,
+	}
+
+	policy, err := NewSimplePolicyWithOptions(topo, []int{}, 0, options)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 48, policy.AvailableCPUs())
+	assert.Equal(t, uint64(48*1024*1024*1024), policy.AvailableMemory())
+
+	// Pinned VM consumes physical CPUs and memory
+	err = policy.AllocateOrUpdate(&resources.VMResources{ID: 100, CPUs: 2, CPUSet: cpuset.New(0, 1), Memory: 16 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, 40, policy.AvailableCPUs())
+
+	// Pinned VM cannot use overcommitted memory
+	err = policy.AllocateOrUpdate(&resources.VMResources{ID: 101, CPUs: 2, CPUSet: cpuset.New(2, 3), Memory: 24 * 1024 * 1024 * 1024})
+	assert.Error(t, err)
+
+	// Unpinned VMs overcommit only the memory not pinned: (32G - 16G) * 1.5 = 24G
+	err = policy.Allocate(&resources.VMResources{ID: 102, CPUs: 32, Memory: 24 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, policy.AvailableCPUs())
+	assert.Equal(t, uint64(0), policy.AvailableMemory())
+	assert.Equal(t, "CPU: Free: 8, Static: [0-1], Common: [2-11], Reserved: [], Mem: 0M, Overcommit: CPU x4.00, Mem x1.50", policy.Status())
+
+	err = policy.Allocate(&resources.VMResources{ID: 103, CPUs: 4, Memory: 1024 * 1024 * 1024})
+	assert.Error(t, err)
+
+	// Pinned VM cannot shrink the capacity below the assigned memory
+	err = policy.AllocateOrUpdate(&resources.VMResources{ID: 104, CPUs: 2, CPUSet: cpuset.New(2, 3), Memory: 1024 * 1024 * 1024})
+	assert.Error(t, err)
+
+	err = policy.Release(&resources.VMResources{ID: 102, CPUs: 32, Memory: 24 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, 40, policy.AvailableCPUs())
+	assert.Equal(t, uint64(24*1024*1024*1024), policy.AvailableMemory())
+
+	// VM tolerates a lower CPU overcommit
+	err = policy.Allocate(&resources.VMResources{ID: 105, CPUs: 4, Memory: 1024 * 1024 * 1024, CPUOvercommitRatio: 2})
+	assert.NoError(t, err)
+
+	// VM does not tolerate the host memory overcommit
+	err = policy.Allocate(&resources.VMResources{ID: 106, CPUs: 4, Memory: 17 * 1024 * 1024 * 1024, MemoryOvercommitRatio: 1})
+	assert.Error(t, err)
+
+	err = policy.Allocate(&resources.VMResources{ID: 107, CPUs: 4, Memory: 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, 32, policy.AvailableCPUs())
+
+	err = policy.Release(&resources.VMResources{ID: 100, CPUs: 2, CPUSet: cpuset.New(0, 1), Memory: 16 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(46*1024*1024*1024), policy.AvailableMemory())
+}
+
+func TestSimpleMemoryBalloon(t *testing.T) {
+	t.Parallel()
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+		},
+	}
+
+	policy, err := NewSimplePolicy(topo, []int{}, 0)
+	assert.NoError(t, err)
+
+	// Ballooned VM commits only the minimum memory
+	vm := &resources.VMResources{ID: 100, CPUs: 2, Memory: 16 * 1024 * 1024 * 1024, MinMemory: 4 * 1024 * 1024 * 1024}
+
+	err = policy.Allocate(vm)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(28*1024*1024*1024), policy.AvailableMemory())
+
+	err = policy.Allocate(&resources.VMResources{ID: 101, CPUs: 2, Memory: 24 * 1024 * 1024 * 1024, MinMemory: 32 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4*1024*1024*1024), policy.AvailableMemory())
+
+	// Pinned VM keeps the full memory pinned
+	err = policy.AllocateOrUpdate(&resources.VMResources{ID: 102, CPUs: 2, CPUSet: cpuset.New(0, 1), Memory: 2 * 1024 * 1024 * 1024, MinMemory: 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2*1024*1024*1024), policy.AvailableMemory())
+
+	err = policy.Release(vm)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6*1024*1024*1024), policy.AvailableMemory())
+}
+
+func TestSimpleFits(t *testing.T) {
+	t.Parallel()
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+		},
+	}
+
+	options := SimplePolicyOptions{
+		CPUOvercommitRatio:    2,
+		MemoryOvercommitRatio: 1.5,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		vm       *resources.VMResources
+		expected bool
+	}{
+		{
+			name:     "host overcommit",
+			vm:       &resources.VMResources{CPUs: 24, Memory: 40 * 1024 * 1024 * 1024},
+			expected: true,
+		},
+		{
+			name:     "host overcommit exceeded",
+			vm:       &resources.VMResources{CPUs: 25, Memory: 40 * 1024 * 1024 * 1024},
+			expected: false,
+		},
+		{
+			name:     "lower CPU overcommit",
+			vm:       &resources.VMResources{CPUs: 16, Memory: 8 * 1024 * 1024 * 1024, CPUOvercommitRatio: 1},
+			expected: false,
+		},
+		{
+			name:     "lower memory overcommit",
+			vm:       &resources.VMResources{CPUs: 4, Memory: 40 * 1024 * 1024 * 1024, MemoryOvercommitRatio: 1},
+			expected: false,
+		},
+		{
+			name:     "higher memory overcommit",
+			vm:       &resources.VMResources{CPUs: 4, Memory: 56 * 1024 * 1024 * 1024, MemoryOvercommitRatio: 2},
+			expected: false,
+		},
+		{
+			name:     "balloon memory",
+			vm:       &resources.VMResources{CPUs: 4, Memory: 64 * 1024 * 1024 * 1024, MinMemory: 16 * 1024 * 1024 * 1024, MemoryOvercommitRatio: 1},
+			expected: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewSimplePolicyWithOptions(topo, []int{}, 0, options)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, policy.Fits(tc.vm))
+			assert.Equal(t, tc.expected, policy.Allocate(tc.vm) == nil)
+		})
+	}
+}
+
+func TestSimpleSharedMemory(t *testing.T) {
+	t.Parallel()
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+		},
+	}
+
+	policy, err := NewSimplePolicyWithOptions(topo, []int{}, 0, SimplePolicyOptions{SharedMemory: 4 * 1024 * 1024 * 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(36*1024*1024*1024), policy.AvailableMemory())
+
+	policy.(*simplePolicy).SetSharedMemory(8 * 1024 * 1024 * 1024)
+	assert.Equal(t, uint64(40*1024*1024*1024), policy.AvailableMemory())
+}
+
+func TestSimpleHugePages(t *testing.T) {
+	t.Parallel()
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+			HugePages: map[int]map[uint64]uint64{
+				0: {2048: 512},
+				1: {2048: 512},
+			},
+		},
+	}
+
+	policy, err := NewSimplePolicy(topo, []int{}, 0)
+	assert.NoError(t, err)
+
+	running := &resources.VMResources{ID: 100, CPUs: 2, Memory: 1024 * 1024 * 1024, HugePageSize: 2}
+	assert.NoError(t, policy.AllocateOrUpdate(running))
+
+	vm := &resources.VMResources{ID: 101, CPUs: 2, Memory: 1024 * 1024 * 1024, HugePageSize: 2}
+	assert.True(t, policy.Fits(vm))
+	assert.NoError(t, policy.Allocate(vm))
+
+	assert.False(t, policy.Fits(&resources.VMResources{CPUs: 1, Memory: 2 * 1024 * 1024, HugePageSize: 2}))
+	assert.Error(t, policy.Allocate(&resources.VMResources{CPUs: 1, Memory: 2 * 1024 * 1024, HugePageSize: 2}))
+	assert.False(t, policy.Fits(&resources.VMResources{CPUs: 1, Memory: 1024 * 1024 * 1024, HugePageSize: 1024}))
+
+	assert.NoError(t, policy.Release(running))
+	assert.True(t, policy.Fits(&resources.VMResources{CPUs: 1, Memory: 1024 * 1024 * 1024, HugePageSize: 2}))
+	assert.False(t, policy.Fits(&resources.VMResources{CPUs: 1, Memory: 1024*1024*1024 + 1024*1024, HugePageSize: 2}))
+}
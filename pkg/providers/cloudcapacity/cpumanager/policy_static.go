@@ -55,6 +55,7 @@ type staticPolicy struct {
 	// Memory-related fields
 	memTopology *topology.MemTopology
 	numaNodes   map[int]uint64
+	hugePages   *hugePages
 
 	// Available memory for allocation
 	availableMemory uint64
@@ -73,6 +74,11 @@ var _ Policy = &staticPolicy{}
 
 // NewStaticPolicy returns a resource manager policy that handles both CPU and memory allocation based on static topology-aware strategy
 func NewStaticPolicy(logger logr.Logger, sysTopology *topology.Topology, reservedCPUs []int, reservedMemory uint64) (Policy, error) {
+	return NewStaticPolicyWithOptions(logger, sysTopology, reservedCPUs, reservedMemory, DefaultStaticPolicyOptions())
+}
+
+// NewStaticPolicyWithOptions returns a static policy with the CPU assignment options
+func NewStaticPolicyWithOptions(logger logr.Logger, sysTopology *topology.Topology, reservedCPUs []int, reservedMemory uint64, options StaticPolicyOptions) (Policy, error) {
 	if sysTopology == nil {
 		return nil, fmt.Errorf("system topology must be provided for %s policy", string(PolicyStatic))
 	}
@@ -96,17 +102,13 @@ func NewStaticPolicy(logger logr.Logger, sysTopology *topology.Topology, reserve
 		availableCPUs: allCPUs.Difference(reservedCPUSet),
 		usedCPUs:      cpuset.New(),
 		reservedCPUs:  reservedCPUSet,
-		options: StaticPolicyOptions{
-			FullPhysicalCPUsOnly:           false,
-			DistributeCPUsAcrossNUMA:       false,
-			DistributeCPUsAcrossCores:      false,
-			PreferAlignByUncoreCacheOption: true,
-		},
-		cpuTopology:  &sysTopology.CPUTopology,
-		cpuGroupSize: sysTopology.CPUTopology.CPUsPerCore(),
+		options:       options,
+		cpuTopology:   &sysTopology.CPUTopology,
+		cpuGroupSize:  sysTopology.CPUTopology.CPUsPerCore(),
 
 		memTopology:     &sysTopology.MemTopology,
 		numaNodes:       numaNodes,
+		hugePages:       newHugePages(sysTopology.HugePages),
 		availableMemory: sysTopology.TotalMemory - reservedMemory,
 		assignedMemory:  0,
 
@@ -138,6 +140,45 @@ func (p *staticPolicy) AvailableMemory() uint64 {
 	return p.availableMemory - p.assignedMemory
 }
 
+func (p *staticPolicy) Fits(op *resources.VMResources) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.options.FullPhysicalCPUsOnly && p.cpuGroupSize > 1 && op.CPUs%p.cpuGroupSize != 0 {
+		return false
+	}
+
+	if op.HugePageSize > 0 && !p.fitsHugePages(op) {
+		return false
+	}
+
+	return p.assignedMemory+op.Memory <= p.availableMemory && p.assignedCPUs+op.CPUs <= p.availableCPUs.Size()
+}
+
+// fitsHugePages reports whether the NUMA nodes with available CPUs have enough free hugepages for the VM memory.
+func (p *staticPolicy) fitsHugePages(op *resources.VMResources) bool {
+	pageSize, pages, err := vmHugePages(op)
+	if err != nil {
+		return false
+	}
+
+	nodes := []int{}
+
+	for _, i := range p.cpuTopology.CPUDetails.NUMANodes().List() {
+		if p.availableCPUs.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i)).IsEmpty() {
+			continue
+		}
+
+		if op.MaxNUMANodes == 1 && p.hugePages.Free(pageSize, i) >= pages {
+			return true
+		}
+
+		nodes = append(nodes, i)
+	}
+
+	return op.MaxNUMANodes != 1 && len(nodes) > 0 && p.hugePages.Free(pageSize, nodes...) >= pages
+}
+
 func (p *staticPolicy) Allocate(op *resources.VMResources) error {
 	if op.CPUs <= 0 && op.Memory == 0 {
 		return nil
@@ -146,6 +187,10 @@ func (p *staticPolicy) Allocate(op *resources.VMResources) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.options.FullPhysicalCPUsOnly && p.cpuGroupSize > 1 && op.CPUs%p.cpuGroupSize != 0 {
+		return fmt.Errorf("requested CPUs must be a multiple of the CPUs per core: requested=%d, cpusPerCore=%d", op.CPUs, p.cpuGroupSize)
+	}
+
 	if p.assignedMemory+op.Memory > p.availableMemory {
 		return fmt.Errorf("not enough memory available: requested=%d, available=%d", op.Memory, p.availableMemory-p.assignedMemory)
 	}
@@ -154,6 +199,11 @@ func (p *staticPolicy) Allocate(op *resources.VMResources) error {
 		return fmt.Errorf("not enough CPUs available: requested=%d, available=%d", op.CPUs, p.availableCPUs.Size()-p.assignedCPUs)
 	}
 
+	pageSize, pages, err := vmHugePages(op)
+	if err != nil {
+		return err
+	}
+
 	NUMANodes := make(map[int]goproxmox.NUMANodeState, p.cpuTopology.CPUDetails.NUMANodes().Size())
 	availableCPUs := cpuset.New()
 
@@ -162,39 +212,75 @@ func (p *staticPolicy) Allocate(op *resources.VMResources) error {
 			return fmt.Errorf("not enough memory available on NUMA node %d", i)
 		}
 
+		if pages > 0 && p.hugePages.Free(pageSize, i)*op.HugePageSize < node.Memory {
+			return fmt.Errorf("not enough hugepages available on NUMA node %d", i)
+		}
+
 		availableCPUs = availableCPUs.Union(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i))
 	}
 
 	if len(op.NUMANodes) == 0 {
 		for i := range p.numaNodes {
+			if pages > 0 && p.hugePages.Free(pageSize, i) == 0 {
+				continue
+			}
+
 			if p.numaNodes[i] >= op.Memory {
 				availableCPUs = availableCPUs.Union(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i))
 			}
 		}
 	}
 
-	cpus, err := p.takeByTopology(p.log, p.availableCPUs.Intersection(availableCPUs), op.CPUs)
+	cpus, err := p.takeByNUMANodes(op, p.availableCPUs.Intersection(availableCPUs))
 	if err != nil {
 		return err
 	}
 
+	numaIDs := []int{}
+	numaCPUs := []int{}
+
+	for _, i := range p.cpuTopology.CPUDetails.NUMANodes().List() {
+		if n := cpus.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i)).Size(); n > 0 {
+			numaIDs = append(numaIDs, i)
+			numaCPUs = append(numaCPUs, n)
+		}
+	}
+
+	// The VM memory is bound to the host NUMA nodes of the assigned CPUs,
+	// each NUMA node of the guest gets the memory share of its CPUs.
+	numaMemory, err := splitNUMAMemory(op.Memory/1024/1024, numaCPUs, op.HugePageSize)
+	if err != nil {
+		return err
+	}
+
+	for idx, i := range numaIDs {
+		if len(op.NUMANodes) != 0 {
+			continue
+		}
+
+		if p.numaNodes[i] < numaMemory[idx]*1024*1024 {
+			return fmt.Errorf("not enough memory available on NUMA node %d: requested=%dM, available=%dM", i, numaMemory[idx], p.numaNodes[i]/1024/1024)
+		}
+
+		if pages > 0 && p.hugePages.Free(pageSize, i) < numaMemory[idx]/op.HugePageSize {
+			return fmt.Errorf("not enough hugepages available on NUMA node %d: requested=%d, available=%d", i, numaMemory[idx]/op.HugePageSize, p.hugePages.Free(pageSize, i))
+		}
+	}
+
 	p.usedCPUs = p.usedCPUs.Union(cpus)
 	p.availableCPUs = p.availableCPUs.Difference(cpus)
 	op.CPUSet = cpus.Clone()
 
 	CPUinx := 0
 
-	for _, i := range p.cpuTopology.CPUDetails.NUMANodes().List() {
-		numaCPUs := op.CPUSet.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i))
-		if numaCPUs.Size() > 0 {
-			NUMANodes[i] = goproxmox.NUMANodeState{
-				CPUs:   fmt.Sprintf("%d-%d", CPUinx, CPUinx+numaCPUs.Size()-1),
-				Memory: op.Memory / 1024 / 1024,
-				Policy: "bind",
-			}
-
-			CPUinx += numaCPUs.Size()
+	for idx, i := range numaIDs {
+		NUMANodes[i] = goproxmox.NUMANodeState{
+			CPUs:   fmt.Sprintf("%d-%d", CPUinx, CPUinx+numaCPUs[idx]-1),
+			Memory: numaMemory[idx],
+			Policy: "bind",
 		}
+
+		CPUinx += numaCPUs[idx]
 	}
 
 	if len(NUMANodes) > 0 {
@@ -209,6 +295,7 @@ func (p *staticPolicy) Allocate(op *resources.VMResources) error {
 		p.numaNodes[i] -= node.Memory * 1024 * 1024
 	}
 
+	p.hugePages.TakeVM(op)
 	p.assignedMemory += op.Memory
 
 	return nil
@@ -262,6 +349,7 @@ func (p *staticPolicy) AllocateOrUpdate(op *resources.VMResources) error {
 		p.numaNodes[i] -= node.Memory * 1024 * 1024
 	}
 
+	p.hugePages.TakeVM(op)
 	p.assignedMemory += op.Memory
 
 	return nil
@@ -300,6 +388,7 @@ func (p *staticPolicy) Release(op *resources.VMResources) error {
 		p.numaNodes[i] += node.Memory * 1024 * 1024
 	}
 
+	p.hugePages.PutVM(op)
 	p.assignedMemory -= op.Memory
 
 	return nil
@@ -351,3 +440,147 @@ func (p *staticPolicy) takeByTopology(logger logr.Logger, availableCPUs cpuset.C
 
 	return takeByTopologyNUMAPacked(logger, p.cpuTopology, availableCPUs, numCPUs, cpuSortingStrategy, p.options.PreferAlignByUncoreCacheOption)
 }
+
+// takeByNUMANodes takes the CPUs bound to the number of host NUMA nodes requested by the VM.
+func (p *staticPolicy) takeByNUMANodes(op *resources.VMResources, availableCPUs cpuset.CPUSet) (cpuset.CPUSet, error) {
+	if op.MaxNUMANodes == 1 {
+		for _, i := range p.cpuTopology.CPUDetails.NUMANodes().List() {
+			cpus, err := p.takeByTopology(p.log, availableCPUs.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i)), op.CPUs)
+			if err == nil {
+				return cpus, nil
+			}
+		}
+
+		return cpuset.New(), fmt.Errorf("not enough CPUs available on a single NUMA node: requested=%d", op.CPUs)
+	}
+
+	cpus, err := p.takeByTopology(p.log, availableCPUs, op.CPUs)
+	if err != nil {
+		return cpus, err
+	}
+
+	nodes := p.cpuTopology.CPUDetails.KeepOnly(cpus).NUMANodes().Size()
+	if op.MinNUMANodes > 0 && nodes < op.MinNUMANodes {
+		cpus, err = p.takeSpreadNUMANodes(availableCPUs, op.CPUs, op.MinNUMANodes)
+		if err != nil {
+			return cpus, err
+		}
+
+		nodes = op.MinNUMANodes
+	}
+
+	if op.MaxNUMANodes > 0 && nodes > op.MaxNUMANodes {
+		return cpuset.New(), fmt.Errorf("CPUs are bound to %d NUMA nodes, requested=%d-%d", nodes, op.MinNUMANodes, op.MaxNUMANodes)
+	}
+
+	return cpus, nil
+}
+
+// takeSpreadNUMANodes takes the CPUs evenly from the NUMA nodes with the most available CPUs.
+func (p *staticPolicy) takeSpreadNUMANodes(availableCPUs cpuset.CPUSet, numCPUs int, numNodes int) (cpuset.CPUSet, error) {
+	groupSize := 1
+	if p.options.FullPhysicalCPUsOnly {
+		groupSize = p.cpuGroupSize
+	}
+
+	if numCPUs < numNodes*groupSize {
+		return cpuset.New(), fmt.Errorf("not enough CPUs requested to spread across %d NUMA nodes: requested=%d", numNodes, numCPUs)
+	}
+
+	nodeIDs := p.cpuTopology.CPUDetails.NUMANodes().List()
+	if len(nodeIDs) < numNodes {
+		return cpuset.New(), fmt.Errorf("not enough NUMA nodes available: requested=%d, available=%d", numNodes, len(nodeIDs))
+	}
+
+	sort.SliceStable(nodeIDs, func(a, b int) bool {
+		return availableCPUs.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(nodeIDs[a])).Size() >
+			availableCPUs.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(nodeIDs[b])).Size()
+	})
+
+	groups := numCPUs / groupSize
+	result := cpuset.New()
+
+	for idx, i := range nodeIDs[:numNodes] {
+		n := groups / numNodes * groupSize
+		if idx < groups%numNodes {
+			n += groupSize
+		}
+
+		if idx == numNodes-1 {
+			n = numCPUs - result.Size()
+		}
+
+		cpus, err := p.takeByTopology(p.log, availableCPUs.Intersection(p.cpuTopology.CPUDetails.CPUsInNUMANodes(i)), n)
+		if err != nil {
+			return cpuset.New(), fmt.Errorf("not enough CPUs available on NUMA node %d: %w", i, err)
+		}
+
+		result = result.Union(cpus)
+	}
+
+	return result, nil
+}
+
+// splitNUMAMemory distributes the memory in MiB across the NUMA nodes proportionally to their CPUs.
+// The shares are aligned to the hugepage size in MiB, an error is returned if the memory cannot be split into the pages.
+// Without hugepages the shares are aligned to 1GiB if possible.
+func splitNUMAMemory(memory uint64, cpus []int, pageSize uint64) ([]uint64, error) {
+	if len(cpus) == 0 {
+		return nil, nil
+	}
+
+	total := 0
+	for _, n := range cpus {
+		total += n
+	}
+
+	split := func(unit uint64) []uint64 {
+		units := memory / unit
+		shares := make([]uint64, len(cpus))
+
+		var assigned uint64
+
+		for i, n := range cpus[:len(cpus)-1] {
+			shares[i] = units * uint64(n) / uint64(total) * unit
+			if shares[i] == 0 {
+				return nil
+			}
+
+			assigned += shares[i]
+		}
+
+		if assigned >= memory {
+			return nil
+		}
+
+		shares[len(cpus)-1] = memory - assigned
+
+		return shares
+	}
+
+	if pageSize > 0 {
+		if memory%pageSize == 0 {
+			if shares := split(pageSize); shares != nil {
+				return shares, nil
+			}
+		}
+
+		return nil, fmt.Errorf("memory %dM cannot be split into %dM hugepages across %d NUMA nodes", memory, pageSize, len(cpus))
+	}
+
+	if memory%1024 == 0 {
+		if shares := split(1024); shares != nil {
+			return shares, nil
+		}
+	}
+
+	if shares := split(1); shares != nil {
+		return shares, nil
+	}
+
+	// Too little memory for the NUMA nodes, keep it on the first one
+	shares := make([]uint64, len(cpus))
+	shares[0] = memory
+
+	return shares, nil
+}
@@ -158,6 +158,27 @@ func TestStaticAllocate(t *testing.T) {
 				0: {CPUs: "0-15", Memory: 4 * 1024, Policy: "bind"},
 			},
 		},
+		{
+			name: "allocate CPUs across NUMA nodes",
+			topo: &topology.Topology{
+				CPUTopology: *topoDualSocketHT,
+				MemTopology: topology.MemTopology{
+					TotalMemory: 32 * 1024 * 1024 * 1024,
+					NUMANodes: map[int]uint64{
+						0: 16 * 1024 * 1024 * 1024,
+						1: 16 * 1024 * 1024 * 1024,
+					},
+				},
+			},
+			reserved: []int{},
+
+			request: &resources.VMResources{CPUs: 8, Memory: 4 * 1024 * 1024 * 1024},
+			status:  "CPU: Free: 4, Static: [0-2,4,6-8,10], Common: [3,5,9,11], Reserved: [], Mem: 28672M, N0:13312M, N1:15360M",
+			numaStatus: map[int]goproxmox.NUMANodeState{
+				0: {CPUs: "0-5", Memory: 3 * 1024, Policy: "bind"},
+				1: {CPUs: "6-7", Memory: 1 * 1024, Policy: "bind"},
+			},
+		},
 		{
 			name: "allocate more than available CPUs",
 			topo: &topology.Topology{
@@ -446,3 +467,265 @@ func TestStaticAllocateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestStaticAllocateWithOptions(t *testing.T) {
+	t.Parallel()
+	logger, _ := ktesting.NewTestContext(t)
+
+	topo := &topology.Topology{
+		CPUTopology: *topoUncoreSingleSocketSMT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+			NUMANodes: map[int]uint64{
+				0: 32 * 1024 * 1024 * 1024,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		options StaticPolicyOptions
+
+		request *resources.VMResources
+		cpus    cpuset.CPUSet
+		error   error
+	}{
+		{
+			name:    "default options",
+			options: DefaultStaticPolicyOptions(),
+			request: &resources.VMResources{CPUs: 4, Memory: 1024 * 1024 * 1024},
+			cpus:    cpuset.New(0, 1, 8, 9),
+		},
+		{
+			name:    "distribute CPUs across cores",
+			options: StaticPolicyOptions{DistributeCPUsAcrossCores: true},
+			request: &resources.VMResources{CPUs: 4, Memory: 1024 * 1024 * 1024},
+			cpus:    cpuset.New(0, 1, 2, 3),
+		},
+		{
+			name:    "full physical CPUs only",
+			options: StaticPolicyOptions{FullPhysicalCPUsOnly: true},
+			request: &resources.VMResources{CPUs: 4, Memory: 1024 * 1024 * 1024},
+			cpus:    cpuset.New(0, 1, 8, 9),
+		},
+		{
+			name:    "full physical CPUs only with odd CPUs",
+			options: StaticPolicyOptions{FullPhysicalCPUsOnly: true},
+			request: &resources.VMResources{CPUs: 3, Memory: 1024 * 1024 * 1024},
+			error:   fmt.Errorf("requested CPUs must be a multiple of the CPUs per core: requested=3, cpusPerCore=2"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewStaticPolicyWithOptions(logger, topo, []int{}, 0, tc.options)
+			assert.NoError(t, err)
+
+			err = policy.Allocate(tc.request)
+			if tc.error != nil {
+				assert.EqualError(t, err, tc.error.Error())
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.cpus, tc.request.CPUSet)
+		})
+	}
+}
+
+func TestStaticAllocateNUMANodes(t *testing.T) {
+	t.Parallel()
+	logger, _ := ktesting.NewTestContext(t)
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+			NUMANodes: map[int]uint64{
+				0: 16 * 1024 * 1024 * 1024,
+				1: 16 * 1024 * 1024 * 1024,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		request *resources.VMResources
+		nodes   int
+		error   bool
+	}{
+		{
+			name:    "any NUMA nodes",
+			request: &resources.VMResources{CPUs: 8, Memory: 4 * 1024 * 1024 * 1024},
+			nodes:   2,
+		},
+		{
+			name:    "single NUMA node",
+			request: &resources.VMResources{CPUs: 4, Memory: 4 * 1024 * 1024 * 1024, MaxNUMANodes: 1},
+			nodes:   1,
+		},
+		{
+			name:    "single NUMA node without enough CPUs",
+			request: &resources.VMResources{CPUs: 8, Memory: 4 * 1024 * 1024 * 1024, MaxNUMANodes: 1},
+			error:   true,
+		},
+		{
+			name:    "multiple NUMA nodes",
+			request: &resources.VMResources{CPUs: 8, Memory: 4 * 1024 * 1024 * 1024, MinNUMANodes: 2},
+			nodes:   2,
+		},
+		{
+			name:    "multiple NUMA nodes for small VM",
+			request: &resources.VMResources{CPUs: 2, Memory: 4 * 1024 * 1024 * 1024, MinNUMANodes: 2},
+			nodes:   2,
+		},
+		{
+			name:    "multiple NUMA nodes for single CPU",
+			request: &resources.VMResources{CPUs: 1, Memory: 4 * 1024 * 1024 * 1024, MinNUMANodes: 2},
+			error:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewStaticPolicy(logger, topo, []int{}, 0)
+			assert.NoError(t, err)
+
+			err = policy.Allocate(tc.request)
+			if tc.error {
+				assert.Error(t, err)
+				assert.Equal(t, 12, policy.AvailableCPUs())
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, tc.request.NUMANodes, tc.nodes)
+			assert.Equal(t, tc.request.CPUs, tc.request.CPUSet.Size())
+		})
+	}
+}
+
+func TestStaticAllocateHugePages(t *testing.T) {
+	t.Parallel()
+	logger, _ := ktesting.NewTestContext(t)
+
+	topo := &topology.Topology{
+		CPUTopology: *topoDualSocketHT,
+		MemTopology: topology.MemTopology{
+			TotalMemory: 32 * 1024 * 1024 * 1024,
+			NUMANodes: map[int]uint64{
+				0: 16 * 1024 * 1024 * 1024,
+				1: 16 * 1024 * 1024 * 1024,
+			},
+			HugePages: map[int]map[uint64]uint64{
+				0: {1048576: 4},
+				1: {1048576: 2},
+			},
+		},
+	}
+
+	policy, err := NewStaticPolicy(logger, topo, []int{}, 0)
+	assert.NoError(t, err)
+
+	hugePages := policy.(*staticPolicy).hugePages
+
+	vm := &resources.VMResources{ID: 100, CPUs: 4, Memory: 4 * 1024 * 1024 * 1024, HugePageSize: 1024, MaxNUMANodes: 1}
+	assert.True(t, policy.Fits(vm))
+	assert.NoError(t, policy.Allocate(vm))
+	assert.Equal(t, []int{0}, lo.Keys(vm.NUMANodes))
+	assert.Equal(t, uint64(0), hugePages.Free(1048576, 0))
+	assert.Equal(t, uint64(2), hugePages.Free(1048576, 1))
+
+	assert.True(t, policy.Fits(&resources.VMResources{CPUs: 2, Memory: 2 * 1024 * 1024 * 1024, HugePageSize: 1024, MaxNUMANodes: 1}))
+	assert.False(t, policy.Fits(&resources.VMResources{CPUs: 2, Memory: 3 * 1024 * 1024 * 1024, HugePageSize: 1024, MaxNUMANodes: 1}))
+	assert.False(t, policy.Fits(&resources.VMResources{CPUs: 2, Memory: 1536 * 1024 * 1024, HugePageSize: 1024}))
+
+	assert.Error(t, policy.Allocate(&resources.VMResources{ID: 101, CPUs: 2, Memory: 3 * 1024 * 1024 * 1024, HugePageSize: 1024}))
+	assert.Error(t, policy.Allocate(&resources.VMResources{ID: 102, CPUs: 2, Memory: 1536 * 1024 * 1024, HugePageSize: 1024}))
+	assert.Equal(t, uint64(2), hugePages.Free(1048576, 1))
+
+	assert.NoError(t, policy.Release(vm))
+	assert.Equal(t, uint64(4), hugePages.Free(1048576, 0))
+}
+
+func TestSplitNUMAMemory(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		memory   uint64
+		cpus     []int
+		pageSize uint64
+		expected []uint64
+		error    bool
+	}{
+		{
+			name:     "aligned to 1GiB",
+			memory:   4096,
+			cpus:     []int{2, 2},
+			expected: []uint64{2048, 2048},
+		},
+		{
+			name:     "not aligned",
+			memory:   3000,
+			cpus:     []int{1, 1},
+			expected: []uint64{1500, 1500},
+		},
+		{
+			name:     "too little memory",
+			memory:   1,
+			cpus:     []int{1, 1},
+			expected: []uint64{1, 0},
+		},
+		{
+			name:     "1GiB hugepages",
+			memory:   3072,
+			cpus:     []int{2, 1},
+			pageSize: 1024,
+			expected: []uint64{2048, 1024},
+		},
+		{
+			name:     "2MiB hugepages",
+			memory:   1000,
+			cpus:     []int{1, 1},
+			pageSize: 2,
+			expected: []uint64{500, 500},
+		},
+		{
+			name:     "memory is not a multiple of hugepages",
+			memory:   1536,
+			cpus:     []int{1},
+			pageSize: 1024,
+			error:    true,
+		},
+		{
+			name:     "too little hugepages for NUMA nodes",
+			memory:   1024,
+			cpus:     []int{1, 1},
+			pageSize: 1024,
+			error:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			shares, err := splitNUMAMemory(tc.memory, tc.cpus, tc.pageSize)
+			if tc.error {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, shares)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
+
+	"k8s.io/utils/cpuset"
+)
+
+// Report is the state of the node after the replay of the events
+type Report struct {
+	// Name is the name of the policy with its options
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+
+	Events       []EventResult `json:"events,omitempty"`
+	FailedEvents int           `json:"failedEvents"`
+	VMs          int           `json:"vms"`
+
+	AvailableCPUs   int    `json:"availableCPUs"`
+	AvailableMemory uint64 `json:"availableMemory"`
+
+	// Fragmentation is the state of the free CPUs, nil if the policy does not pin the VMs
+	Fragmentation *Fragmentation `json:"fragmentation,omitempty"`
+	// Alignment is the placement of the pinned VMs, nil if the policy does not pin the VMs
+	Alignment *Alignment `json:"alignment,omitempty"`
+
+	// Fits is the number of the VMs of each instance type which can be allocated on the node
+	Fits map[string]int `json:"fits"`
+}
+
+// EventResult is the result of the event replay
+type EventResult struct {
+	Op     string `json:"op"`
+	ID     int    `json:"id"`
+	CPUs   int    `json:"cpus,omitempty"`
+	CPUSet string `json:"cpuset,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Fragmentation is the state of the CPUs not pinned to the VMs
+type Fragmentation struct {
+	FreeCPUs int `json:"freeCPUs"`
+	// FreeCores are the physical cores with all CPUs free
+	FreeCores int `json:"freeCores"`
+	// SplitCores are the physical cores with the free and the used CPUs
+	SplitCores int `json:"splitCores"`
+	// FreeUncoreCaches are the uncore caches with all CPUs free
+	FreeUncoreCaches int `json:"freeUncoreCaches"`
+	// SplitUncoreCaches are the uncore caches with the free and the used CPUs
+	SplitUncoreCaches int `json:"splitUncoreCaches"`
+	// NUMANodes is the number of the free CPUs in each NUMA node
+	NUMANodes map[int]int `json:"numaNodes"`
+	// Ratio is the share of the free CPUs on the split cores, 0 if all free CPUs are on the free cores
+	Ratio float64 `json:"ratio"`
+}
+
+// Alignment is the placement of the CPUs of the pinned VMs
+type Alignment struct {
+	VMs int `json:"vms"`
+	// NUMAAligned are the VMs with all CPUs in one NUMA node
+	NUMAAligned int `json:"numaAligned"`
+	// UncoreAligned are the VMs with all CPUs in one uncore cache
+	UncoreAligned int `json:"uncoreAligned"`
+	// SharedCores are the VMs sharing a physical core with another VM
+	SharedCores int `json:"sharedCores"`
+}
+
+func (s *Simulator) usedCPUs(vms map[int]*resources.VMResources) cpuset.CPUSet {
+	used := cpuset.New()
+
+	for _, vm := range vms {
+		used = used.Union(vm.CPUSet)
+	}
+
+	return used
+}
+
+func (s *Simulator) fragmentation(vms map[int]*resources.VMResources) *Fragmentation {
+	used := s.usedCPUs(vms)
+	if used.IsEmpty() {
+		return nil
+	}
+
+	details := s.topology.CPUDetails
+	free := details.CPUs().Difference(used).Difference(s.reservedCPUs)
+
+	f := &Fragmentation{
+		FreeCPUs:  free.Size(),
+		NUMANodes: map[int]int{},
+	}
+
+	splitFreeCPUs := 0
+
+	for _, core := range details.Cores().List() {
+		cpus := details.CPUsInCores(core)
+
+		switch n := cpus.Intersection(free).Size(); {
+		case n == cpus.Size():
+			f.FreeCores++
+		case n > 0:
+			f.SplitCores++
+			splitFreeCPUs += n
+		}
+	}
+
+	for _, uncore := range details.UncoreCaches().List() {
+		cpus := details.CPUsInUncoreCaches(uncore)
+
+		switch n := cpus.Intersection(free).Size(); {
+		case n == cpus.Size():
+			f.FreeUncoreCaches++
+		case n > 0:
+			f.SplitUncoreCaches++
+		}
+	}
+
+	for _, node := range details.NUMANodes().List() {
+		f.NUMANodes[node] = details.CPUsInNUMANodes(node).Intersection(free).Size()
+	}
+
+	if f.FreeCPUs > 0 {
+		f.Ratio = float64(splitFreeCPUs) / float64(f.FreeCPUs)
+	}
+
+	return f
+}
+
+func (s *Simulator) alignment(vms map[int]*resources.VMResources) *Alignment {
+	used := s.usedCPUs(vms)
+	if used.IsEmpty() {
+		return nil
+	}
+
+	details := s.topology.CPUDetails
+	a := &Alignment{}
+
+	for _, vm := range vms {
+		if vm.CPUSet.IsEmpty() {
+			continue
+		}
+
+		a.VMs++
+
+		vmDetails := details.KeepOnly(vm.CPUSet)
+		if vmDetails.NUMANodes().Size() == 1 {
+			a.NUMAAligned++
+		}
+
+		if s.topology.CheckAlignment(vm.CPUSet).UncoreCache {
+			a.UncoreAligned++
+		}
+
+		others := used.Difference(vm.CPUSet)
+		if !details.CPUsInCores(vmDetails.Cores().List()...).Intersection(others).IsEmpty() {
+			a.SharedCores++
+		}
+	}
+
+	return a
+}
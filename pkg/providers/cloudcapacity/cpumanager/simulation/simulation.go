@@ -0,0 +1,235 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulation replays the VM allocations with the CPU manager policies
+// and reports the state of the node.
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// EventAllocate allocates the VM resources
+	EventAllocate = "allocate"
+	// EventRelease releases the VM resources allocated before
+	EventRelease = "release"
+
+	// maxFits limits the number of the VMs allocated to count the free capacity
+	maxFits = 10000
+)
+
+// Event is the allocation or the release of the VM resources on the node
+type Event struct {
+	Op string `json:"op"`
+	// ID is the VM ID, it is used to release the VM resources
+	ID int `json:"id"`
+	// InstanceType is the name of the instance type, it sets the CPUs and the memory of the VM
+	InstanceType string `json:"instanceType,omitempty"`
+	// CPUs is the number of the VM CPUs
+	CPUs int `json:"cpus,omitempty"`
+	// Memory is the VM memory, for example 8Gi
+	Memory resource.Quantity `json:"memory,omitempty"`
+}
+
+// InstanceType is the size of the VM
+type InstanceType struct {
+	Name   string `json:"name"`
+	CPUs   int    `json:"cpus"`
+	Memory uint64 `json:"memory"`
+}
+
+// PolicyFactory returns a new policy for the replay of the events
+type PolicyFactory func() (cpumanager.Policy, error)
+
+// Simulator replays the events on the node topology
+type Simulator struct {
+	topology      *topology.Topology
+	reservedCPUs  cpuset.CPUSet
+	instanceTypes []InstanceType
+}
+
+// NewSimulator returns a simulator of the node with the topology and the reserved CPUs
+func NewSimulator(sysTopology *topology.Topology, reservedCPUs []int, instanceTypes []InstanceType) *Simulator {
+	return &Simulator{
+		topology:      sysTopology,
+		reservedCPUs:  cpuset.New(reservedCPUs...),
+		instanceTypes: instanceTypes,
+	}
+}
+
+// Run replays the events with the policy and reports the state of the node,
+// the failed events do not stop the replay, they are reported in the event results.
+func (s *Simulator) Run(name string, newPolicy PolicyFactory, events []Event) (*Report, error) {
+	policy, err := newPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	vms, results := s.replay(policy, events)
+
+	report := &Report{
+		Name:            name,
+		Policy:          policy.Name(),
+		Events:          results,
+		AvailableCPUs:   policy.AvailableCPUs(),
+		AvailableMemory: policy.AvailableMemory(),
+		Fits:            map[string]int{},
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			report.FailedEvents++
+		}
+	}
+
+	report.VMs = len(vms)
+	report.Fragmentation = s.fragmentation(vms)
+	report.Alignment = s.alignment(vms)
+
+	for _, instanceType := range s.instanceTypes {
+		fits, err := s.fits(newPolicy, events, instanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Fits[instanceType.Name] = fits
+	}
+
+	return report, nil
+}
+
+// replay applies the events to the policy, it returns the allocated VMs and the results of the events
+func (s *Simulator) replay(policy cpumanager.Policy, events []Event) (map[int]*resources.VMResources, []EventResult) {
+	vms := map[int]*resources.VMResources{}
+	results := make([]EventResult, 0, len(events))
+
+	for _, event := range events {
+		result := EventResult{Op: event.Op, ID: event.ID}
+
+		if err := s.apply(policy, vms, event); err != nil {
+			result.Error = err.Error()
+		} else if vm, ok := vms[event.ID]; ok && event.Op == EventAllocate {
+			result.CPUs = vm.CPUs
+			result.CPUSet = vm.CPUSet.String()
+		}
+
+		results = append(results, result)
+	}
+
+	return vms, results
+}
+
+func (s *Simulator) apply(policy cpumanager.Policy, vms map[int]*resources.VMResources, event Event) error {
+	switch event.Op {
+	case EventAllocate:
+		if _, ok := vms[event.ID]; ok {
+			return fmt.Errorf("VM %d is already allocated", event.ID)
+		}
+
+		op, err := s.vmResources(event)
+		if err != nil {
+			return err
+		}
+
+		if err := policy.Allocate(op); err != nil {
+			return err
+		}
+
+		vms[event.ID] = op
+	case EventRelease:
+		op, ok := vms[event.ID]
+		if !ok {
+			return fmt.Errorf("VM %d is not allocated", event.ID)
+		}
+
+		if err := policy.Release(op); err != nil {
+			return err
+		}
+
+		delete(vms, event.ID)
+	default:
+		return fmt.Errorf("unknown event %q", event.Op)
+	}
+
+	return nil
+}
+
+func (s *Simulator) vmResources(event Event) (*resources.VMResources, error) {
+	op := &resources.VMResources{
+		ID:     event.ID,
+		CPUs:   event.CPUs,
+		Memory: uint64(event.Memory.Value()),
+	}
+
+	if event.InstanceType != "" {
+		instanceType, ok := s.instanceType(event.InstanceType)
+		if !ok {
+			return nil, fmt.Errorf("unknown instance type %q", event.InstanceType)
+		}
+
+		op.CPUs = instanceType.CPUs
+		op.Memory = instanceType.Memory
+	}
+
+	if op.CPUs <= 0 {
+		return nil, fmt.Errorf("VM %d has no CPUs", event.ID)
+	}
+
+	return op, nil
+}
+
+func (s *Simulator) instanceType(name string) (InstanceType, bool) {
+	for _, instanceType := range s.instanceTypes {
+		if instanceType.Name == name {
+			return instanceType, true
+		}
+	}
+
+	return InstanceType{}, false
+}
+
+// fits returns the number of the VMs of the instance type which can be allocated after the events
+func (s *Simulator) fits(newPolicy PolicyFactory, events []Event, instanceType InstanceType) (int, error) {
+	if instanceType.CPUs <= 0 {
+		return 0, nil
+	}
+
+	policy, err := newPolicy()
+	if err != nil {
+		return 0, err
+	}
+
+	s.replay(policy, events)
+
+	n := 0
+	for n < maxFits {
+		if err := policy.Allocate(&resources.VMResources{CPUs: instanceType.CPUs, Memory: instanceType.Memory}); err != nil {
+			break
+		}
+
+		n++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/simulation"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/cpumanager/topology"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/resourcemanager/settings"
+	testtopology "github.com/sergelogvinov/karpenter-provider-proxmox/test/topology"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2/ktesting"
+)
+
+func TestSimulatorRun(t *testing.T) {
+	t.Parallel()
+	logger, _ := ktesting.NewTestContext(t)
+
+	topo := &topology.Topology{
+		CPUTopology: *testtopology.CPUTopoUncoreSingleSocketSMT,
+		MemTopology: *testtopology.MemTopoUncoreDualSocketNoSMT16G,
+	}
+
+	instanceTypes := []simulation.InstanceType{
+		{Name: "c1.2VCPU-4GB", CPUs: 2, Memory: 4 * 1024 * 1024 * 1024},
+		{Name: "c1.4VCPU-8GB", CPUs: 4, Memory: 8 * 1024 * 1024 * 1024},
+	}
+
+	events := []simulation.Event{
+		{Op: simulation.EventAllocate, ID: 100, CPUs: 3, Memory: resource.MustParse("2Gi")},
+		{Op: simulation.EventAllocate, ID: 101, InstanceType: "c1.2VCPU-4GB"},
+		{Op: simulation.EventAllocate, ID: 102, InstanceType: "c1.8VCPU-16GB"},
+		{Op: simulation.EventRelease, ID: 103},
+	}
+
+	simulator := simulation.NewSimulator(topo, nil, instanceTypes)
+
+	testCases := []struct {
+		name      string
+		newPolicy simulation.PolicyFactory
+		expected  *simulation.Report
+	}{
+		{
+			name: "simple",
+			newPolicy: func() (cpumanager.Policy, error) {
+				return cpumanager.NewSimplePolicy(topo, nil, 0)
+			},
+			expected: &simulation.Report{
+				Policy: "simple",
+				Events: []simulation.EventResult{
+					{Op: simulation.EventAllocate, ID: 100, CPUs: 3},
+					{Op: simulation.EventAllocate, ID: 101, CPUs: 2},
+					{Op: simulation.EventAllocate, ID: 102, Error: `unknown instance type "c1.8VCPU-16GB"`},
+					{Op: simulation.EventRelease, ID: 103, Error: "VM 103 is not allocated"},
+				},
+				FailedEvents:    2,
+				VMs:             2,
+				AvailableCPUs:   11,
+				AvailableMemory: 10 * 1024 * 1024 * 1024,
+				Fits: map[string]int{
+					"c1.2VCPU-4GB": 2,
+					"c1.4VCPU-8GB": 1,
+				},
+			},
+		},
+		{
+			name: "static",
+			newPolicy: func() (cpumanager.Policy, error) {
+				return cpumanager.NewStaticPolicy(logger, topo, nil, 0)
+			},
+			expected: &simulation.Report{
+				Policy: "static",
+				Events: []simulation.EventResult{
+					{Op: simulation.EventAllocate, ID: 100, CPUs: 3, CPUSet: "0-2"},
+					{Op: simulation.EventAllocate, ID: 101, CPUs: 2, CPUSet: "3-4"},
+					{Op: simulation.EventAllocate, ID: 102, Error: `unknown instance type "c1.8VCPU-16GB"`},
+					{Op: simulation.EventRelease, ID: 103, Error: "VM 103 is not allocated"},
+				},
+				FailedEvents:    2,
+				VMs:             2,
+				AvailableCPUs:   11,
+				AvailableMemory: 10 * 1024 * 1024 * 1024,
+				Fragmentation: &simulation.Fragmentation{
+					FreeCPUs:          11,
+					FreeCores:         11,
+					FreeUncoreCaches:  2,
+					SplitUncoreCaches: 1,
+					NUMANodes:         map[int]int{0: 3, 1: 8},
+				},
+				Alignment: &simulation.Alignment{
+					VMs:           2,
+					NUMAAligned:   2,
+					UncoreAligned: 1,
+				},
+				Fits: map[string]int{
+					"c1.2VCPU-4GB": 2,
+					"c1.4VCPU-8GB": 1,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			report, err := simulator.Run(tc.name, tc.newPolicy, events)
+			require.NoError(t, err)
+
+			tc.expected.Name = tc.name
+			assert.Equal(t, tc.expected, report)
+		})
+	}
+}
+
+func TestSimulatorFragmentation(t *testing.T) {
+	t.Parallel()
+	logger, _ := ktesting.NewTestContext(t)
+
+	topo, err := topology.DiscoverFromSettings(&settings.NodeSettings{
+		NumThreads: 2,
+		NUMANodes: settings.NUMANodes{
+			0: {CPUs: "0-7", MemSize: 16 * 1024 * 1024 * 1024},
+		},
+	})
+	require.NoError(t, err)
+
+	events := []simulation.Event{
+		{Op: simulation.EventAllocate, ID: 100, CPUs: 2, Memory: resource.MustParse("1Gi")},
+		{Op: simulation.EventAllocate, ID: 101, CPUs: 2, Memory: resource.MustParse("1Gi")},
+	}
+
+	simulator := simulation.NewSimulator(topo, []int{0}, []simulation.InstanceType{
+		{Name: "c1.2VCPU-4GB", CPUs: 2, Memory: 4 * 1024 * 1024 * 1024},
+	})
+
+	testCases := []struct {
+		name          string
+		options       cpumanager.StaticPolicyOptions
+		fragmentation *simulation.Fragmentation
+		alignment     *simulation.Alignment
+		fits          int
+	}{
+		{
+			name:    "packed",
+			options: cpumanager.DefaultStaticPolicyOptions(),
+			fragmentation: &simulation.Fragmentation{
+				FreeCPUs:          3,
+				FreeCores:         1,
+				SplitCores:        1,
+				SplitUncoreCaches: 1,
+				NUMANodes:         map[int]int{0: 3},
+				Ratio:             1.0 / 3,
+			},
+			alignment: &simulation.Alignment{VMs: 2, NUMAAligned: 2, UncoreAligned: 2},
+			fits:      1,
+		},
+		{
+			name:    "distribute CPUs across cores",
+			options: cpumanager.StaticPolicyOptions{DistributeCPUsAcrossCores: true},
+			fragmentation: &simulation.Fragmentation{
+				FreeCPUs:          3,
+				SplitCores:        3,
+				SplitUncoreCaches: 1,
+				NUMANodes:         map[int]int{0: 3},
+				Ratio:             1,
+			},
+			alignment: &simulation.Alignment{VMs: 2, NUMAAligned: 2, UncoreAligned: 2},
+			fits:      1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			report, err := simulator.Run(tc.name, func() (cpumanager.Policy, error) {
+				return cpumanager.NewStaticPolicyWithOptions(logger, topo, []int{0}, 0, tc.options)
+			}, events)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.fragmentation, report.Fragmentation)
+			assert.Equal(t, tc.alignment, report.Alignment)
+			assert.Equal(t, tc.fits, report.Fits["c1.2VCPU-4GB"])
+		})
+	}
+}
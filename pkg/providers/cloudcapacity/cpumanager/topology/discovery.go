@@ -18,6 +18,7 @@ package topology
 
 import (
 	"fmt"
+	"maps"
 
 	"github.com/luthermonson/go-proxmox"
 
@@ -80,6 +81,8 @@ func DiscoverFromSettings(settings *settings.NodeSettings) (*Topology, error) {
 	memTotal := uint64(0)
 	memNUMA := make(map[int]uint64)
 
+	var hugePages map[int]map[uint64]uint64
+
 	parsedCPUs := make(map[int]cpuset.CPUSet, len(settings.NUMANodes))
 	for i, numa := range settings.NUMANodes {
 		cpus, err := cpuset.Parse(numa.CPUs)
@@ -93,6 +96,14 @@ func DiscoverFromSettings(settings *settings.NodeSettings) (*Topology, error) {
 
 		memTotal += numa.MemSize
 		memNUMA[i] = numa.MemSize
+
+		if len(numa.HugePages) > 0 {
+			if hugePages == nil {
+				hugePages = make(map[int]map[uint64]uint64)
+			}
+
+			hugePages[i] = maps.Clone(numa.HugePages)
+		}
 	}
 
 	coresPerCache := max(1, nCores/nCache)
@@ -131,6 +142,58 @@ func DiscoverFromSettings(settings *settings.NodeSettings) (*Topology, error) {
 		MemTopology: MemTopology{
 			NUMANodes:   memNUMA,
 			TotalMemory: memTotal,
+			HugePages:   hugePages,
+		},
+	}, nil
+}
+
+// DiscoverFromNodeTopology returns Topology based on the topology published by proxmox-scheduler.
+func DiscoverFromNodeTopology(nodeTopology *settings.NodeTopology) (*Topology, error) {
+	if nodeTopology == nil || len(nodeTopology.CPUs) == 0 {
+		return nil, fmt.Errorf("could not detect cpu topology from empty node topology")
+	}
+
+	cpuDetails := CPUDetails{}
+	for _, cpu := range nodeTopology.CPUs {
+		cpuDetails[cpu.ID] = CPUInfo{
+			CoreID:        cpu.CoreID,
+			SocketID:      cpu.SocketID,
+			NUMANodeID:    cpu.NUMANodeID,
+			UncoreCacheID: cpu.UncoreCache,
+		}
+	}
+
+	memTotal := uint64(0)
+	memNUMA := make(map[int]uint64, len(nodeTopology.NUMANodes))
+
+	var hugePages map[int]map[uint64]uint64
+
+	for id, node := range nodeTopology.NUMANodes {
+		memNUMA[id] = node.MemSize
+		memTotal += node.MemSize
+
+		if len(node.HugePages) > 0 {
+			if hugePages == nil {
+				hugePages = make(map[int]map[uint64]uint64)
+			}
+
+			hugePages[id] = maps.Clone(node.HugePages)
+		}
+	}
+
+	return &Topology{
+		CPUTopology: CPUTopology{
+			NumCPUs:        len(cpuDetails),
+			NumSockets:     cpuDetails.Sockets().Size(),
+			NumCores:       cpuDetails.Cores().Size(),
+			NumNUMANodes:   cpuDetails.NUMANodes().Size(),
+			NumUncoreCache: cpuDetails.UncoreCaches().Size(),
+			CPUDetails:     cpuDetails,
+		},
+		MemTopology: MemTopology{
+			NUMANodes:   memNUMA,
+			TotalMemory: memTotal,
+			HugePages:   hugePages,
 		},
 	}, nil
 }
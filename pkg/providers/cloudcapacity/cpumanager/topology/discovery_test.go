@@ -403,3 +403,64 @@ func TestDiscoverFromSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscoverFromNodeTopology(t *testing.T) {
+	t.Parallel()
+
+	cpus := []settings.CPUTopology{}
+	for cpu := range 16 {
+		cpus = append(cpus, settings.CPUTopology{
+			ID:          cpu,
+			CoreID:      cpu,
+			SocketID:    cpu / 8,
+			NUMANodeID:  cpu / 8,
+			UncoreCache: cpu / 4,
+		})
+	}
+
+	testCases := []struct {
+		name     string
+		topology *settings.NodeTopology
+		topo     *Topology
+		error    error
+	}{
+		{
+			name:  "empty topology",
+			error: fmt.Errorf("could not detect cpu topology from empty node topology"),
+		},
+		{
+			name: "dual socket machine",
+			topology: &settings.NodeTopology{
+				CPUs: cpus,
+				NUMANodes: map[int]settings.NUMATopology{
+					0: {MemSize: 8 * 1024 * 1024 * 1024},
+					1: {MemSize: 8 * 1024 * 1024 * 1024},
+				},
+			},
+			topo: &Topology{
+				CPUTopology: topoUncoreDualSocketNoSMT,
+				MemTopology: MemTopology{
+					TotalMemory: 16 * 1024 * 1024 * 1024,
+					NUMANodes:   map[int]uint64{0: 8 * 1024 * 1024 * 1024, 1: 8 * 1024 * 1024 * 1024},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			topo, err := DiscoverFromNodeTopology(tc.topology)
+			if tc.error != nil {
+				assert.Error(t, err)
+				assert.EqualError(t, err, tc.error.Error())
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.topo, topo)
+		})
+	}
+}
@@ -61,6 +61,8 @@ type MemTopology struct {
 	TotalMemory uint64
 	// NUMANodes is a map from NUMA Node ID to the amount of memory in bytes associated with that NUMA Node
 	NUMANodes map[int]uint64
+	// HugePages is a map from NUMA Node ID to the page size in KiB to the number of preallocated hugepages
+	HugePages map[int]map[uint64]uint64
 }
 
 func (topo *Topology) String() string {
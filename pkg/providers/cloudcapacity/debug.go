@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudcapacity
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	goproxmox "github.com/sergelogvinov/go-proxmox"
+)
+
+type debugNodeInfo struct {
+	Status          string                `json:"status"`
+	AvailableCPUs   int                   `json:"availableCPUs"`
+	AvailableMemory uint64                `json:"availableMemory"`
+	Generation      uint64                `json:"generation"`
+	Allocations     []debugAllocationInfo `json:"allocations"`
+}
+
+type debugAllocationInfo struct {
+	ID         int                             `json:"id"`
+	State      string                          `json:"state"`
+	Generation uint64                          `json:"generation"`
+	Timestamp  time.Time                       `json:"timestamp"`
+	CPUs       int                             `json:"cpus"`
+	CPUSet     string                          `json:"cpuSet,omitempty"`
+	Memory     uint64                          `json:"memory"`
+	MinMemory  uint64                          `json:"minMemory,omitempty"`
+	NUMANodes  map[int]goproxmox.NUMANodeState `json:"numaNodes,omitempty"`
+}
+
+// DebugHandler returns the HTTP handler which dumps the CPU and memory allocations of the nodes.
+func (p *DefaultProvider) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		p.muCapacityInfo.RLock()
+
+		nodes := make(map[string]debugNodeInfo, len(p.capacityInfo))
+
+		for key, info := range p.capacityInfo {
+			if info.ResourceManager == nil {
+				continue
+			}
+
+			node := debugNodeInfo{
+				Status:          info.ResourceManager.Status(),
+				AvailableCPUs:   info.ResourceManager.AvailableCPUs(),
+				AvailableMemory: info.ResourceManager.AvailableMemory(),
+				Generation:      info.ResourceManager.Generation(),
+				Allocations:     []debugAllocationInfo{},
+			}
+
+			for id, a := range info.ResourceManager.Allocations() {
+				node.Allocations = append(node.Allocations, debugAllocationInfo{
+					ID:         id,
+					State:      string(a.State),
+					Generation: a.Generation,
+					Timestamp:  a.Timestamp,
+					CPUs:       a.Resources.CPUs,
+					CPUSet:     a.Resources.CPUSet.String(),
+					Memory:     a.Resources.Memory,
+					MinMemory:  a.Resources.MinMemory,
+					NUMANodes:  a.Resources.NUMANodes,
+				})
+			}
+
+			slices.SortFunc(node.Allocations, func(a, b debugAllocationInfo) int {
+				return a.ID - b.ID
+			})
+
+			nodes[key] = node
+		}
+
+		p.muCapacityInfo.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(nodes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcemanager
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
+)
+
+const (
+	// reservationTimeout is the time to keep the reservation of a VM which never appeared on the node.
+	reservationTimeout = 30 * time.Minute
+)
+
+// AllocationState is the state of the VM resources allocation.
+type AllocationState string
+
+const (
+	// AllocationReserved is the allocation of a VM which is being created and is not observed on the node yet.
+	AllocationReserved AllocationState = "Reserved"
+	// AllocationObserved is the allocation of a VM which is observed on the node.
+	AllocationObserved AllocationState = "Observed"
+)
+
+// Allocation is the resources allocated for a VM.
+type Allocation struct {
+	// Resources is the resources assigned to the VM.
+	Resources *resources.VMResources
+	// State is the state of the allocation.
+	State AllocationState
+	// Generation is the generation of the resource manager when the allocation was changed.
+	Generation uint64
+	// Timestamp is the time when the allocation was changed.
+	Timestamp time.Time
+}
+
+// Generation implements ResourceManager.
+func (r *resourceManager) Generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.generation
+}
+
+// Allocations implements ResourceManager.
+func (r *resourceManager) Allocations() map[int]Allocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	allocations := make(map[int]Allocation, len(r.allocations))
+	for id, a := range r.allocations {
+		a.Resources = copyResources(a.Resources)
+		allocations[id] = *a
+	}
+
+	return allocations
+}
+
+// Restore implements ResourceManager.
+func (r *resourceManager) Restore(allocations map[int]Allocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs error
+
+	for id, a := range allocations {
+		if a.State != AllocationReserved || a.Resources == nil {
+			continue
+		}
+
+		if _, ok := r.allocations[id]; ok {
+			continue
+		}
+
+		op := copyResources(a.Resources)
+		if err := r.nodePolicy.AllocateOrUpdate(op); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to restore reservation of VM %d: %w", id, err))
+
+			continue
+		}
+
+		r.track(op, AllocationReserved)
+		r.allocations[id].Timestamp = a.Timestamp
+	}
+
+	return errs
+}
+
+// Reconcile implements ResourceManager.
+func (r *resourceManager) Reconcile(observedAt uint64, vms []*resources.VMResources) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs error
+
+	seen := make(map[int]bool, len(vms))
+
+	for _, vm := range vms {
+		if vm == nil || vm.ID == 0 {
+			continue
+		}
+
+		seen[vm.ID] = true
+
+		// The VM list is older than the last change of the allocation
+		if gen, ok := r.released[vm.ID]; ok && gen > observedAt {
+			continue
+		}
+
+		a, ok := r.allocations[vm.ID]
+		if ok && a.Generation > observedAt {
+			continue
+		}
+
+		if ok && sameResources(a.Resources, vm) {
+			if a.State != AllocationObserved {
+				a.State = AllocationObserved
+				a.Timestamp = time.Now()
+			}
+
+			continue
+		}
+
+		if ok {
+			r.log.V(1).Info("VM resources changed, updating allocation", "id", vm.ID, "CPUs", vm.CPUs, "CPUSet", vm.CPUSet.String(), "memory", vm.Memory/1024/1024)
+
+			if err := r.release(a.Resources); err != nil {
+				errs = multierr.Append(errs, err)
+			}
+		} else {
+			r.log.V(1).Info("Found VM without allocation", "id", vm.ID, "CPUs", vm.CPUs, "CPUSet", vm.CPUSet.String(), "memory", vm.Memory/1024/1024)
+		}
+
+		op := copyResources(vm)
+		if err := r.nodePolicy.AllocateOrUpdate(op); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to allocate resources of VM %d: %w", vm.ID, err))
+
+			continue
+		}
+
+		r.track(op, AllocationObserved)
+	}
+
+	for id, a := range r.allocations {
+		if seen[id] || a.Generation > observedAt {
+			continue
+		}
+
+		if a.State == AllocationReserved && time.Since(a.Timestamp) < reservationTimeout {
+			continue
+		}
+
+		r.log.V(1).Info("VM is gone, releasing allocation", "id", id, "state", a.State, "CPUs", a.Resources.CPUs, "CPUSet", a.Resources.CPUSet.String())
+
+		if err := r.release(a.Resources); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
+	for id, gen := range r.released {
+		if gen <= observedAt {
+			delete(r.released, id)
+		}
+	}
+
+	return errs
+}
+
+// track records the allocation of the VM, the caller must hold the lock.
+func (r *resourceManager) track(op *resources.VMResources, state AllocationState) {
+	if r.allocations == nil {
+		r.allocations = make(map[int]*Allocation)
+	}
+
+	r.generation++
+	r.allocations[op.ID] = &Allocation{
+		Resources:  copyResources(op),
+		State:      state,
+		Generation: r.generation,
+		Timestamp:  time.Now(),
+	}
+
+	delete(r.released, op.ID)
+}
+
+// release frees the allocated resources of the VM, the caller must hold the lock.
+func (r *resourceManager) release(op *resources.VMResources) error {
+	if err := r.nodePolicy.Release(op); err != nil {
+		return fmt.Errorf("failed to release resources of VM %d: %w", op.ID, err)
+	}
+
+	if r.released == nil {
+		r.released = make(map[int]uint64)
+	}
+
+	r.generation++
+	r.released[op.ID] = r.generation
+	delete(r.allocations, op.ID)
+
+	return nil
+}
+
+func sameResources(a, b *resources.VMResources) bool {
+	return a.CPUs == b.CPUs &&
+		a.Memory == b.Memory &&
+		a.CommittedMemory() == b.CommittedMemory() &&
+		a.CPUSet.Equals(b.CPUSet) &&
+		maps.Equal(a.NUMANodes, b.NUMANodes)
+}
+
+func copyResources(op *resources.VMResources) *resources.VMResources {
+	c := *op
+	c.CPUSet = op.CPUSet.Clone()
+	c.NUMANodes = maps.Clone(op.NUMANodes)
+
+	return &c
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
@@ -38,6 +39,7 @@ import (
 	"k8s.io/utils/cpuset"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
 type ResourceManager interface {
@@ -47,6 +49,25 @@ type ResourceManager interface {
 
 	AvailableCPUs() int
 	AvailableMemory() uint64
+	// Fits reports whether the resources can be allocated with the overcommit ratios of the VM.
+	Fits(*resources.VMResources) bool
+	// RefreshSharedMemory updates the memory deduplicated by KSM on the node.
+	RefreshSharedMemory(ctx context.Context) error
+
+	// Architecture returns the CPU architecture of the node.
+	Architecture() string
+	// NUMANodes returns the number of host NUMA nodes the VMs are bound to, zero if the policy does not pin the VMs.
+	NUMANodes() int
+
+	// Generation returns the generation of the allocations, it is increased on every change.
+	Generation() uint64
+	// Allocations returns a snapshot of the allocations keyed by VM ID.
+	Allocations() map[int]Allocation
+	// Restore allocates the reservations of another resource manager of the same node.
+	Restore(allocations map[int]Allocation) error
+	// Reconcile applies the VMs observed on the node at the generation observedAt.
+	// The allocations changed after observedAt and the reservations of the VMs being created are kept.
+	Reconcile(observedAt uint64, vms []*resources.VMResources) error
 
 	Status() string
 }
@@ -57,11 +78,24 @@ type resourceManager struct {
 	log  logr.Logger
 
 	nodeSettings settings.NodeSettings
+	nodeTopology *settings.NodeTopology
 	nodePolicy   cpumanager.Policy
+	numaNodes    int
+
+	mu          sync.Mutex
+	generation  uint64
+	allocations map[int]*Allocation
+	// released is the generation when the allocation of the VM was released
+	released map[int]uint64
 }
 
 var _ ResourceManager = &resourceManager{}
 
+// sharedMemoryPolicy is implemented by the policies which overcommit the memory deduplicated by KSM.
+type sharedMemoryPolicy interface {
+	SetSharedMemory(memory uint64)
+}
+
 func NewResourceManager(ctx context.Context, cl *goproxmox.APIClient, region, zone string) (ResourceManager, error) {
 	log := log.FromContext(ctx).WithName("ResourceManager").WithValues("node", zone)
 
@@ -81,7 +115,7 @@ func NewResourceManager(ctx context.Context, cl *goproxmox.APIClient, region, zo
 		log:  log,
 	}
 
-	manager.nodeSettings, err = nodeSettingsFromCluster(ctx, cl, zone)
+	manager.nodeSettings, manager.nodeTopology, err = nodeSettingsFromCluster(ctx, cl, zone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node settings from VM: %w", err)
 	}
@@ -93,6 +127,10 @@ func NewResourceManager(ctx context.Context, cl *goproxmox.APIClient, region, zo
 		}
 
 		if setting != nil {
+			if setting.Arch != "" {
+				manager.nodeSettings.Arch = setting.Arch
+			}
+
 			if setting.NumSockets != 0 {
 				manager.nodeSettings.NumSockets = setting.NumSockets
 			}
@@ -115,25 +153,63 @@ func NewResourceManager(ctx context.Context, cl *goproxmox.APIClient, region, zo
 
 			if len(setting.NUMANodes) != 0 {
 				manager.nodeSettings.NUMANodes = setting.NUMANodes
+				manager.nodeTopology = nil
+			}
+
+			if setting.CPUOvercommitRatio != 0 {
+				manager.nodeSettings.CPUOvercommitRatio = setting.CPUOvercommitRatio
+			}
+
+			if setting.MemoryOvercommitRatio != 0 {
+				manager.nodeSettings.MemoryOvercommitRatio = setting.MemoryOvercommitRatio
+			}
+
+			if setting.KSMSharing {
+				manager.nodeSettings.KSMSharing = setting.KSMSharing
 			}
 
 			log.V(1).Info("Loaded node settings from file", "file", name, "settings", manager.nodeSettings)
 		}
 	}
 
-	sysTopology, err = topology.DiscoverFromSettings(&manager.nodeSettings)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover topology from settings for node %s: %w", manager.zone, err)
+	if manager.nodeTopology != nil {
+		sysTopology, err = topology.DiscoverFromNodeTopology(manager.nodeTopology)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover topology from published topology for node %s: %w", manager.zone, err)
+		}
+	} else {
+		sysTopology, err = topology.DiscoverFromSettings(&manager.nodeSettings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover topology from settings for node %s: %w", manager.zone, err)
+		}
 	}
 
 	switch opts.NodePolicy { //nolint:gocritic
 	case string(cpumanager.PolicyStatic):
+		if manager.nodeSettings.CPUOvercommitRatio > 1 || manager.nodeSettings.MemoryOvercommitRatio > 1 {
+			log.Info("Overcommit is not supported by the static policy, ignoring")
+		}
+
 		manager.nodePolicy, err = cpumanager.NewStaticPolicy(log, sysTopology, manager.nodeSettings.ReservedCPUs, manager.nodeSettings.ReservedMemory)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create static policy for node %s: %w", manager.zone, err)
 		}
+
+		manager.numaNodes = sysTopology.CPUDetails.NUMANodes().Size()
 	default:
-		manager.nodePolicy, err = cpumanager.NewSimplePolicy(sysTopology, manager.nodeSettings.ReservedCPUs, manager.nodeSettings.ReservedMemory)
+		policyOptions := cpumanager.SimplePolicyOptions{
+			CPUOvercommitRatio:    manager.nodeSettings.CPUOvercommitRatio,
+			MemoryOvercommitRatio: manager.nodeSettings.MemoryOvercommitRatio,
+		}
+
+		if manager.nodeSettings.KSMSharing {
+			policyOptions.SharedMemory, err = nodeSharedMemory(ctx, cl, zone)
+			if err != nil {
+				log.Error(err, "Failed to get KSM shared memory")
+			}
+		}
+
+		manager.nodePolicy, err = cpumanager.NewSimplePolicyWithOptions(sysTopology, manager.nodeSettings.ReservedCPUs, manager.nodeSettings.ReservedMemory, policyOptions)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create simple policy for node %s: %w", manager.zone, err)
 		}
@@ -154,11 +230,20 @@ func (r *resourceManager) Allocate(op *resources.VMResources) (err error) {
 		return fmt.Errorf("cannot allocate resources, invalid resources request")
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.allocations[op.ID]; ok && op.ID != 0 {
+		return fmt.Errorf("cannot allocate resources, VM %d already has an allocation", op.ID)
+	}
+
 	err = r.nodePolicy.Allocate(op)
 	if err != nil {
 		return err
 	}
 
+	r.track(op, AllocationReserved)
+
 	r.log.V(1).Info("Allocated resources", "id", op.ID,
 		"availableCapacity", r.Status(),
 		"CPUs", op.CPUs,
@@ -176,11 +261,26 @@ func (r *resourceManager) AllocateOrUpdate(op *resources.VMResources) error {
 		return fmt.Errorf("cannot allocate resources, invalid resources request")
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a, ok := r.allocations[op.ID]; ok {
+		if sameResources(a.Resources, op) {
+			return nil
+		}
+
+		if err := r.release(a.Resources); err != nil {
+			return err
+		}
+	}
+
 	err := r.nodePolicy.AllocateOrUpdate(op)
 	if err != nil {
 		return err
 	}
 
+	r.track(op, AllocationObserved)
+
 	r.log.V(4).Info("Allocated or updated resources", "id", op.ID,
 		"availableCapacity", r.Status(),
 		"CPUs", op.CPUs,
@@ -196,11 +296,20 @@ func (r *resourceManager) Release(op *resources.VMResources) (err error) {
 		return nil
 	}
 
-	if err := r.nodePolicy.Release(op); err != nil {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The allocation is released already or the VM was never observed
+	a, ok := r.allocations[op.ID]
+	if !ok {
+		return nil
+	}
+
+	if err := r.release(a.Resources); err != nil {
 		return err
 	}
 
-	r.log.V(4).Info("Released resources", "id", op.ID, "availableCapacity", r.Status(), "CPUs", op.CPUs, "CPUSet", op.CPUSet.String())
+	r.log.V(4).Info("Released resources", "id", op.ID, "availableCapacity", r.Status(), "CPUs", a.Resources.CPUs, "CPUSet", a.Resources.CPUSet.String())
 
 	return nil
 }
@@ -215,24 +324,83 @@ func (r *resourceManager) AvailableMemory() uint64 {
 	return r.nodePolicy.AvailableMemory()
 }
 
+// Fits implements ResourceManager.
+func (r *resourceManager) Fits(op *resources.VMResources) bool {
+	if op == nil {
+		return false
+	}
+
+	return r.nodePolicy.Fits(op)
+}
+
+// RefreshSharedMemory implements ResourceManager.
+func (r *resourceManager) RefreshSharedMemory(ctx context.Context) error {
+	policy, ok := r.nodePolicy.(sharedMemoryPolicy)
+	if !ok || !r.nodeSettings.KSMSharing {
+		return nil
+	}
+
+	shared, err := nodeSharedMemory(ctx, r.cl, r.zone)
+	if err != nil {
+		return err
+	}
+
+	policy.SetSharedMemory(shared)
+
+	return nil
+}
+
+// Architecture implements ResourceManager.
+func (r *resourceManager) Architecture() string {
+	if r.nodeSettings.Arch == "" {
+		return karpv1.ArchitectureAmd64
+	}
+
+	return r.nodeSettings.Arch
+}
+
+// NUMANodes implements ResourceManager.
+func (r *resourceManager) NUMANodes() int {
+	return r.numaNodes
+}
+
 // Status implements ResourceManager.
 func (r *resourceManager) Status() string {
 	return r.nodePolicy.Status()
 }
 
-func nodeSettingsFromCluster(ctx context.Context, cl *goproxmox.APIClient, zone string) (settings.NodeSettings, error) {
+// nodeSettingsFromCluster returns the node settings discovered from the Proxmox cluster.
+// The topology published by proxmox-scheduler in the node description is preferred,
+// then the node-capacity VM, and the CPU model of the node as the last resort.
+func nodeSettingsFromCluster(ctx context.Context, cl *goproxmox.APIClient, zone string) (settings.NodeSettings, *settings.NodeTopology, error) {
 	nodeSettings := settings.NodeSettings{
 		ReservedMemory: 1024 * 1024 * 1024, // 1GiB
 	}
 
+	nodeTopology, err := nodeTopologyFromCluster(ctx, cl, zone)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to get published node topology", "node", zone)
+	}
+
+	if st := nodeTopology.NodeSettings(); st != nil {
+		nodeSettings.Arch = st.Arch
+		nodeSettings.NumCores = st.NumCores
+		nodeSettings.NumSockets = st.NumSockets
+		nodeSettings.NumThreads = st.NumThreads
+		nodeSettings.NumUncoreCaches = st.NumUncoreCaches
+		nodeSettings.NUMANodes = st.NUMANodes
+
+		return nodeSettings, nodeTopology, nil
+	}
+
 	n, err := cl.Client.Node(ctx, zone)
 	if err != nil {
-		return nodeSettings, fmt.Errorf("failed to get node %s: %w", zone, err)
+		return nodeSettings, nil, fmt.Errorf("failed to get node %s: %w", zone, err)
 	}
 
 	st, err := nodesettings.GetNodeSettingByNode(n)
 	if err != nil {
-		return nodeSettings, fmt.Errorf("getting node settings: %w", err)
+		return nodeSettings, nil, fmt.Errorf("getting node settings: %w", err)
 	}
 
 	if st != nil {
@@ -244,24 +412,47 @@ func nodeSettingsFromCluster(ctx context.Context, cl *goproxmox.APIClient, zone
 		return v.Node == zone && v.Name == "node-capacity" && slices.Contains(strings.Split(v.Tags, ";"), "karpenter"), nil
 	})
 	if err != nil && !errors.Is(err, goproxmox.ErrVirtualMachineNotFound) {
-		return nodeSettings, fmt.Errorf("failed to get VM by filter for node settings: %w", err)
+		return nodeSettings, nil, fmt.Errorf("failed to get VM by filter for node settings: %w", err)
 	}
 
 	if vmr == nil {
-		return nodeSettings, nil
+		return nodeSettings, nil, nil
 	}
 
 	vm, err := cl.GetVMConfig(ctx, int(vmr.VMID))
 	if err != nil {
-		return nodeSettings, fmt.Errorf("failed to get VM config for node settings: %w", err)
+		return nodeSettings, nil, fmt.Errorf("failed to get VM config for node settings: %w", err)
 	}
 
 	err = nodeSettingsFromVM(vm, &nodeSettings)
 	if err != nil {
-		return nodeSettings, fmt.Errorf("failed to get node settings from VM: %w", err)
+		return nodeSettings, nil, fmt.Errorf("failed to get node settings from VM: %w", err)
+	}
+
+	return nodeSettings, nil, nil
+}
+
+// nodeTopologyFromCluster returns the topology published by proxmox-scheduler in the node description.
+func nodeTopologyFromCluster(ctx context.Context, cl *goproxmox.APIClient, zone string) (*settings.NodeTopology, error) {
+	config := struct {
+		Description string `json:"description,omitempty"`
+	}{}
+
+	if err := cl.Client.Get(ctx, fmt.Sprintf("/nodes/%s/config", zone), &config); err != nil {
+		return nil, fmt.Errorf("failed to get node %s config: %w", zone, err)
+	}
+
+	return settings.DecodeNodeTopology(config.Description)
+}
+
+// nodeSharedMemory returns the memory deduplicated by KSM on the node.
+func nodeSharedMemory(ctx context.Context, cl *goproxmox.APIClient, zone string) (uint64, error) {
+	n, err := cl.Client.Node(ctx, zone)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get node %s: %w", zone, err)
 	}
 
-	return nodeSettings, nil
+	return uint64(max(n.Ksm.Shared, 0)), nil
 }
 
 func nodeSettingsFromVM(vm *proxmox.VirtualMachine, nodeSettings *settings.NodeSettings) error {
@@ -416,3 +416,74 @@ func TestNodeSettingsFromVM(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	sysPolicy := lo.Must(topology.DiscoverFromSettings(&testNodeSettings))
+
+	manager := &resourceManager{
+		nodeSettings: testNodeSettings,
+		nodePolicy:   lo.Must(cpumanager.NewSimplePolicy(sysPolicy, testNodeSettings.ReservedCPUs, testNodeSettings.ReservedMemory)),
+	}
+
+	observedAt := manager.Generation()
+
+	// VM is being created, the VM list does not contain it yet
+	err := manager.Allocate(&resources.VMResources{ID: 100, CPUs: 4, Memory: 4096 * 1024 * 1024})
+	assert.NoError(t, err)
+
+	err = manager.Reconcile(observedAt, []*resources.VMResources{})
+	assert.NoError(t, err)
+	assert.Equal(t, AllocationReserved, manager.Allocations()[100].State)
+
+	err = manager.Reconcile(manager.Generation(), []*resources.VMResources{})
+	assert.NoError(t, err)
+	assert.Equal(t, AllocationReserved, manager.Allocations()[100].State)
+	assert.Equal(t, "CPU: Free: 12, Static: [], Common: [0-15], Reserved: [], Mem: 27648M", manager.Status())
+
+	// VM is running and a VM was created out-of-band
+	err = manager.Reconcile(manager.Generation(), []*resources.VMResources{
+		{ID: 100, CPUs: 4, CPUSet: cpuset.New(), Memory: 4096 * 1024 * 1024},
+		{ID: 200, CPUs: 2, CPUSet: cpuset.New(2, 3), Memory: 2048 * 1024 * 1024},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, AllocationObserved, manager.Allocations()[100].State)
+	assert.Equal(t, AllocationObserved, manager.Allocations()[200].State)
+	assert.Equal(t, "CPU: Free: 10, Static: [2-3], Common: [0-1,4-15], Reserved: [], Mem: 25600M", manager.Status())
+
+	// VM was deleted out-of-band
+	err = manager.Reconcile(manager.Generation(), []*resources.VMResources{
+		{ID: 100, CPUs: 4, CPUSet: cpuset.New(), Memory: 4096 * 1024 * 1024},
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, manager.Allocations(), 200)
+	assert.Equal(t, "CPU: Free: 12, Static: [], Common: [0-15], Reserved: [], Mem: 27648M", manager.Status())
+
+	// VM was released after the VM list was read
+	observedAt = manager.Generation()
+
+	err = manager.Release(&resources.VMResources{ID: 100, CPUs: 4, Memory: 4096 * 1024 * 1024})
+	assert.NoError(t, err)
+
+	err = manager.Reconcile(observedAt, []*resources.VMResources{
+		{ID: 100, CPUs: 4, CPUSet: cpuset.New(), Memory: 4096 * 1024 * 1024},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, manager.Allocations())
+	assert.Equal(t, "CPU: Free: 16, Static: [], Common: [0-15], Reserved: [], Mem: 31744M", manager.Status())
+
+	// Reservations are kept by the new resource manager
+	err = manager.Allocate(&resources.VMResources{ID: 300, CPUs: 2, Memory: 2048 * 1024 * 1024})
+	assert.NoError(t, err)
+
+	restored := &resourceManager{
+		nodeSettings: testNodeSettings,
+		nodePolicy:   lo.Must(cpumanager.NewSimplePolicy(sysPolicy, testNodeSettings.ReservedCPUs, testNodeSettings.ReservedMemory)),
+	}
+
+	err = restored.Restore(manager.Allocations())
+	assert.NoError(t, err)
+	assert.Equal(t, AllocationReserved, restored.Allocations()[300].State)
+	assert.Equal(t, manager.Status(), restored.Status())
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// NodeTopologyVersion is the version of the published node topology format.
+	NodeTopologyVersion = 1
+
+	nodeTopologyBlockStart = "```karpenter-topology\n"
+	nodeTopologyBlockEnd   = "\n```"
+)
+
+// NodeTopology is the hardware topology of the node published by proxmox-scheduler
+// into the Proxmox node description.
+type NodeTopology struct {
+	Version int `json:"version"`
+	// Arch is the CPU architecture of the node, amd64 or arm64.
+	Arch string `json:"arch,omitempty"`
+	// CPUs is the list of logical CPUs of the node.
+	CPUs []CPUTopology `json:"cpus"`
+	// NUMANodes is a map of NUMA node ID to its memory information.
+	NUMANodes map[int]NUMATopology `json:"nodes"`
+	// PCIDevices is the list of the PCI devices which can be passed through to VMs.
+	PCIDevices []PCIDevice `json:"pci,omitempty"`
+}
+
+// CPUTopology represents the location of a logical CPU.
+type CPUTopology struct {
+	ID          int `json:"id"`
+	CoreID      int `json:"core"`
+	SocketID    int `json:"socket"`
+	NUMANodeID  int `json:"node"`
+	UncoreCache int `json:"cache"`
+}
+
+// NUMATopology represents the memory of a NUMA node.
+type NUMATopology struct {
+	// MemSize in bytes.
+	MemSize uint64 `json:"memsize"`
+	// HugePages is a map of the page size in KiB to the number of pages.
+	HugePages map[uint64]uint64 `json:"hugepages,omitempty"`
+}
+
+// PCIDevice represents a PCI device of the node.
+type PCIDevice struct {
+	Address    string `json:"address"`
+	Vendor     string `json:"vendor"`
+	Device     string `json:"device"`
+	Class      string `json:"class"`
+	NUMANodeID int    `json:"node"`
+}
+
+// NodeSettings returns the node settings based on the topology.
+func (t *NodeTopology) NodeSettings() *NodeSettings {
+	if t == nil || len(t.CPUs) == 0 {
+		return nil
+	}
+
+	cores := map[[2]int]bool{}
+	sockets := map[int]bool{}
+	caches := map[int]bool{}
+	nodeCPUs := map[int][]int{}
+
+	for _, cpu := range t.CPUs {
+		cores[[2]int{cpu.SocketID, cpu.CoreID}] = true
+		sockets[cpu.SocketID] = true
+		caches[cpu.UncoreCache] = true
+		nodeCPUs[cpu.NUMANodeID] = append(nodeCPUs[cpu.NUMANodeID], cpu.ID)
+	}
+
+	st := &NodeSettings{
+		Arch:            t.Arch,
+		NumCores:        len(cores),
+		NumSockets:      len(sockets),
+		NumThreads:      max(1, len(t.CPUs)/len(cores)),
+		NumUncoreCaches: len(caches),
+		NUMANodes:       make(NUMANodes, len(nodeCPUs)),
+	}
+
+	for id, cpus := range nodeCPUs {
+		st.NUMANodes[id] = NUMAInfo{
+			CPUs:      cpuset.New(cpus...).String(),
+			MemSize:   t.NUMANodes[id].MemSize,
+			HugePages: t.NUMANodes[id].HugePages,
+		}
+	}
+
+	return st
+}
+
+// DecodeNodeTopology returns the node topology stored in the node description.
+// It returns nil if the description does not have the topology block.
+func DecodeNodeTopology(description string) (*NodeTopology, error) {
+	start := strings.Index(description, nodeTopologyBlockStart)
+	if start == -1 {
+		return nil, nil
+	}
+
+	data := description[start+len(nodeTopologyBlockStart):]
+
+	end := strings.Index(data, nodeTopologyBlockEnd)
+	if end == -1 {
+		return nil, fmt.Errorf("unterminated node topology block")
+	}
+
+	topology := &NodeTopology{}
+	if err := json.Unmarshal([]byte(data[:end]), topology); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node topology: %w", err)
+	}
+
+	if topology.Version != NodeTopologyVersion {
+		return nil, fmt.Errorf("unsupported node topology version %d", topology.Version)
+	}
+
+	return topology, nil
+}
+
+// EncodeNodeTopology stores the node topology in the node description.
+// The previous topology block is replaced, the rest of the description is kept.
+func EncodeNodeTopology(description string, topology *NodeTopology) (string, error) {
+	if start := strings.Index(description, nodeTopologyBlockStart); start != -1 {
+		rest := description[start+len(nodeTopologyBlockStart):]
+
+		end := strings.Index(rest, nodeTopologyBlockEnd)
+		if end == -1 {
+			return "", fmt.Errorf("unterminated node topology block")
+		}
+
+		description = description[:start] + rest[end+len(nodeTopologyBlockEnd):]
+	}
+
+	description = strings.TrimSpace(description)
+
+	if topology == nil {
+		return description, nil
+	}
+
+	t := *topology
+	t.Version = NodeTopologyVersion
+	t.CPUs = slices.Clone(t.CPUs)
+	slices.SortFunc(t.CPUs, func(a, b CPUTopology) int { return a.ID - b.ID })
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal node topology: %w", err)
+	}
+
+	block := nodeTopologyBlockStart + string(data) + nodeTopologyBlockEnd
+	if description == "" {
+		return block, nil
+	}
+
+	return description + "\n\n" + block, nil
+}
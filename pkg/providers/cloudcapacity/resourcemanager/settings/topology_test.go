@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeTopologyDescription(t *testing.T) {
+	t.Parallel()
+
+	topology := &NodeTopology{
+		Arch: "arm64",
+		CPUs: []CPUTopology{
+			{ID: 2, CoreID: 2, SocketID: 0, NUMANodeID: 1, UncoreCache: 1},
+			{ID: 0, CoreID: 0, SocketID: 0, NUMANodeID: 0, UncoreCache: 0},
+			{ID: 1, CoreID: 1, SocketID: 0, NUMANodeID: 0, UncoreCache: 0},
+			{ID: 3, CoreID: 3, SocketID: 0, NUMANodeID: 1, UncoreCache: 1},
+		},
+		NUMANodes: map[int]NUMATopology{
+			0: {MemSize: 4 * 1024 * 1024 * 1024, HugePages: map[uint64]uint64{2048: 512}},
+			1: {MemSize: 4 * 1024 * 1024 * 1024},
+		},
+	}
+
+	description, err := EncodeNodeTopology("Rack 4, row 2", topology)
+	assert.NoError(t, err)
+	assert.Contains(t, description, "Rack 4, row 2\n\n```karpenter-topology\n")
+
+	decoded, err := DecodeNodeTopology(description)
+	assert.NoError(t, err)
+	assert.Equal(t, NodeTopologyVersion, decoded.Version)
+	assert.Equal(t, []int{0, 1, 2, 3}, []int{decoded.CPUs[0].ID, decoded.CPUs[1].ID, decoded.CPUs[2].ID, decoded.CPUs[3].ID})
+	assert.Equal(t, topology.NUMANodes, decoded.NUMANodes)
+
+	updated, err := EncodeNodeTopology(description, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, description, updated)
+
+	cleared, err := EncodeNodeTopology(description, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rack 4, row 2", cleared)
+
+	empty, err := DecodeNodeTopology(cleared)
+	assert.NoError(t, err)
+	assert.Nil(t, empty)
+
+	_, err = DecodeNodeTopology("```karpenter-topology\n{\"version\":1}")
+	assert.EqualError(t, err, "unterminated node topology block")
+
+	assert.Equal(t, &NodeSettings{
+		Arch:            "arm64",
+		NumCores:        4,
+		NumSockets:      1,
+		NumThreads:      1,
+		NumUncoreCaches: 2,
+		NUMANodes: NUMANodes{
+			0: {CPUs: "0-1", MemSize: 4 * 1024 * 1024 * 1024, HugePages: map[uint64]uint64{2048: 512}},
+			1: {CPUs: "2-3", MemSize: 4 * 1024 * 1024 * 1024},
+		},
+	}, decoded.NodeSettings())
+}
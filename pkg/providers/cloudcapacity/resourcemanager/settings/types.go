@@ -18,6 +18,8 @@ package settings
 
 // NodeSettings represents the hardware settings of a node.
 type NodeSettings struct {
+	// Arch is the CPU architecture of the node, amd64 or arm64.
+	Arch string `json:"arch,omitempty"`
 	// NumCores is the physical core count of the CPU.
 	NumCores int `json:"cores,omitempty"`
 	// NumSockets is the number of CPU sockets.
@@ -34,6 +36,15 @@ type NodeSettings struct {
 	ReservedCPUs []int `json:"reservedcpus,omitempty"`
 	// ReservedMemory in bytes.
 	ReservedMemory uint64 `json:"reservedmemory,omitempty"`
+
+	// CPUOvercommitRatio is the ratio of virtual CPUs to physical CPUs, for example 4.0.
+	// It applies only to the simple policy and the VMs without CPU affinity.
+	CPUOvercommitRatio float64 `json:"cpuovercommit,omitempty"`
+	// MemoryOvercommitRatio is the ratio of VM memory to host memory, for example 1.5.
+	// It applies only to the simple policy, the memory of VMs with CPU affinity is never overcommitted.
+	MemoryOvercommitRatio float64 `json:"memoryovercommit,omitempty"`
+	// KSMSharing adds the memory deduplicated by KSM to the memory capacity.
+	KSMSharing bool `json:"ksmsharing,omitempty"`
 }
 
 // NUMANodes is a map from NUMA node ID to its information.
@@ -50,6 +61,8 @@ type NUMANodes map[int]NUMAInfo
 type NUMAInfo struct {
 	CPUs    string `json:"cpus"`
 	MemSize uint64 `json:"memsize,omitempty"`
+	// HugePages is a map of the page size in KiB to the number of pages.
+	HugePages map[uint64]uint64 `json:"hugepages,omitempty"`
 }
 
 // NodeSettingsConfig is a map from region to zone (or "*") to NodeSettings.
@@ -64,7 +77,9 @@ type NodeSettingsConfig map[string]map[string]NodeSettings
 //     },
 //     "node2": {
 //       "reservedcpus": [1,5],
-//       "reservedmemory": 4294967296
+//       "reservedmemory": 4294967296,
+//       "cpuovercommit": 4.0,
+//       "memoryovercommit": 1.5
 //     }
 //   },
 //   "region-2": {
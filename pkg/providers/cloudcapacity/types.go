@@ -25,13 +25,42 @@ type NodeCapacityInfo struct {
 	Name string `json:"name"`
 	// Region is the region of the node.
 	Region string `json:"region"`
+	// Architecture is the CPU architecture of the node, amd64 or arm64.
+	Architecture string `json:"architecture"`
+	// NUMANodes is the number of host NUMA nodes the VMs are bound to, zero if the node policy does not pin the VMs.
+	NUMANodes int `json:"numa_nodes,omitempty"`
+	// CPUTypes are the emulated CPU types which the node can run.
+	CPUTypes []string `json:"cpu_types,omitempty"`
 	// CPULoad is the CPU load of the node in percentage.
 	CPULoad int `json:"cpu_load"`
+	// MemoryUsage is the host memory usage in percentage.
+	MemoryUsage int `json:"memory_usage"`
+	// MemoryPressure is true if the host memory usage reached the memory pressure threshold,
+	// it is reset when the usage drops below the threshold with the hysteresis.
+	MemoryPressure bool `json:"memory_pressure,omitempty"`
+	// Tags are the Proxmox tags of the node.
+	Tags []string `json:"tags,omitempty"`
+	// HAGroups are the Proxmox HA groups the node is a member of.
+	HAGroups []string `json:"ha_groups,omitempty"`
 
 	// ResourceManager manages the CPU and memory and other resources of the node.
 	ResourceManager resourcemanager.ResourceManager `json:"-"`
 }
 
+// NodeUsageInfo is the snapshot of the node resource usage.
+type NodeUsageInfo struct {
+	// AvailableCPUs is the number of CPUs which can be allocated for new VMs.
+	AvailableCPUs int
+	// AvailableMemory is the memory in bytes which can be allocated for new VMs.
+	AvailableMemory uint64
+	// CPULoad is the host CPU usage in percentage.
+	CPULoad int
+	// MemoryUsage is the host memory usage in percentage.
+	MemoryUsage int
+	// MemoryPressure is true if the host is under memory pressure.
+	MemoryPressure bool
+}
+
 type NodeStorageCapacityInfo struct {
 	// Name is the name of the node.
 	Name string
@@ -45,6 +74,10 @@ type NodeStorageCapacityInfo struct {
 	Capabilities []string
 	// Zones are the zones where the storage is available.
 	Zones []string
+	// Size is the total size of the storage in bytes, it is set only for the storage in a zone.
+	Size uint64
+	// Used is the used space of the storage in bytes, it is set only for the storage in a zone.
+	Used uint64
 }
 
 type NodeNetworkIfaceInfo struct {
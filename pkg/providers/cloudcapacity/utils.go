@@ -21,29 +21,76 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/samber/lo"
 
 	goproxmox "github.com/sergelogvinov/go-proxmox"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity/resourcemanager"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
 	vmresources "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources/vm"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/nodesettings"
+
+	corev1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func getNodeCapacity(ctx context.Context, cl *goproxmox.APIClient, region string, r *proxmox.ClusterResource) (NodeCapacityInfo, error) {
+// NodeClassResources returns the resources which the VM of the node class with the capacity allocates on the node.
+func NodeClassResources(capacity corev1.ResourceList, nodeClass *v1alpha1.ProxmoxNodeClass) *resources.VMResources {
+	res := &resources.VMResources{
+		CPUs:   int(capacity.Cpu().Value()),
+		Memory: uint64(capacity.Memory().Value()),
+	}
+
+	if nodeClass != nil {
+		res.MinMemory = nodeClass.Spec.MemoryBalloon.GetMinMemory(res.Memory)
+		res.CPUOvercommitRatio = nodeClass.Spec.Overcommit.GetCPURatio()
+		res.MemoryOvercommitRatio = nodeClass.Spec.Overcommit.GetMemoryRatio()
+		res.HugePageSize = resources.ParseHugePageSize(nodeClass.Spec.Hugepages, res.Memory)
+	}
+
+	return res
+}
+
+func getNodeCapacity(ctx context.Context, cl *goproxmox.APIClient, region string, r *proxmox.ClusterResource, previous resourcemanager.ResourceManager) (NodeCapacityInfo, error) {
 	resourceManager, err := resourcemanager.NewResourceManager(ctx, cl, region, r.Node)
 	if err != nil {
 		return NodeCapacityInfo{}, fmt.Errorf("failed to create resource manager for node %s in region %s: %w", r.Node, region, err)
 	}
 
+	// Keep the reservations of the VMs which are being created
+	if previous != nil {
+		if err := resourceManager.Restore(previous.Allocations()); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to restore reservations", "node", r.Node, "region", region)
+		}
+	}
+
 	info := NodeCapacityInfo{
 		Name:            r.Node,
 		Region:          region,
+		Architecture:    resourceManager.Architecture(),
+		NUMANodes:       resourceManager.NUMANodes(),
 		CPULoad:         int(r.CPU * 100),
+		MemoryUsage:     memoryUsage(r),
+		Tags:            lo.Compact(strings.Split(r.Tags, ";")),
 		ResourceManager: resourceManager,
 	}
 
+	cpuFlags := ""
+
+	// Permission: Sys.Audit
+	n, err := cl.Client.Node(ctx, r.Node)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to get node status, all CPU types are allowed", "node", r.Node, "region", region)
+	} else {
+		cpuFlags = n.CPUInfo.Flags
+	}
+
+	info.CPUTypes = nodesettings.SupportedCPUTypes(info.Architecture, cpuFlags)
+
 	err = info.updateNodeCapacity(ctx, cl)
 	if err != nil {
 		return NodeCapacityInfo{}, fmt.Errorf("failed to get allocatable resources for node %s in region %s: %w", r.Node, region, err)
@@ -55,31 +102,54 @@ func getNodeCapacity(ctx context.Context, cl *goproxmox.APIClient, region string
 func (i *NodeCapacityInfo) updateNodeCapacity(ctx context.Context, cl *goproxmox.APIClient) error {
 	log := log.FromContext(ctx).WithName("updateNodeCapacity()")
 
+	observedAt := i.ResourceManager.Generation()
+
+	vms, err := getNodeVMResources(ctx, cl, i.Name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get VM resources for node %s in region %s: %w", i.Name, i.Region, err)
+	}
+
+	if err := i.ResourceManager.Reconcile(observedAt, vms); err != nil {
+		log.Error(err, "Failed to allocate resources for VMs", "node", i.Name)
+	}
+
+	return nil
+}
+
+// getNodeVMResources returns the resources of the running VMs on the node.
+// The config of the VM is read only if the VM is not in the known allocations or its size was changed.
+func getNodeVMResources(ctx context.Context, cl *goproxmox.APIClient, node string, known map[int]resourcemanager.Allocation) ([]*resources.VMResources, error) {
 	vms, err := cl.GetVMsByFilter(ctx, func(vm *proxmox.ClusterResource) (bool, error) {
-		return vm.Node == i.Name && vm.Status == "running", nil
+		return vm.Node == node && vm.Status == "running", nil
 	})
 	if err != nil && !errors.Is(err, goproxmox.ErrVirtualMachineNotFound) {
-		return fmt.Errorf("cannot list vms for node %s: %w", i.Name, err)
+		return nil, fmt.Errorf("cannot list vms for node %s: %w", node, err)
 	}
 
+	res := make([]*resources.VMResources, 0, len(vms))
+
 	for _, vmr := range vms {
+		if a, ok := known[int(vmr.VMID)]; ok && a.State == resourcemanager.AllocationObserved &&
+			uint64(a.Resources.CPUs) == vmr.MaxCPU && a.Resources.Memory == vmr.MaxMem {
+			res = append(res, a.Resources)
+
+			continue
+		}
+
 		vm, err := cl.GetVMConfig(ctx, int(vmr.VMID))
 		if err != nil {
-			return fmt.Errorf("failed to get VM %d config for node %s in region %s: %w", vmr.VMID, i.Name, i.Region, err)
+			return nil, fmt.Errorf("failed to get VM %d config: %w", vmr.VMID, err)
 		}
 
 		opt, err := vmresources.GetResourceFromVM(vm)
 		if err != nil {
-			return fmt.Errorf("failed to generate resource request for VM %d: %w", vmr.VMID, err)
+			return nil, fmt.Errorf("failed to generate resource request for VM %d: %w", vmr.VMID, err)
 		}
 
-		err = i.ResourceManager.AllocateOrUpdate(opt)
-		if err != nil {
-			log.Error(err, "Failed to allocate resources for VM", "vmID", vmr.VMID)
-		}
+		res = append(res, opt)
 	}
 
-	return nil
+	return res, nil
 }
 
 func getNodeNetwork(ctx context.Context, cl *goproxmox.APIClient, region string, r *proxmox.ClusterResource) (NodeNetworkIfaceInfo, error) {
@@ -118,3 +188,49 @@ func getNodeNetwork(ctx context.Context, cl *goproxmox.APIClient, region string,
 		Ifaces: ifaces,
 	}, nil
 }
+
+// getHAGroups returns the HA groups of the cluster grouped by the node name.
+func getHAGroups(ctx context.Context, cl *goproxmox.APIClient) (map[string][]string, error) {
+	groups, err := cl.GetHAGroupList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HA groups: %w", err)
+	}
+
+	nodes := map[string][]string{}
+
+	for _, group := range groups {
+		// nodes format: <node>[:<priority>]{,<node>[:<priority>]}*
+		for item := range strings.SplitSeq(group.Nodes, ",") {
+			node, _, _ := strings.Cut(strings.TrimSpace(item), ":")
+			if node == "" {
+				continue
+			}
+
+			nodes[node] = append(nodes[node], group.Group)
+		}
+	}
+
+	return nodes, nil
+}
+
+// memoryPressureHysteresis is the memory usage in percent below the threshold at which the host leaves the memory pressure,
+// so the usage flapping around the threshold does not drift the nodes again and again.
+const memoryPressureHysteresis = 5
+
+// memoryPressure returns whether the host is under memory pressure, pressure is the previous state of the host.
+func memoryPressure(pressure bool, usage, threshold int) bool {
+	if pressure {
+		return usage >= threshold-memoryPressureHysteresis
+	}
+
+	return usage >= threshold
+}
+
+// memoryUsage returns the host memory usage in percentage.
+func memoryUsage(r *proxmox.ClusterResource) int {
+	if r.MaxMem == 0 {
+		return 0
+	}
+
+	return int(r.Mem * 100 / r.MaxMem)
+}
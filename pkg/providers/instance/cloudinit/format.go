@@ -0,0 +1,233 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+
+	goYaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// cloudConfigSchema is the expected type of the common cloud-config modules.
+var cloudConfigSchema = map[string]reflect.Kind{
+	"hostname":            reflect.String,
+	"fqdn":                reflect.String,
+	"timezone":            reflect.String,
+	"package_update":      reflect.Bool,
+	"package_upgrade":     reflect.Bool,
+	"packages":            reflect.Slice,
+	"bootcmd":             reflect.Slice,
+	"runcmd":              reflect.Slice,
+	"write_files":         reflect.Slice,
+	"users":               reflect.Slice,
+	"mounts":              reflect.Slice,
+	"ssh_authorized_keys": reflect.Slice,
+}
+
+const (
+	cloudConfigHeader = "#cloud-config"
+
+	// talosConfigVersion is the version of the Talos v1alpha1 machine configuration document
+	talosConfigVersion = "v1alpha1"
+)
+
+// ValidateUserData checks that the rendered user-data is a valid document of the bootstrap format.
+func ValidateUserData(format string, data string) error {
+	switch format {
+	case v1alpha1.MetadataOptionsFormatIgnition:
+		return validateIgnition(data)
+	case v1alpha1.MetadataOptionsFormatTalos:
+		return validateTalos(data)
+	default:
+		return validateCloudConfig(data)
+	}
+}
+
+// ValidateBootstrapData runs the strict checks of all rendered documents.
+// It is too strict for existing templates, so it is used only by the dry-run rendering.
+func ValidateBootstrapData(format string, data *BootstrapData) error {
+	if format == "" || format == v1alpha1.MetadataOptionsFormatCloudInit {
+		if err := validateCloudConfigSchema(data.UserData); err != nil {
+			return fmt.Errorf("failed to validate userdata: %v", err)
+		}
+	}
+
+	if err := validateMetaData(data.MetaData); err != nil {
+		return fmt.Errorf("failed to validate metadata: %v", err)
+	}
+
+	if data.VendorData != "" {
+		if err := validateCloudConfig(data.VendorData); err != nil {
+			return fmt.Errorf("failed to validate vendor-data: %v", err)
+		}
+
+		if err := validateCloudConfigSchema(data.VendorData); err != nil {
+			return fmt.Errorf("failed to validate vendor-data: %v", err)
+		}
+	}
+
+	if err := validateNetworkConfig(data.NetworkConfig); err != nil {
+		return fmt.Errorf("failed to validate network-config: %v", err)
+	}
+
+	return nil
+}
+
+// validateMetaData checks the rendered NoCloud meta-data.
+func validateMetaData(data string) error {
+	metadata := map[string]any{}
+	if err := goYaml.Unmarshal([]byte(data), &metadata); err != nil {
+		return fmt.Errorf("invalid meta-data: %w", err)
+	}
+
+	if id, ok := metadata["instance-id"]; !ok || fmt.Sprint(id) == "" {
+		return fmt.Errorf("invalid meta-data: instance-id is required")
+	}
+
+	return nil
+}
+
+// validateNetworkConfig checks the rendered network-config version 1 or 2.
+func validateNetworkConfig(data string) error {
+	config := map[string]any{}
+	if err := goYaml.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Errorf("invalid network-config: %w", err)
+	}
+
+	if network, ok := config["network"].(map[string]any); ok {
+		config = network
+	}
+
+	switch config["version"] {
+	case 1, 2:
+		return nil
+	default:
+		return fmt.Errorf("invalid network-config: unsupported version %v", config["version"])
+	}
+}
+
+// validateCloudConfig checks only the cloud-config documents, other user-data types (scripts, multipart) are passed as is.
+func validateCloudConfig(data string) error {
+	if !strings.HasPrefix(data, cloudConfigHeader) {
+		return nil
+	}
+
+	config := map[string]any{}
+	if err := goYaml.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Errorf("invalid cloud-config: %w", err)
+	}
+
+	return nil
+}
+
+// validateCloudConfigSchema checks the types of the common cloud-config modules.
+func validateCloudConfigSchema(data string) error {
+	if !strings.HasPrefix(data, cloudConfigHeader) {
+		return nil
+	}
+
+	config := map[string]any{}
+	if err := goYaml.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Errorf("invalid cloud-config: %w", err)
+	}
+
+	for key, kind := range cloudConfigSchema {
+		value, ok := config[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		if reflect.TypeOf(value).Kind() != kind {
+			return fmt.Errorf("invalid cloud-config: %s must be %s, got %T", key, kind, value)
+		}
+	}
+
+	if files, ok := config["write_files"].([]any); ok {
+		for i, file := range files {
+			f, ok := file.(map[string]any)
+			if !ok {
+				return fmt.Errorf("invalid cloud-config: write_files[%d] must be map", i)
+			}
+
+			if path, ok := f["path"].(string); !ok || path == "" {
+				return fmt.Errorf("invalid cloud-config: write_files[%d].path is required", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateIgnition(data string) error {
+	config := struct {
+		Ignition *struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}{}
+
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Errorf("invalid ignition config: %w", err)
+	}
+
+	if config.Ignition == nil || config.Ignition.Version == "" {
+		return fmt.Errorf("invalid ignition config: ignition.version is required")
+	}
+
+	return nil
+}
+
+// validateTalos checks the Talos machine configuration, it can have multiple documents,
+// one of them must be the v1alpha1 machine config.
+func validateTalos(data string) error {
+	decoder := goYaml.NewDecoder(bytes.NewBufferString(data))
+
+	found := false
+
+	for {
+		doc := struct {
+			Version string         `yaml:"version"`
+			Machine map[string]any `yaml:"machine"`
+		}{}
+
+		err := decoder.Decode(&doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("invalid talos machine config: %w", err)
+		}
+
+		if doc.Version == talosConfigVersion && doc.Machine != nil {
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("invalid talos machine config: %s document with machine section is required", talosConfigVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
+)
+
+func TestValidateUserData(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		wantErr bool
+	}{
+		{
+			name:   "cloud-config",
+			format: v1alpha1.MetadataOptionsFormatCloudInit,
+			data:   "#cloud-config\nhostname: node-1\n",
+		},
+		{
+			name:    "cloud-config-invalid",
+			format:  v1alpha1.MetadataOptionsFormatCloudInit,
+			data:    "#cloud-config\nhostname: [node-1\n",
+			wantErr: true,
+		},
+		{
+			name:   "shell-script",
+			format: "",
+			data:   "#!/bin/sh\necho hello: [\n",
+		},
+		{
+			name:   "ignition",
+			format: v1alpha1.MetadataOptionsFormatIgnition,
+			data:   `{"ignition":{"version":"3.4.0"},"storage":{"files":[]}}`,
+		},
+		{
+			name:    "ignition-without-version",
+			format:  v1alpha1.MetadataOptionsFormatIgnition,
+			data:    `{"storage":{"files":[]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "ignition-invalid",
+			format:  v1alpha1.MetadataOptionsFormatIgnition,
+			data:    "#cloud-config\n",
+			wantErr: true,
+		},
+		{
+			name:   "talos",
+			format: v1alpha1.MetadataOptionsFormatTalos,
+			data: `version: v1alpha1
+machine:
+  type: worker
+  token: abcdef.0123456789abcdef
+cluster:
+  controlPlane:
+    endpoint: https://10.0.0.1:6443
+---
+apiVersion: v1alpha1
+kind: HostnameConfig
+hostname: node-1
+`,
+		},
+		{
+			name:    "talos-without-machine",
+			format:  v1alpha1.MetadataOptionsFormatTalos,
+			data:    "apiVersion: v1alpha1\nkind: HostnameConfig\nhostname: node-1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := cloudinit.ValidateUserData(tt.format, tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+const (
+	dryRunHostname       = "karpenter-dry-run"
+	dryRunInstanceID     = 100
+	dryRunInstanceUUID   = "00000000-0000-0000-0000-000000000000"
+	dryRunRegion         = "region-1"
+	dryRunZone           = "zone-1"
+	dryRunVersion        = "v1.34.0"
+	dryRunBootstrapToken = "abcdef.0123456789abcdef"
+)
+
+// BootstrapData is the rendered instance bootstrap data.
+type BootstrapData struct {
+	UserData      string
+	MetaData      string
+	VendorData    string
+	NetworkConfig string
+}
+
+// NewUserDataValues returns the user-data template values for the instance type.
+func NewUserDataValues(
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	instanceType *cloudprovider.InstanceType,
+	metadataValues MetaData,
+	networkValues NetworkConfig,
+	kubernetes Kubernetes,
+	values map[string]string,
+) UserDataValues {
+	userdataValues := UserDataValues{
+		Metadata: metadataValues,
+		Network:  networkValues,
+		Resources: Resources{
+			CPU:    instanceType.Capacity.Cpu().Value(),
+			Memory: instanceType.Capacity.Memory().Value(),
+		},
+		Kubernetes: kubernetes,
+		Values:     values,
+	}
+	userdataValues.Kubernetes.KubeletConfiguration = applyKubernetesConfiguration(nodeClass, instanceType)
+	userdataValues.Kubernetes.KubeletConfiguration.ProviderID = metadataValues.ProviderID
+
+	if len(userdataValues.Kubernetes.KubeletConfiguration.RegisterWithTaints) == 0 {
+		userdataValues.Kubernetes.KubeletConfiguration.RegisterWithTaints = []KubernetesTaint{
+			{
+				Key:    karpv1.UnregisteredTaintKey,
+				Effect: corev1.TaintEffectNoExecute,
+			},
+		}
+	}
+
+	for name, quantity := range instanceType.Capacity {
+		if size, ok := strings.CutPrefix(string(name), corev1.ResourceHugePagesPrefix); ok {
+			switch size {
+			case "1Gi":
+				userdataValues.Resources.Hugepages1Gi = int(quantity.Value() / (1024 * 1024 * 1024))
+			case "2Mi":
+				userdataValues.Resources.Hugepages2Mi = int(quantity.Value() / (2 * 1024 * 1024))
+			}
+		}
+	}
+
+	return userdataValues
+}
+
+// RenderBootstrapData renders the metadata templates of the TemplatesRef secret.
+// Only the user-data is checked against the bootstrap format, the strict checks run in RenderDryRun.
+func RenderBootstrapData(templates map[string][]byte, format string, userdataValues UserDataValues) (*BootstrapData, error) {
+	var err error
+
+	data := &BootstrapData{}
+
+	data.UserData = string(templates["user-data"])
+	if data.UserData == "" {
+		if format != "" && format != v1alpha1.MetadataOptionsFormatCloudInit {
+			return nil, fmt.Errorf("user-data template is required for %s format", format)
+		}
+
+		data.UserData = DefaultUserdata
+	}
+
+	data.UserData, err = ExecuteTemplate(data.UserData, userdataValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute userdata template: %v", err)
+	}
+
+	if err = ValidateUserData(format, data.UserData); err != nil {
+		return nil, fmt.Errorf("failed to validate userdata: %v", err)
+	}
+
+	data.MetaData = string(templates["meta-data"])
+	if data.MetaData == "" {
+		data.MetaData = DefaultMetadata
+	}
+
+	data.MetaData, err = ExecuteTemplate(data.MetaData, userdataValues.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute metadata template: %v", err)
+	}
+
+	data.VendorData = string(templates["vendor-data"])
+	if data.VendorData != "" {
+		data.VendorData, err = ExecuteTemplate(data.VendorData, userdataValues.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute vendor-data template: %v", err)
+		}
+	}
+
+	data.NetworkConfig = string(templates["network-config"])
+	if data.NetworkConfig == "" {
+		data.NetworkConfig = DefaultNetworkV2
+	}
+
+	data.NetworkConfig, err = ExecuteTemplate(data.NetworkConfig, userdataValues.Network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute network-config template: %v", err)
+	}
+
+	return data, nil
+}
+
+// RenderDryRun renders the bootstrap data of the NodeClass for a hypothetical instance
+// and runs the strict checks of all documents.
+// The instance type is optional, region and zone are used only if they are not empty.
+func RenderDryRun(
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	templates map[string][]byte,
+	values map[string]string,
+	instanceType *cloudprovider.InstanceType,
+	region string,
+	zone string,
+) (*BootstrapData, error) {
+	if instanceType == nil {
+		instanceType = &cloudprovider.InstanceType{
+			Name: "c1.2VCPU-4GB",
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		}
+	}
+
+	if region == "" {
+		region = dryRunRegion
+		if nodeClass.Spec.Region != "" {
+			region = nodeClass.Spec.Region
+		}
+	}
+
+	if zone == "" {
+		zone = dryRunZone
+	}
+
+	metadataValues := MetaData{
+		Hostname:     dryRunHostname,
+		InstanceID:   fmt.Sprintf("%d", dryRunInstanceID),
+		InstanceType: instanceType.Name,
+		InstanceUUID: dryRunInstanceUUID,
+		ProviderID:   provider.GetProviderID(region, dryRunInstanceID),
+		Region:       region,
+		Zone:         zone,
+		Tags:         nodeClass.Spec.Tags,
+		NodeClass:    nodeClass.Name,
+	}
+
+	networkValues := NetworkConfig{
+		Interfaces: []InterfaceConfig{
+			{
+				Name:     "eth0",
+				MacAddr:  "BC:24:11:00:00:01",
+				Address4: []string{"192.168.0.10/24"},
+				Gateway4: "192.168.0.1",
+				MTU:      1500,
+			},
+		},
+		NameServers: []string{"192.168.0.1"},
+	}
+
+	kubernetes := Kubernetes{
+		Version:        dryRunVersion,
+		BootstrapToken: dryRunBootstrapToken,
+	}
+
+	if values == nil {
+		values = map[string]string{}
+	}
+
+	format := ""
+	if nodeClass.Spec.MetadataOptions != nil {
+		format = nodeClass.Spec.MetadataOptions.Format
+	}
+
+	data, err := RenderBootstrapData(templates, format, NewUserDataValues(nodeClass, instanceType, metadataValues, networkValues, kubernetes, values))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ValidateBootstrapData(format, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func applyKubernetesConfiguration(
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	instanceType *cloudprovider.InstanceType,
+) *KubeletConfiguration {
+	kubeletConfig := &KubeletConfiguration{}
+
+	if nodeClass.Spec.KubeletConfiguration != nil {
+		data, _ := json.Marshal(nodeClass.Spec.KubeletConfiguration) //nolint: errchkjson
+		json.Unmarshal(data, kubeletConfig)
+	}
+
+	if instanceType.Overhead != nil {
+		if len(instanceType.Overhead.KubeReserved) > 0 {
+			kubeletConfig.KubeReserved = requestsToMap(instanceType.Overhead.KubeReserved)
+		}
+
+		if len(instanceType.Overhead.SystemReserved) > 0 {
+			kubeletConfig.SystemReserved = requestsToMap(instanceType.Overhead.SystemReserved)
+		}
+
+		if len(instanceType.Overhead.EvictionThreshold) > 0 && instanceType.Overhead.EvictionThreshold.Memory().String() != "" {
+			kubeletConfig.EvictionHard = DefaultEvictionHard
+			kubeletConfig.EvictionHard["memory.available"] = instanceType.Overhead.EvictionThreshold.Memory().String()
+		}
+	}
+
+	return kubeletConfig
+}
+
+func requestsToMap(requests corev1.ResourceList) map[string]string {
+	m := make(map[string]string)
+
+	cpu := requests.Cpu().MilliValue()
+	if cpu > 0 {
+		m[string(corev1.ResourceCPU)] = fmt.Sprintf("%dm", cpu)
+	}
+
+	mem := requests.Memory().Value() / (1024 * 1024)
+	if mem > 0 {
+		m[string(corev1.ResourceMemory)] = fmt.Sprintf("%dMi", mem)
+	}
+
+	storage := requests.StorageEphemeral().Value() / (1024 * 1024 * 1024)
+	if storage > 0 {
+		m[string(corev1.ResourceEphemeralStorage)] = fmt.Sprintf("%dGi", storage)
+	}
+
+	return m
+}
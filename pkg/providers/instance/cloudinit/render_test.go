@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderDryRun(t *testing.T) {
+	nodeClass := &v1alpha1.ProxmoxNodeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v1alpha1.ProxmoxNodeClassSpec{
+			Region: "cluster-1",
+			MetadataOptions: &v1alpha1.MetadataOptions{
+				Type: v1alpha1.MetadataOptionsTypeCDRom,
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		format    string
+		templates map[string][]byte
+		expectErr string
+	}{
+		{
+			name:      "default",
+			templates: map[string][]byte{},
+		},
+		{
+			name: "template-error",
+			templates: map[string][]byte{
+				"user-data": []byte("#cloud-config\nhostname: {{ .Metadata.Unknown }}\n"),
+			},
+			expectErr: "failed to execute userdata template",
+		},
+		{
+			name: "invalid-metadata",
+			templates: map[string][]byte{
+				"meta-data": []byte("hostname: {{ .Hostname }}\n"),
+			},
+			expectErr: "instance-id is required",
+		},
+		{
+			name: "invalid-network-config",
+			templates: map[string][]byte{
+				"network-config": []byte("network:\n  version: 3\n"),
+			},
+			expectErr: "unsupported version",
+		},
+		{
+			name:      "talos-without-template",
+			format:    v1alpha1.MetadataOptionsFormatTalos,
+			templates: map[string][]byte{},
+			expectErr: "user-data template is required",
+		},
+		{
+			name:   "ignition",
+			format: v1alpha1.MetadataOptionsFormatIgnition,
+			templates: map[string][]byte{
+				"user-data": []byte(`{"ignition":{"version":"3.4.0"},"passwd":{"users":[{"name":"{{ .Metadata.Hostname }}"}]}}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			nc := nodeClass.DeepCopy()
+			nc.Spec.MetadataOptions.Format = tt.format
+
+			data, err := cloudinit.RenderDryRun(nc, tt.templates, nil, nil, "", "")
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Contains(t, data.MetaData, "provider-id: proxmox://cluster-1/100")
+		})
+	}
+}
+
+func TestRenderBootstrapData(t *testing.T) {
+	templates := map[string][]byte{
+		"meta-data":      []byte("hostname: {{ .Hostname }}\n"),
+		"network-config": []byte("network:\n  version: 3\n"),
+	}
+
+	values := cloudinit.UserDataValues{
+		Metadata: cloudinit.MetaData{Hostname: "node-1"},
+	}
+
+	data, err := cloudinit.RenderBootstrapData(templates, "", values)
+	assert.NoError(t, err)
+	assert.Equal(t, "hostname: node-1\n", data.MetaData)
+
+	_, err = cloudinit.RenderBootstrapData(map[string][]byte{"user-data": []byte("#cloud-config\nhostname: [node-1\n")}, "", values)
+	assert.ErrorContains(t, err, "failed to validate userdata")
+}
@@ -20,3 +20,6 @@ import "github.com/pkg/errors"
 
 // ErrNoZoneFound is returned when no zones are available
 var ErrNoZoneFound = errors.New("no zones available")
+
+// ErrZoneSpreadUnsatisfiable is returned when all zones exceed the max skew of the zone spread
+var ErrZoneSpreadUnsatisfiable = errors.New("zone spread is unsatisfiable")
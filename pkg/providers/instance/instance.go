@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/luthermonson/go-proxmox"
 	"github.com/samber/lo"
@@ -32,6 +33,7 @@ import (
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity"
 	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/metadata"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/nodeipam"
 	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
 
@@ -39,6 +41,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -49,39 +52,57 @@ type Provider interface {
 	Create(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass, instanceTypes []*cloudprovider.InstanceType) (*corev1.Node, error)
 	Get(ctx context.Context, providerID string) (*corev1.Node, error)
 	Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim) error
+	GetInstanceDrift(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (*InstanceDrift, error)
 
 	UpdateFirewallRules(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) error
 	UpdateTags(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) error
 	UpdatePoolMembership(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) error
 
 	DetachCloudInit(ctx context.Context, nodeClaim *karpv1.NodeClaim) error
+	GetInstanceIdentity(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*InstanceIdentity, error)
 }
 
 type DefaultProvider struct {
+	kubeClient                  client.Client
 	kubernetesInterface         kubernetes.Interface
 	kubernetesBootstrapProvider bootstrap.Provider
 	cluster                     *pxpool.ProxmoxPool
 	cloudCapacityProvider       cloudcapacity.Provider
 	nodeIpamProvider            nodeipam.Provider
 	instanceTemplateProvider    instancetemplate.Provider
+	metadataProvider            metadata.Provider
+
+	// launched keeps the zones of the launched NodeClaims until the NodeClaims get the zone label.
+	muLaunched sync.Mutex
+	launched   map[string]launchedZone
+
+	// drift keeps the last instance drift of the NodeClaims, see GetInstanceDrift.
+	muDrift sync.Mutex
+	drift   map[string]cachedInstanceDrift
 }
 
 func NewProvider(
 	ctx context.Context,
+	kubeClient client.Client,
 	kubernetesInterface kubernetes.Interface,
 	kubernetesBootstrapProvider bootstrap.Provider,
 	cluster *pxpool.ProxmoxPool,
 	cloudCapacityProvider cloudcapacity.Provider,
 	nodeIpamController nodeipam.Provider,
 	instanceTemplateProvider instancetemplate.Provider,
+	metadataProvider metadata.Provider,
 ) (*DefaultProvider, error) {
 	return &DefaultProvider{
+		kubeClient:                  kubeClient,
 		kubernetesInterface:         kubernetesInterface,
 		kubernetesBootstrapProvider: kubernetesBootstrapProvider,
 		cluster:                     cluster,
 		cloudCapacityProvider:       cloudCapacityProvider,
 		nodeIpamProvider:            nodeIpamController,
 		instanceTemplateProvider:    instanceTemplateProvider,
+		metadataProvider:            metadataProvider,
+		launched:                    map[string]launchedZone{},
+		drift:                       map[string]cachedInstanceDrift{},
 	}, nil
 }
 
@@ -105,10 +126,13 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClai
 		for _, region := range regions {
 			zones := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(corev1.LabelTopologyZone).Values()
 			if len(zones) == 0 {
-				zones = p.cloudCapacityProvider.GetAvailableZonesInRegion(region, instanceType.Capacity)
+				zones = p.cloudCapacityProvider.GetAvailableZonesInRegion(region, cloudcapacity.NodeClassResources(instanceType.Capacity, nodeClass))
 			}
 
 			zones = getValuesByKey(instanceType, corev1.LabelTopologyZone, zones)
+			zones = p.filterZonesByArchitecture(nodeClaim, region, zones)
+			zones = p.filterZonesByCPUType(nodeClaim, region, zones)
+			zones = p.filterZonesByNUMANodes(nodeClaim, region, zones)
 			if len(zones) == 0 {
 				log.Error(ErrNoZoneFound, "No zones available in region for instanceType", "region", region, "instanceType", instanceType.Name)
 
@@ -117,7 +141,17 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClai
 
 			templateIDs := nodeClass.GetTemplateIDs(region)
 
-			zones = p.sortBestZoneByPlacementStrategy(nodeClass.Spec.PlacementStrategy, region, lo.Intersect(zones, nodeClass.GetZones(region)))
+			zones = p.sortBestZoneByPlacementStrategy(ctx, nodeClaim, nodeClass, region, lo.Intersect(zones, nodeClass.GetZones(region)))
+
+			zones = p.spreadZones(ctx, nodeClaim, nodeClass, region, zones)
+			if len(zones) == 0 {
+				log.Info("All zones exceed the zone spread max skew", "region", region, "instanceType", instanceType.Name)
+
+				errs = append(errs, fmt.Errorf("%w in region %s", ErrZoneSpreadUnsatisfiable, region))
+
+				continue
+			}
+
 			for _, zone := range zones {
 				templates := p.instanceTemplateProvider.ListWithFilter(ctx, func(c *instancetemplate.InstanceTemplateInfo) bool {
 					return c.Region == region && c.Zone == zone && slices.Contains(templateIDs, c.TemplateID)
@@ -143,6 +177,8 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClai
 
 				node.Labels[v1alpha1.LabelInstanceImageID] = template.TemplateHash
 
+				p.trackLaunchedZone(nodeClaim.Name, region, zone)
+
 				return node, nil
 			}
 		}
@@ -174,7 +210,7 @@ func (p *DefaultProvider) Get(ctx context.Context, providerID string) (*corev1.N
 		},
 		Status: corev1.NodeStatus{
 			NodeInfo: corev1.NodeSystemInfo{
-				Architecture:    karpv1.ArchitectureAmd64,
+				Architecture:    p.zoneArchitecture(region, vm.Node),
 				OperatingSystem: string(corev1.Linux),
 			},
 			Conditions: []corev1.NodeCondition{
@@ -201,6 +237,14 @@ func (p *DefaultProvider) Get(ctx context.Context, providerID string) (*corev1.N
 func (p *DefaultProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
 	log := log.FromContext(ctx).WithName("instance.Delete()")
 
+	p.untrackLaunchedZone(nodeClaim.Name)
+	p.forgetInstanceDrift(nodeClaim.Name)
+
+	// The bootstrap token is useless after the deletion, and must not be used to join another node.
+	if err := p.kubernetesBootstrapProvider.DeleteNodeClaimTokens(ctx, nodeClaim.Name); err != nil {
+		log.Error(err, "Failed to revoke bootstrap tokens", "nodeClaim", nodeClaim.Name)
+	}
+
 	vmid, region, err := provider.ParseProviderID(nodeClaim.Status.ProviderID)
 	if err != nil {
 		return fmt.Errorf("failed to get vm id from provider-id: %v", err)
@@ -379,5 +423,19 @@ func (p *DefaultProvider) DetachCloudInit(ctx context.Context, nodeClaim *karpv1
 		return fmt.Errorf("failed to detach cloud-init ISO from vm %d in region %s: %v", vmid, region, err)
 	}
 
+	err = p.detachIgnitionFWCfg(ctx, region, zone, vmid)
+	if err != nil {
+		return fmt.Errorf("failed to detach ignition config from vm %d in region %s: %v", vmid, region, err)
+	}
+
+	err = p.detachCloudInitSnippets(ctx, region, zone, vmid)
+	if err != nil {
+		return fmt.Errorf("failed to detach cloud-init snippets from vm %d in region %s: %v", vmid, region, err)
+	}
+
+	if err = p.metadataProvider.Delete(ctx, nodeClaim.Name); err != nil {
+		return fmt.Errorf("failed to delete instance metadata of vm %d in region %s: %v", vmid, region, err)
+	}
+
 	return nil
 }
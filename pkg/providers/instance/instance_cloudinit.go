@@ -18,27 +18,45 @@ package instance
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/luthermonson/go-proxmox"
+	"github.com/samber/lo"
 
 	goproxmox "github.com/sergelogvinov/go-proxmox"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/bootstrap"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
 	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/metadata"
 
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
 
+const (
+	// cloudInitSnippetFormat is the file name of the cloud-init snippet, vmID and snippet file name
+	cloudInitSnippetFormat = "karpenter-%d-%s"
+
+	// defaultStorageMountPath is the mount point of the network storages on the Proxmox nodes
+	defaultStorageMountPath = "/mnt/pve"
+)
+
+// ignitionFWCfgNames are the fw_cfg keys where Flatcar and Fedora CoreOS look for the Ignition config.
+var ignitionFWCfgNames = []string{
+	"opt/org.flatcar-linux/config",
+	"opt/com.coreos/config",
+}
+
 func (p *DefaultProvider) attachCloudInitISO(
 	ctx context.Context,
 	nodeClaim *karpv1.NodeClaim,
@@ -110,54 +128,432 @@ func (p *DefaultProvider) detachCloudInitISO(
 	return nil
 }
 
-func applyKubernetesConfiguration(
+func (p *DefaultProvider) attachCloudInitSnippets(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
 	nodeClass *v1alpha1.ProxmoxNodeClass,
+	instanceTemplate *instancetemplate.InstanceTemplateInfo,
 	instanceType *cloudprovider.InstanceType,
-) *KubeletConfiguration {
-	kubeletConfig := &KubeletConfiguration{}
+	region string,
+	zone string,
+	vmID int,
+	storage string,
+) error {
+	snippetsPath, err := snippetsStoragePath(ctx, nodeClass)
+	if err != nil {
+		return err
+	}
+
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return fmt.Errorf("failed to get proxmox cluster with region name %s: %v", region, err)
+	}
 
-	if nodeClass.Spec.KubeletConfiguration != nil {
-		data, _ := json.Marshal(nodeClass.Spec.KubeletConfiguration) //nolint: errchkjson
-		json.Unmarshal(data, kubeletConfig)
+	node, err := px.Node(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("unable to find node with name %s: %w", zone, err)
 	}
 
-	if instanceType.Overhead != nil {
-		if len(instanceType.Overhead.KubeReserved) > 0 {
-			kubeletConfig.KubeReserved = requestsToMap(instanceType.Overhead.KubeReserved)
+	vm, err := node.VirtualMachine(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("unable to find vm with id %d: %w", vmID, err)
+	}
+
+	userdata, metadata, vendordata, networkconfig, err := p.generateCloudInitVars(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, vm)
+	if err != nil {
+		return fmt.Errorf("failed to generate cloud-init for vm %d in region %s: %v", vmID, region, err)
+	}
+
+	cicustom := []string{}
+
+	for _, snippet := range []struct {
+		key  string
+		data string
+	}{
+		{"user", userdata},
+		{"meta", metadata},
+		{"vendor", vendordata},
+		{"network", networkconfig},
+	} {
+		if snippet.data == "" {
+			continue
 		}
 
-		if len(instanceType.Overhead.SystemReserved) > 0 {
-			kubeletConfig.SystemReserved = requestsToMap(instanceType.Overhead.SystemReserved)
+		name := fmt.Sprintf(cloudInitSnippetFormat, vmID, snippet.key+".yaml")
+		if err := os.WriteFile(filepath.Join(snippetsPath, "snippets", name), []byte(snippet.data), 0o640); err != nil {
+			return fmt.Errorf("failed to write cloud-init snippet %s: %w", name, err)
 		}
 
-		if len(instanceType.Overhead.EvictionThreshold) > 0 && instanceType.Overhead.EvictionThreshold.Memory().String() != "" {
-			kubeletConfig.EvictionHard = DefaultEvictionHard
-			kubeletConfig.EvictionHard["memory.available"] = instanceType.Overhead.EvictionThreshold.Memory().String()
+		cicustom = append(cicustom, fmt.Sprintf("%s=%s:snippets/%s", snippet.key, nodeClass.Spec.MetadataOptions.SnippetsStorage, name))
+	}
+
+	vmOptions := []proxmox.VirtualMachineOption{
+		{Name: "cicustom", Value: strings.Join(cicustom, ",")},
+	}
+
+	// Proxmox generates the cloud-init drive from the snippets, so the drive must exist.
+	if !hasCloudInitDrive(vm.VirtualMachineConfig) {
+		device, ok := lo.Find([]string{"ide2", "ide0", "ide1", "ide3"}, func(d string) bool {
+			_, used := vm.VirtualMachineConfig.MergeIDEs()[d]
+
+			return !used
+		})
+		if !ok {
+			return fmt.Errorf("no free ide device for cloud-init drive on vm %d", vmID)
 		}
+
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: device, Value: fmt.Sprintf("%s:cloudinit", storage)})
 	}
 
-	return kubeletConfig
+	task, err := vm.Config(ctx, vmOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to set cicustom for vm %d in region %s: %v", vmID, region, err)
+	}
+
+	return task.WaitFor(ctx, 5)
 }
 
-func requestsToMap(requests corev1.ResourceList) map[string]string {
-	m := make(map[string]string)
+func (p *DefaultProvider) attachIgnitionFWCfg(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	instanceTemplate *instancetemplate.InstanceTemplateInfo,
+	instanceType *cloudprovider.InstanceType,
+	region string,
+	zone string,
+	vmID int,
+) error {
+	snippetsPath, err := snippetsStoragePath(ctx, nodeClass)
+	if err != nil {
+		return err
+	}
+
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return fmt.Errorf("failed to get proxmox cluster with region name %s: %v", region, err)
+	}
 
-	cpu := requests.Cpu().MilliValue()
-	if cpu > 0 {
-		m[string(corev1.ResourceCPU)] = fmt.Sprintf("%dm", cpu)
+	node, err := px.Node(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("unable to find node with name %s: %w", zone, err)
+	}
+
+	vm, err := node.VirtualMachine(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("unable to find vm with id %d: %w", vmID, err)
 	}
 
-	mem := requests.Memory().Value() / (1024 * 1024)
-	if mem > 0 {
-		m[string(corev1.ResourceMemory)] = fmt.Sprintf("%dMi", mem)
+	userdata, _, _, _, err := p.generateCloudInitVars(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, vm)
+	if err != nil {
+		return fmt.Errorf("failed to generate ignition config for vm %d in region %s: %v", vmID, region, err)
 	}
 
-	storage := requests.StorageEphemeral().Value() / (1024 * 1024 * 1024)
-	if storage > 0 {
-		m[string(corev1.ResourceEphemeralStorage)] = fmt.Sprintf("%dGi", storage)
+	name := fmt.Sprintf(cloudInitSnippetFormat, vmID, "ignition.json")
+	if err := os.WriteFile(filepath.Join(snippetsPath, "snippets", name), []byte(userdata), 0o640); err != nil {
+		return fmt.Errorf("failed to write ignition config %s: %w", name, err)
 	}
 
-	return m
+	storageID := nodeClass.Spec.MetadataOptions.SnippetsStorage
+
+	storage, err := px.ClusterStorage(ctx, storageID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage %s: %v", storageID, err)
+	}
+
+	storagePath := storage.Path
+	if storagePath == "" {
+		storagePath = filepath.Join(defaultStorageMountPath, storageID)
+	}
+
+	args := strings.TrimSpace(vm.VirtualMachineConfig.Args + " " + ignitionFWCfgArgs(filepath.Join(storagePath, "snippets", name)))
+
+	task, err := vm.Config(ctx, proxmox.VirtualMachineOption{Name: "args", Value: args})
+	if err != nil {
+		return fmt.Errorf("failed to set fw_cfg args for vm %d in region %s: %v", vmID, region, err)
+	}
+
+	return task.WaitFor(ctx, 5)
+}
+
+func (p *DefaultProvider) detachIgnitionFWCfg(
+	ctx context.Context,
+	region string,
+	zone string,
+	vmID int,
+) error {
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return fmt.Errorf("failed to get proxmox cluster with region name %s: %v", region, err)
+	}
+
+	node, err := px.Node(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("unable to find node with name %s: %w", zone, err)
+	}
+
+	vm, err := node.VirtualMachine(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("unable to find vm with id %d: %w", vmID, err)
+	}
+
+	args, found := withoutIgnitionFWCfgArgs(vm.VirtualMachineConfig.Args, vmID)
+	if !found {
+		return nil
+	}
+
+	option := proxmox.VirtualMachineOption{Name: "args", Value: args}
+	if args == "" {
+		option = proxmox.VirtualMachineOption{Name: "delete", Value: "args"}
+	}
+
+	// QEMU fails to start if the fw_cfg file does not exist,
+	// so the args must be removed before the snippet.
+	task, err := vm.Config(ctx, option)
+	if err != nil {
+		return fmt.Errorf("failed to remove fw_cfg args from vm %d in region %s: %v", vmID, region, err)
+	}
+
+	return task.WaitFor(ctx, 5)
+}
+
+func ignitionFWCfgArgs(file string) string {
+	args := make([]string, 0, len(ignitionFWCfgNames))
+	for _, name := range ignitionFWCfgNames {
+		args = append(args, fmt.Sprintf("-fw_cfg name=%s,file=%s", name, file))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// withoutIgnitionFWCfgArgs removes the fw_cfg arguments added by Karpenter from the QEMU args.
+func withoutIgnitionFWCfgArgs(args string, vmID int) (string, bool) {
+	snippet := fmt.Sprintf("snippets/"+cloudInitSnippetFormat, vmID, "")
+
+	fields := strings.Fields(args)
+	result := make([]string, 0, len(fields))
+	found := false
+
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "-fw_cfg" && i+1 < len(fields) && strings.Contains(fields[i+1], snippet) {
+			found = true
+			i++
+
+			continue
+		}
+
+		result = append(result, fields[i])
+	}
+
+	return strings.Join(result, " "), found
+}
+
+func (p *DefaultProvider) publishCloudInitMetadata(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	instanceTemplate *instancetemplate.InstanceTemplateInfo,
+	instanceType *cloudprovider.InstanceType,
+	region string,
+	zone string,
+	vmID int,
+) error {
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return fmt.Errorf("failed to get proxmox cluster with region name %s: %v", region, err)
+	}
+
+	node, err := px.Node(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("unable to find node with name %s: %w", zone, err)
+	}
+
+	vm, err := node.VirtualMachine(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("unable to find vm with id %d: %w", vmID, err)
+	}
+
+	userData, metaData, vendorData, networkConfig, err := p.generateCloudInitVars(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, vm)
+	if err != nil {
+		return fmt.Errorf("failed to generate cloud-init for vm %d in region %s: %v", vmID, region, err)
+	}
+
+	token, err := metadata.NewToken()
+	if err != nil {
+		return err
+	}
+
+	if url := options.FromContext(ctx).MetadataServiceURL; url != "" {
+		smbios1, err := metadataServiceSMBIOS(vm.VirtualMachineConfig.SMBios1, url, token)
+		if err != nil {
+			return fmt.Errorf("failed to configure metadata service datasource for vm %d: %w", vmID, err)
+		}
+
+		if err := px.UpdateVMByID(ctx, zone, vmID, map[string]any{"smbios1": smbios1}); err != nil {
+			return fmt.Errorf("failed to configure metadata service datasource for vm %d: %w", vmID, err)
+		}
+	}
+
+	return p.metadataProvider.Set(ctx, &metadata.InstanceMetadata{
+		Name:          nodeClaim.Name,
+		UUID:          goproxmox.GetVMUUID(vm),
+		Token:         token,
+		Addresses:     instanceAddresses(vm.VirtualMachineConfig),
+		UserData:      userData,
+		MetaData:      metaData,
+		VendorData:    vendorData,
+		NetworkConfig: networkConfig,
+	})
+}
+
+// metadataServiceSMBIOS returns the smbios1 option with the NoCloud datasource of the instance in the serial,
+// the datasource URL contains the instance token, so only the VM can read its metadata.
+// The values are base64 encoded, so the base64 padding and the datasource separators are kept as is.
+func metadataServiceSMBIOS(value, url, token string) (string, error) {
+	keys := []string{}
+	values := map[string]string{}
+	encoded := false
+
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+
+		if k == "base64" {
+			encoded = v == "1" || v == "true"
+
+			continue
+		}
+
+		keys = append(keys, k)
+		values[k] = v
+	}
+
+	for _, k := range keys {
+		if k == "uuid" || !encoded {
+			continue
+		}
+
+		b, err := base64.StdEncoding.DecodeString(values[k])
+		if err != nil {
+			return "", fmt.Errorf("failed to decode smbios1 %s: %w", k, err)
+		}
+
+		values[k] = string(b)
+	}
+
+	fields := []string{"ds=nocloud"}
+	for _, f := range strings.Split(values["serial"], ";") {
+		if f != "" && f != "ds=nocloud" && !strings.HasPrefix(f, "s=") {
+			fields = append(fields, f)
+		}
+	}
+
+	fields = append(fields, fmt.Sprintf("s=%s/%s/", strings.TrimSuffix(url, "/"), token))
+
+	if _, ok := values["serial"]; !ok {
+		keys = append(keys, "serial")
+	}
+
+	values["serial"] = strings.Join(fields, ";")
+
+	options := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		v := values[k]
+		if k != "uuid" {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+
+		options = append(options, k+"="+v)
+	}
+
+	return strings.Join(append(options, "base64=1"), ","), nil
+}
+
+func (p *DefaultProvider) detachCloudInitSnippets(
+	ctx context.Context,
+	region string,
+	zone string,
+	vmID int,
+) error {
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return fmt.Errorf("failed to get proxmox cluster with region name %s: %v", region, err)
+	}
+
+	node, err := px.Node(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("unable to find node with name %s: %w", zone, err)
+	}
+
+	vm, err := node.VirtualMachine(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("unable to find vm with id %d: %w", vmID, err)
+	}
+
+	if strings.Contains(vm.VirtualMachineConfig.CICustom, fmt.Sprintf("snippets/"+cloudInitSnippetFormat, vmID, "")) {
+		task, err := vm.Config(ctx, proxmox.VirtualMachineOption{Name: "delete", Value: "cicustom"})
+		if err != nil {
+			return fmt.Errorf("failed to remove cicustom from vm %d in region %s: %v", vmID, region, err)
+		}
+
+		if err = task.WaitFor(ctx, 5); err != nil {
+			return fmt.Errorf("failed to remove cicustom from vm %d in region %s: %v", vmID, region, err)
+		}
+
+		// Regenerate the cloud-init drive to remove the bootstrap token from it
+		if err = px.RegenerateVMCloudInit(ctx, zone, vmID); err != nil {
+			return fmt.Errorf("failed to regenerate cloudinit iso for vm %d in region %s: %v", vmID, region, err)
+		}
+	}
+
+	return removeCloudInitSnippets(ctx, vmID)
+}
+
+// snippetsStoragePath returns the mount path of the NodeClass snippets storage.
+// Only one storage is mounted, the VM would reference missing files on other storages.
+func snippetsStoragePath(ctx context.Context, nodeClass *v1alpha1.ProxmoxNodeClass) (string, error) {
+	opts := options.FromContext(ctx)
+	if opts.SnippetsStoragePath == "" {
+		return "", fmt.Errorf("snippets storage path is not configured")
+	}
+
+	if storage := nodeClass.Spec.MetadataOptions.SnippetsStorage; storage != opts.SnippetsStorage {
+		return "", fmt.Errorf("snippets storage %s is not mounted, the mounted storage is %s", storage, opts.SnippetsStorage)
+	}
+
+	return opts.SnippetsStoragePath, nil
+}
+
+// removeCloudInitSnippets removes the snippet files of the VM from the snippets storage.
+func removeCloudInitSnippets(ctx context.Context, vmID int) error {
+	snippetsPath := options.FromContext(ctx).SnippetsStoragePath
+	if snippetsPath == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(snippetsPath, "snippets", fmt.Sprintf(cloudInitSnippetFormat, vmID, "*")))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cloud-init snippet %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func hasCloudInitDrive(config *proxmox.VirtualMachineConfig) bool {
+	for _, disk := range config.MergeDisks() {
+		if strings.Contains(disk, "cloudinit") {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (p *DefaultProvider) generateCloudInitVars(
@@ -206,11 +602,6 @@ func (p *DefaultProvider) generateCloudInitVars(
 		}
 	}
 
-	bootstrapToken, err := p.kubernetesBootstrapProvider.CreateToken(ctx, nodeClaim)
-	if err != nil {
-		return "", "", "", "", fmt.Errorf("failed to create bootstrap token: %v", err)
-	}
-
 	metadataValues := cloudinit.MetaData{
 		Hostname:     nodeClaim.Name,
 		InstanceID:   fmt.Sprintf("%d", vm.VMID),
@@ -223,6 +614,16 @@ func (p *DefaultProvider) generateCloudInitVars(
 		NodeClass:    nodeClass.Name,
 	}
 
+	ttl := bootstrap.DefaultTokenTTL
+	if nodeClass.Spec.MetadataOptions.BootstrapTokenTTL != nil {
+		ttl = nodeClass.Spec.MetadataOptions.BootstrapTokenTTL.Duration
+	}
+
+	bootstrapToken, err := p.kubernetesBootstrapProvider.CreateToken(ctx, nodeClaim, metadataValues.ProviderID, ttl)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to create bootstrap token: %v", err)
+	}
+
 	ifaces := map[string]cloudcapacity.NetworkIfaceInfo{}
 
 	net := p.cloudCapacityProvider.GetNetwork(region, zone)
@@ -232,82 +633,18 @@ func (p *DefaultProvider) generateCloudInitVars(
 
 	networkValues := cloudinit.GetNetworkConfigFromVirtualMachineConfig(vm.VirtualMachineConfig, ifaces)
 
-	userdataValues := UserDataValues{
-		Metadata: metadataValues,
-		Network:  networkValues,
-		Resources: Resources{
-			CPU:    instanceType.Capacity.Cpu().Value(),
-			Memory: instanceType.Capacity.Memory().Value(),
-		},
-		Kubernetes: Kubernetes{
-			Version:              version.String(),
-			RootCA:               rootCA,
-			BootstrapToken:       bootstrapToken,
-			KubeletConfiguration: applyKubernetesConfiguration(nodeClass, instanceType),
-		},
-		Values: values,
-	}
-	userdataValues.Kubernetes.KubeletConfiguration.ProviderID = metadataValues.ProviderID
-
-	if len(userdataValues.Kubernetes.KubeletConfiguration.RegisterWithTaints) == 0 {
-		userdataValues.Kubernetes.KubeletConfiguration.RegisterWithTaints = []KubernetesTaint{
-			{
-				Key:    karpv1.UnregisteredTaintKey,
-				Effect: corev1.TaintEffectNoExecute,
-			},
-		}
+	kubernetes := Kubernetes{
+		Version:        version.String(),
+		RootCA:         rootCA,
+		BootstrapToken: bootstrapToken,
 	}
 
-	for name, quantity := range instanceType.Capacity {
-		if size, ok := strings.CutPrefix(string(name), corev1.ResourceHugePagesPrefix); ok {
-			switch size {
-			case "1Gi":
-				userdataValues.Resources.Hugepages1Gi = int(quantity.Value() / (1024 * 1024 * 1024))
-			case "2Mi":
-				userdataValues.Resources.Hugepages2Mi = int(quantity.Value() / (2 * 1024 * 1024))
-			}
-		}
-	}
+	userdataValues := cloudinit.NewUserDataValues(nodeClass, instanceType, metadataValues, networkValues, kubernetes, values)
 
-	userdata := string(secret.Data["user-data"])
-	if userdata == "" {
-		userdata = cloudinit.DefaultUserdata
-	}
-
-	userdata, err = cloudinit.ExecuteTemplate(userdata, userdataValues)
-	if err != nil {
-		return "", "", "", "", fmt.Errorf("failed to execute userdata template: %v", err)
-	}
-
-	metadata := string(secret.Data["meta-data"])
-	if metadata == "" {
-		metadata = cloudinit.DefaultMetadata
-	}
-
-	metadata, err = cloudinit.ExecuteTemplate(metadata, metadataValues)
+	data, err := cloudinit.RenderBootstrapData(secret.Data, nodeClass.Spec.MetadataOptions.Format, userdataValues)
 	if err != nil {
-		return "", "", "", "", fmt.Errorf("failed to execute metadata template: %v", err)
-	}
-
-	vendordata := string(secret.Data["vendor-data"])
-	if vendordata != "" {
-		vendordata, err = cloudinit.ExecuteTemplate(vendordata, metadataValues)
-		if err != nil {
-			return "", "", "", "", fmt.Errorf("failed to execute vendor-data template: %v", err)
-		}
-	}
-
-	networkconfig := string(secret.Data["network-config"])
-	if networkconfig == "" {
-		networkconfig = cloudinit.DefaultNetworkV2
-	}
-
-	if networkconfig != "" {
-		networkconfig, err = cloudinit.ExecuteTemplate(networkconfig, networkValues)
-		if err != nil {
-			return "", "", "", "", fmt.Errorf("failed to execute network-config template: %v", err)
-		}
+		return "", "", "", "", err
 	}
 
-	return userdata, metadata, vendordata, networkconfig, nil
+	return data.UserData, data.MetaData, data.VendorData, data.NetworkConfig, nil
 }
@@ -0,0 +1,172 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/assert"
+
+	goproxmox "github.com/sergelogvinov/go-proxmox"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
+)
+
+func TestWithoutIgnitionFWCfgArgs(t *testing.T) {
+	file := "/mnt/pve/cephfs/snippets/karpenter-100-ignition.json"
+
+	tests := []struct {
+		name   string
+		args   string
+		expect string
+		found  bool
+	}{
+		{
+			name:   "empty",
+			args:   "",
+			expect: "",
+		},
+		{
+			name:   "only-ignition",
+			args:   ignitionFWCfgArgs(file),
+			expect: "",
+			found:  true,
+		},
+		{
+			name:   "template-args",
+			args:   "-cpu host,+kvm_pv_unhalt " + ignitionFWCfgArgs(file),
+			expect: "-cpu host,+kvm_pv_unhalt",
+			found:  true,
+		},
+		{
+			name:   "other-vm",
+			args:   ignitionFWCfgArgs("/mnt/pve/cephfs/snippets/karpenter-101-ignition.json"),
+			expect: ignitionFWCfgArgs("/mnt/pve/cephfs/snippets/karpenter-101-ignition.json"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			args, found := withoutIgnitionFWCfgArgs(tt.args, 100)
+			assert.Equal(t, tt.expect, args)
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
+
+func TestMetadataServiceSMBIOS(t *testing.T) {
+	t.Parallel()
+
+	serial := base64.StdEncoding.EncodeToString([]byte("h=node-1;i=100"))
+
+	tests := []struct {
+		name   string
+		smbios string
+		serial string
+	}{
+		{
+			name:   "empty",
+			smbios: "uuid=5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11",
+			serial: "ds=nocloud;s=http://metadata:8090/token/",
+		},
+		{
+			name:   "instance serial",
+			smbios: "uuid=5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11,serial=" + serial + ",base64=1",
+			serial: "ds=nocloud;h=node-1;i=100;s=http://metadata:8090/token/",
+		},
+		{
+			name:   "datasource serial",
+			smbios: "uuid=5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11,serial=ds=nocloud;s=http://old/",
+			serial: "ds=nocloud;s=http://metadata:8090/token/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := metadataServiceSMBIOS(tt.smbios, "http://metadata:8090/", "token")
+			assert.NoError(t, err)
+
+			assert.Equal(t, "uuid=5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11,serial="+base64.StdEncoding.EncodeToString([]byte(tt.serial))+",base64=1", value)
+			assert.Equal(t, "5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11", goproxmox.GetVMUUID(&proxmox.VirtualMachine{
+				VirtualMachineConfig: &proxmox.VirtualMachineConfig{SMBios1: value},
+			}))
+		})
+	}
+}
+
+func TestSnippetsStoragePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      *options.Options
+		storage   string
+		expected  string
+		expectErr string
+	}{
+		{
+			name:      "not-configured",
+			opts:      &options.Options{},
+			storage:   "cephfs",
+			expectErr: "snippets storage path is not configured",
+		},
+		{
+			name:     "mounted",
+			opts:     &options.Options{SnippetsStorage: "cephfs", SnippetsStoragePath: "/mnt/snippets"},
+			storage:  "cephfs",
+			expected: "/mnt/snippets",
+		},
+		{
+			name:      "other-storage",
+			opts:      &options.Options{SnippetsStorage: "cephfs", SnippetsStoragePath: "/mnt/snippets"},
+			storage:   "nfs",
+			expectErr: "snippets storage nfs is not mounted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			nodeClass := &v1alpha1.ProxmoxNodeClass{
+				Spec: v1alpha1.ProxmoxNodeClassSpec{
+					MetadataOptions: &v1alpha1.MetadataOptions{
+						Type:            v1alpha1.MetadataOptionsTypeSnippets,
+						SnippetsStorage: tt.storage,
+					},
+				},
+			}
+
+			path, err := snippetsStoragePath(options.ToContext(context.Background(), tt.opts), nodeClass)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
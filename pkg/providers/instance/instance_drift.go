@@ -0,0 +1,380 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/samber/lo"
+
+	goproxmox "github.com/sergelogvinov/go-proxmox"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
+	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/cpuset"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+const (
+	InstanceDriftFieldCPU            = "cpu"
+	InstanceDriftFieldMemory         = "memory"
+	InstanceDriftFieldMemoryBalloon  = "memoryBalloon"
+	InstanceDriftFieldAffinity       = "affinity"
+	InstanceDriftFieldNetwork        = "network"
+	InstanceDriftFieldBootDevice     = "bootDevice"
+	InstanceDriftFieldSecurityGroups = "securityGroups"
+	InstanceDriftFieldTags           = "tags"
+	InstanceDriftFieldResourcePool   = "resourcePool"
+
+	// instanceDriftTTL is the time to keep the instance drift of the NodeClaim.
+	// The drift is polled for every NodeClaim, and each check reads the VM config and firewall rules from Proxmox.
+	instanceDriftTTL = 1 * time.Minute
+)
+
+// InstanceDrift describes the difference between the live virtual machine configuration
+// and the configuration which was applied by Karpenter during instance creation.
+type InstanceDrift struct {
+	// Static is the list of drifted fields which require the instance replacement.
+	Static []string
+	// InPlace is the list of drifted fields which can be repaired by the in-place update controller.
+	InPlace []string
+}
+
+// cachedInstanceDrift is the last instance drift of the NodeClaim.
+type cachedInstanceDrift struct {
+	// key is the state of the NodeClaim and NodeClass which the drift was checked against.
+	key     string
+	drift   *InstanceDrift
+	expires time.Time
+}
+
+// instanceConfig is the expected virtual machine configuration.
+type instanceConfig struct {
+	CPUs int
+	// Memory in MiB
+	Memory uint64
+	// MinMemory is the balloon minimum memory in MiB, zero skips the check.
+	MinMemory uint64
+	Affinity  string
+	StorageID string
+	// Networks is the network devices of the instance template, nil skips the check.
+	Networks map[string]string
+	Tags     []string
+}
+
+// GetInstanceDrift reads the live virtual machine configuration and compares it
+// with the configuration expected for the NodeClaim and NodeClass.
+// The result is cached for instanceDriftTTL, changes of the NodeClaim or NodeClass invalidate it.
+func (p *DefaultProvider) GetInstanceDrift(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (*InstanceDrift, error) {
+	key := strings.Join([]string{
+		nodeClaim.Status.ProviderID,
+		nodeClaim.Status.ImageID,
+		nodeClaim.Annotations[v1alpha1.AnnotationProxmoxNodeInPlaceUpdateHash],
+		nodeClass.Hash(),
+	}, "/")
+
+	if drift, ok := p.cachedInstanceDrift(nodeClaim.Name, key); ok {
+		return drift, nil
+	}
+
+	drift, err := p.getInstanceDrift(ctx, nodeClaim, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cacheInstanceDrift(nodeClaim.Name, key, drift)
+
+	return drift, nil
+}
+
+func (p *DefaultProvider) cachedInstanceDrift(name, key string) (*InstanceDrift, bool) {
+	p.muDrift.Lock()
+	defer p.muDrift.Unlock()
+
+	cached, ok := p.drift[name]
+	if !ok || cached.key != key || time.Now().After(cached.expires) {
+		return nil, false
+	}
+
+	return &InstanceDrift{
+		Static:  slices.Clone(cached.drift.Static),
+		InPlace: slices.Clone(cached.drift.InPlace),
+	}, true
+}
+
+func (p *DefaultProvider) cacheInstanceDrift(name, key string, drift *InstanceDrift) {
+	p.muDrift.Lock()
+	defer p.muDrift.Unlock()
+
+	now := time.Now()
+
+	for n, cached := range p.drift {
+		if now.After(cached.expires) {
+			delete(p.drift, n)
+		}
+	}
+
+	p.drift[name] = cachedInstanceDrift{
+		key: key,
+		drift: &InstanceDrift{
+			Static:  slices.Clone(drift.Static),
+			InPlace: slices.Clone(drift.InPlace),
+		},
+		expires: now.Add(instanceDriftTTL),
+	}
+}
+
+func (p *DefaultProvider) forgetInstanceDrift(name string) {
+	p.muDrift.Lock()
+	defer p.muDrift.Unlock()
+
+	delete(p.drift, name)
+}
+
+func (p *DefaultProvider) getInstanceDrift(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ProxmoxNodeClass) (*InstanceDrift, error) {
+	log := log.FromContext(ctx).WithName("instance.GetInstanceDrift()")
+
+	drift := &InstanceDrift{}
+
+	vmid, region, err := provider.ParseProviderID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		return drift, nil //nolint: nilerr
+	}
+
+	if region == "" {
+		region = nodeClaim.Labels[corev1.LabelTopologyRegion]
+	}
+
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return nil, pxpool.ErrRegionNotFound
+	}
+
+	vmr, err := p.cluster.GetVMByIDInRegion(ctx, region, uint64(vmid))
+	if err != nil {
+		if errors.Is(err, goproxmox.ErrVirtualMachineNotFound) {
+			return drift, nil
+		}
+
+		return nil, fmt.Errorf("failed to get vm %d: %w", vmid, err)
+	}
+
+	vm, err := px.GetVMConfig(ctx, vmid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vm config for vm %d: %w", vmid, err)
+	}
+
+	expected := instanceConfig{
+		CPUs:      int(nodeClaim.Status.Capacity.Cpu().Value()),
+		Memory:    uint64(nodeClaim.Status.Capacity.Memory().Value()) / 1024 / 1024,
+		MinMemory: nodeClass.Spec.MemoryBalloon.GetMinMemory(uint64(nodeClaim.Status.Capacity.Memory().Value())) / 1024 / 1024,
+		Affinity:  affinityFromDescription(vm.VirtualMachineConfig.Description),
+		StorageID: nodeClass.Spec.BootDevice.Storage,
+		Tags:      nodeClass.Spec.Tags,
+	}
+
+	if nodeClaim.Status.ImageID != "" {
+		templates := p.instanceTemplateProvider.ListWithFilter(ctx, func(c *instancetemplate.InstanceTemplateInfo) bool {
+			return c.Region == region && c.Zone == vmr.Node && c.TemplateHash == nodeClaim.Status.ImageID
+		})
+
+		if len(templates) > 0 {
+			if expected.StorageID == "" {
+				expected.StorageID = templates[0].TemplateStorageID
+			}
+
+			template, err := px.GetVMTemplateConfig(ctx, int(templates[0].TemplateID))
+			if err != nil {
+				log.Error(err, "Failed to get instance template config", "templateID", templates[0].TemplateID)
+			} else {
+				expected.Networks = template.VirtualMachineConfig.MergeNets()
+			}
+		}
+	}
+
+	drift = compareInstanceConfig(expected, vm.VirtualMachineConfig)
+
+	rules, err := vm.FirewallGetRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get firewall rules for vm %d: %w", vmid, err)
+	}
+
+	if !firewallRulesEqual(nodeClass.Spec.SecurityGroups, rules) {
+		drift.InPlace = append(drift.InPlace, InstanceDriftFieldSecurityGroups)
+	}
+
+	if vmr.Pool != nodeClass.Spec.ResourcePool {
+		drift.InPlace = append(drift.InPlace, InstanceDriftFieldResourcePool)
+	}
+
+	if len(drift.Static) > 0 || len(drift.InPlace) > 0 {
+		log.V(1).Info("Instance configuration drifted", "nodeClaim", nodeClaim.Name, "vmID", vmid, "static", drift.Static, "inPlace", drift.InPlace)
+	}
+
+	return drift, nil
+}
+
+func compareInstanceConfig(expected instanceConfig, config *proxmox.VirtualMachineConfig) *InstanceDrift {
+	drift := &InstanceDrift{}
+
+	if config == nil {
+		return drift
+	}
+
+	if expected.CPUs > 0 && config.Cores != expected.CPUs {
+		drift.Static = append(drift.Static, InstanceDriftFieldCPU)
+	}
+
+	if expected.Memory > 0 && uint64(config.Memory) != expected.Memory {
+		drift.Static = append(drift.Static, InstanceDriftFieldMemory)
+	}
+
+	if expected.MinMemory > 0 && uint64(config.Balloon) != expected.MinMemory {
+		drift.Static = append(drift.Static, InstanceDriftFieldMemoryBalloon)
+	}
+
+	if expected.Affinity != "" {
+		expectedCPUs, _ := cpuset.Parse(expected.Affinity) //nolint:errcheck
+
+		cpus, err := cpuset.Parse(config.Affinity)
+		if err != nil || !cpus.Equals(expectedCPUs) {
+			drift.Static = append(drift.Static, InstanceDriftFieldAffinity)
+		}
+	}
+
+	if expected.Networks != nil && !networksEqual(expected.Networks, config.MergeNets()) {
+		drift.Static = append(drift.Static, InstanceDriftFieldNetwork)
+	}
+
+	if expected.StorageID != "" && bootDeviceStorage(config) != expected.StorageID {
+		drift.Static = append(drift.Static, InstanceDriftFieldBootDevice)
+	}
+
+	// The VM can have extra tags, such as the power profile or policy tags of the proxmox-scheduler set by operators
+	if !lo.Every(strings.Split(config.Tags, ";"), expected.Tags) {
+		drift.InPlace = append(drift.InPlace, InstanceDriftFieldTags)
+	}
+
+	return drift
+}
+
+// affinityFromDescription returns the CPU affinity which was stored in the VM description during creation.
+func affinityFromDescription(description string) string {
+	for part := range strings.SplitSeq(description, ",") {
+		if affinity, ok := strings.CutPrefix(strings.TrimSpace(part), "affinity="); ok {
+			if _, err := cpuset.Parse(affinity); err != nil {
+				return ""
+			}
+
+			return affinity
+		}
+	}
+
+	return ""
+}
+
+// networksEqual compares the network devices, the MAC address and queues are ignored
+// because they are generated during the cloning.
+func networksEqual(expected, current map[string]string) bool {
+	if len(expected) != len(current) {
+		return false
+	}
+
+	for name, value := range expected {
+		currentValue, ok := current[name]
+		if !ok {
+			return false
+		}
+
+		expectedIface := goproxmox.VMNetworkDevice{}
+		if err := expectedIface.UnmarshalString(value); err != nil {
+			return false
+		}
+
+		currentIface := goproxmox.VMNetworkDevice{}
+		if err := currentIface.UnmarshalString(currentValue); err != nil {
+			return false
+		}
+
+		if expectedIface.Bridge != currentIface.Bridge ||
+			lo.FromPtr(expectedIface.Tag) != lo.FromPtr(currentIface.Tag) ||
+			bool(lo.FromPtr(expectedIface.Firewall)) != bool(lo.FromPtr(currentIface.Firewall)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bootDeviceStorage returns the storage ID of the boot disk.
+func bootDeviceStorage(config *proxmox.VirtualMachineConfig) string {
+	disks := config.MergeDisks()
+
+	devices := []string{}
+	if order, ok := strings.CutPrefix(config.Boot, "order="); ok {
+		devices = strings.Split(order, ";")
+	}
+
+	devices = append(devices, "virtio0", "scsi0", "sata0", "ide0")
+
+	for _, device := range devices {
+		disk, ok := disks[device]
+		if !ok || strings.Contains(disk, "media=cdrom") || strings.Contains(disk, "cloudinit") {
+			continue
+		}
+
+		storage, _, found := strings.Cut(disk, ":")
+		if !found {
+			continue
+		}
+
+		return storage
+	}
+
+	return ""
+}
+
+// firewallRulesEqual checks that the security groups are applied to the VM in the same order.
+func firewallRulesEqual(groups []v1alpha1.SecurityGroups, rules []*proxmox.FirewallRule) bool {
+	if len(groups) != len(rules) {
+		return false
+	}
+
+	rules = slices.SortedFunc(slices.Values(rules), func(a, b *proxmox.FirewallRule) int {
+		return a.Pos - b.Pos
+	})
+
+	for i, sg := range groups {
+		rule := rules[i]
+		if rule.Type != "group" || rule.Action != sg.Name || rule.Iface != sg.Interface || !rule.IsEnable() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+)
+
+func TestCompareInstanceConfig(t *testing.T) {
+	expected := instanceConfig{
+		CPUs:      4,
+		Memory:    8192,
+		Affinity:  "0-3",
+		StorageID: "lvm",
+		Networks: map[string]string{
+			"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1",
+		},
+		Tags: []string{"karpenter", "k8s"},
+	}
+
+	tests := []struct {
+		name   string
+		config *proxmox.VirtualMachineConfig
+		expect *InstanceDrift
+	}{
+		{
+			name: "no-drift",
+			config: &proxmox.VirtualMachineConfig{
+				Cores:    4,
+				Memory:   8192,
+				Affinity: "0,1,2,3",
+				Boot:     "order=scsi0;net0",
+				SCSI0:    "lvm:vm-100-disk-0,size=50G",
+				IDE2:     "local:cloudinit,media=cdrom",
+				Net0:     "virtio=BC:24:11:00:00:02,bridge=vmbr0,firewall=1,queues=4",
+				Tags:     "k8s;karpenter;go-proxmox+cloud-init",
+			},
+			expect: &InstanceDrift{},
+		},
+		{
+			name: "extra-tags",
+			config: &proxmox.VirtualMachineConfig{
+				Cores:    4,
+				Memory:   8192,
+				Affinity: "0-3",
+				SCSI0:    "lvm:vm-100-disk-0,size=50G",
+				Net0:     "virtio=BC:24:11:00:00:02,bridge=vmbr0,firewall=1",
+				Tags:     "k8s;karpenter;power-latency",
+			},
+			expect: &InstanceDrift{},
+		},
+		{
+			name: "static-drift",
+			config: &proxmox.VirtualMachineConfig{
+				Cores:   8,
+				Memory:  4096,
+				VirtIO0: "ceph:vm-100-disk-0,size=50G",
+				Net0:    "virtio=BC:24:11:00:00:02,bridge=vmbr1,firewall=1",
+				Net1:    "virtio=BC:24:11:00:00:03,bridge=vmbr0",
+				Tags:    "k8s;karpenter",
+			},
+			expect: &InstanceDrift{
+				Static: []string{
+					InstanceDriftFieldCPU,
+					InstanceDriftFieldMemory,
+					InstanceDriftFieldAffinity,
+					InstanceDriftFieldNetwork,
+					InstanceDriftFieldBootDevice,
+				},
+			},
+		},
+		{
+			name: "in-place-drift",
+			config: &proxmox.VirtualMachineConfig{
+				Cores:    4,
+				Memory:   8192,
+				Affinity: "0-3",
+				SCSI0:    "lvm:vm-100-disk-0,size=50G",
+				Net0:     "virtio=BC:24:11:00:00:02,bridge=vmbr0,firewall=1",
+				Tags:     "karpenter",
+			},
+			expect: &InstanceDrift{
+				InPlace: []string{InstanceDriftFieldTags},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expect, compareInstanceConfig(expected, tt.config))
+		})
+	}
+}
+
+func TestFirewallRulesEqual(t *testing.T) {
+	groups := []v1alpha1.SecurityGroups{
+		{Name: "kubernetes", Interface: "net0"},
+		{Name: "monitoring", Interface: "net0"},
+	}
+
+	tests := []struct {
+		name   string
+		rules  []*proxmox.FirewallRule
+		expect bool
+	}{
+		{
+			name: "equal",
+			rules: []*proxmox.FirewallRule{
+				{Pos: 1, Type: "group", Action: "monitoring", Iface: "net0", Enable: 1},
+				{Pos: 0, Type: "group", Action: "kubernetes", Iface: "net0", Enable: 1},
+			},
+			expect: true,
+		},
+		{
+			name: "removed-group",
+			rules: []*proxmox.FirewallRule{
+				{Pos: 0, Type: "group", Action: "kubernetes", Iface: "net0", Enable: 1},
+			},
+			expect: false,
+		},
+		{
+			name: "disabled-group",
+			rules: []*proxmox.FirewallRule{
+				{Pos: 0, Type: "group", Action: "kubernetes", Iface: "net0", Enable: 1},
+				{Pos: 1, Type: "group", Action: "monitoring", Iface: "net0"},
+			},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules := slices.Clone(tt.rules)
+
+			assert.Equal(t, tt.expect, firewallRulesEqual(groups, tt.rules))
+			assert.Equal(t, rules, tt.rules)
+		})
+	}
+}
+
+func TestInstanceDriftCache(t *testing.T) {
+	p := &DefaultProvider{
+		drift: map[string]cachedInstanceDrift{},
+	}
+
+	_, ok := p.cachedInstanceDrift("node-1", "key-1")
+	assert.False(t, ok)
+
+	p.cacheInstanceDrift("node-1", "key-1", &InstanceDrift{InPlace: []string{InstanceDriftFieldTags}})
+
+	drift, ok := p.cachedInstanceDrift("node-1", "key-1")
+	assert.True(t, ok)
+	assert.Equal(t, &InstanceDrift{InPlace: []string{InstanceDriftFieldTags}}, drift)
+
+	// The NodeClaim or NodeClass was changed
+	_, ok = p.cachedInstanceDrift("node-1", "key-2")
+	assert.False(t, ok)
+
+	// The expired drift is removed on the next update
+	p.drift["node-2"] = cachedInstanceDrift{key: "key-1", drift: &InstanceDrift{}, expires: time.Now().Add(-time.Second)}
+
+	_, ok = p.cachedInstanceDrift("node-2", "key-1")
+	assert.False(t, ok)
+
+	p.cacheInstanceDrift("node-3", "key-1", &InstanceDrift{})
+	assert.NotContains(t, p.drift, "node-2")
+
+	p.forgetInstanceDrift("node-1")
+	assert.NotContains(t, p.drift, "node-1")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+
+	goproxmox "github.com/sergelogvinov/go-proxmox"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
+	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
+	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
+
+	corev1 "k8s.io/api/core/v1"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// InstanceIdentity is the virtual machine identity, it is used to verify the node certificate requests.
+type InstanceIdentity struct {
+	// UUID is the SMBIOS UUID of the virtual machine
+	UUID string
+	// Addresses is the list of the static IP addresses of the virtual machine
+	Addresses []string
+}
+
+// GetInstanceIdentity returns the identity of the virtual machine of the NodeClaim.
+func (p *DefaultProvider) GetInstanceIdentity(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*InstanceIdentity, error) {
+	vmid, region, err := provider.ParseProviderID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vm id from provider-id: %v", err)
+	}
+
+	if region == "" {
+		region = nodeClaim.Labels[corev1.LabelTopologyRegion]
+	}
+
+	px, err := p.cluster.GetProxmoxCluster(region)
+	if err != nil {
+		return nil, pxpool.ErrRegionNotFound
+	}
+
+	vm, err := px.GetVMConfig(ctx, vmid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vm config for vm %d: %w", vmid, err)
+	}
+
+	return &InstanceIdentity{
+		UUID:      goproxmox.GetVMUUID(vm),
+		Addresses: instanceAddresses(vm.VirtualMachineConfig),
+	}, nil
+}
+
+// instanceAddresses returns the static IP addresses of the virtual machine without the prefix length.
+func instanceAddresses(config *proxmox.VirtualMachineConfig) []string {
+	addresses := []string{}
+
+	networkValues := cloudinit.GetNetworkConfigFromVirtualMachineConfig(config, nil)
+	for _, iface := range networkValues.Interfaces {
+		for _, cidr := range append(iface.Address4, iface.Address6...) {
+			addresses = append(addresses, strings.Split(cidr, "/")[0])
+		}
+	}
+
+	return addresses
+}
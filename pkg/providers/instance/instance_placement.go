@@ -17,27 +17,42 @@ limitations under the License.
 package instance
 
 import (
+	"context"
 	"math"
 	"math/rand/v2"
+	"slices"
 	"sort"
+	"strconv"
 
 	"github.com/samber/lo"
 
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
-func (p *DefaultProvider) sortBestZoneByPlacementStrategy(placementStrategy *v1alpha1.PlacementStrategy, region string, zones []string) []string {
+const (
+	defaultZoneWeight = 100
+)
+
+func (p *DefaultProvider) sortBestZoneByPlacementStrategy(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	region string,
+	zones []string,
+) []string {
 	if len(zones) == 1 {
 		return zones
 	}
 
-	strategy := placementStrategy
+	strategy := nodeClass.Spec.PlacementStrategy
 	if strategy == nil {
 		strategy = &v1alpha1.PlacementStrategy{
 			ZoneBalance: v1alpha1.PlacementStrategyBalanced,
@@ -46,6 +61,10 @@ func (p *DefaultProvider) sortBestZoneByPlacementStrategy(placementStrategy *v1a
 
 	switch strategy.ZoneBalance {
 	case v1alpha1.PlacementStrategyAvailabilityFirst:
+		if len(strategy.ZoneWeights) > 0 {
+			return shuffleZonesByWeight(zones, strategy.ZoneWeights)
+		}
+
 		// Sort zones randomly to prioritize availability
 		sortedZones := make([]string, len(zones))
 		for i, v := range rand.Perm(len(zones)) {
@@ -53,10 +72,136 @@ func (p *DefaultProvider) sortBestZoneByPlacementStrategy(placementStrategy *v1a
 		}
 
 		return sortedZones
+	case v1alpha1.PlacementStrategyScored:
+		return p.sortZonesByScore(ctx, nodeClaim, nodeClass, region, zones)
 	default:
 		// Sort zones by CPU load
-		return p.cloudCapacityProvider.SortZonesByCPULoad(region, zones)
+		sortedZones := p.cloudCapacityProvider.SortZonesByCPULoad(region, zones)
+		if len(strategy.ZoneWeights) == 0 {
+			return sortedZones
+		}
+
+		loads := make(map[string]int, len(sortedZones))
+		for _, zone := range sortedZones {
+			if info := p.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil {
+				loads[zone] = info.CPULoad
+			}
+		}
+
+		return sortZonesByWeightedLoad(sortedZones, loads, strategy.ZoneWeights)
+	}
+}
+
+// spreadZones removes or moves to the end the zones which exceed the max skew of the zone spread.
+func (p *DefaultProvider) spreadZones(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	region string,
+	zones []string,
+) []string {
+	if nodeClass.Spec.PlacementStrategy == nil || nodeClass.Spec.PlacementStrategy.ZoneSpread == nil || len(zones) == 0 {
+		return zones
+	}
+
+	spread := nodeClass.Spec.PlacementStrategy.ZoneSpread
+
+	selector := zoneSpreadSelector(nodeClaim, spread)
+	if selector == nil {
+		return zones
+	}
+
+	counts, err := p.getZoneNodeCounts(ctx, region, selector)
+	if err != nil {
+		log.FromContext(ctx).WithName("instance.spreadZones()").Error(err, "Failed to get the number of nodes in zones", "region", region)
+
+		return zones
+	}
+
+	return spreadZonesByCount(zones, nodeClass.GetZones(region), counts, int(max(spread.MaxSkew, 1)), spread.WhenUnsatisfiable)
+}
+
+// zoneSpreadSelector returns the node selector of the group the NodeClaim belongs to.
+func zoneSpreadSelector(nodeClaim *karpv1.NodeClaim, spread *v1alpha1.ZoneSpread) labels.Selector {
+	keys := spread.LabelKeys
+	if len(keys) == 0 {
+		keys = []string{karpv1.NodePoolLabelKey}
+	}
+
+	set := labels.Set{}
+
+	for _, key := range keys {
+		if value, ok := nodeClaim.Labels[key]; ok {
+			set[key] = value
+		}
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+
+	return labels.SelectorFromSet(set)
+}
+
+// spreadZonesByCount keeps the zone order and filters out the zones where one more node
+// exceeds the max skew. The skew is calculated against all domains (zones of the node class).
+func spreadZonesByCount(zones, domains []string, counts map[string]int, maxSkew int, whenUnsatisfiable string) []string {
+	domains = lo.Uniq(append(slices.Clone(domains), zones...))
+
+	minCount := lo.Min(lo.Map(domains, func(zone string, _ int) int { return counts[zone] }))
+
+	allowed, skewed := lo.FilterReject(zones, func(zone string, _ int) bool {
+		return counts[zone]+1-minCount <= maxSkew
+	})
+
+	if whenUnsatisfiable == v1alpha1.ZoneSpreadScheduleAnyway {
+		sort.SliceStable(skewed, func(i, j int) bool {
+			return counts[skewed[i]] < counts[skewed[j]]
+		})
+
+		return append(allowed, skewed...)
 	}
+
+	return allowed
+}
+
+func zoneWeight(weights map[string]int32, zone string) float64 {
+	if w, ok := weights[zone]; ok && w > 0 {
+		return float64(w)
+	}
+
+	return defaultZoneWeight
+}
+
+// sortZonesByWeightedLoad sorts zones by the CPU load divided by the zone weight.
+func sortZonesByWeightedLoad(zones []string, loads map[string]int, weights map[string]int32) []string {
+	sortedZones := slices.Clone(zones)
+
+	sort.SliceStable(sortedZones, func(i, j int) bool {
+		// +1 to prefer the zone with a higher weight if both zones are idle
+		iScore := float64(loads[sortedZones[i]]+1) / zoneWeight(weights, sortedZones[i])
+		jScore := float64(loads[sortedZones[j]]+1) / zoneWeight(weights, sortedZones[j])
+
+		return iScore < jScore
+	})
+
+	return sortedZones
+}
+
+// shuffleZonesByWeight returns a random order of zones, where zones with a higher weight
+// have a higher chance to be first (weighted random sampling without replacement).
+func shuffleZonesByWeight(zones []string, weights map[string]int32) []string {
+	keys := make(map[string]float64, len(zones))
+	for _, zone := range zones {
+		keys[zone] = math.Pow(rand.Float64(), 1/zoneWeight(weights, zone))
+	}
+
+	sortedZones := slices.Clone(zones)
+	sort.SliceStable(sortedZones, func(i, j int) bool {
+		return keys[sortedZones[i]] > keys[sortedZones[j]]
+	})
+
+	return sortedZones
 }
 
 func orderInstanceTypesByPrice(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements) []*cloudprovider.InstanceType {
@@ -83,6 +228,82 @@ func orderInstanceTypesByPrice(instanceTypes []*cloudprovider.InstanceType, requ
 	return instanceTypes
 }
 
+// filterZonesByArchitecture returns the zones with the CPU architecture requested by the NodeClaim.
+func (p *DefaultProvider) filterZonesByArchitecture(nodeClaim *karpv1.NodeClaim, region string, zones []string) []string {
+	archs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(corev1.LabelArchStable)
+
+	return lo.Filter(zones, func(zone string, _ int) bool {
+		return archs.Has(p.zoneArchitecture(region, zone))
+	})
+}
+
+// filterZonesByCPUType returns the zones which can run at least one CPU type requested by the NodeClaim.
+func (p *DefaultProvider) filterZonesByCPUType(nodeClaim *karpv1.NodeClaim, region string, zones []string) []string {
+	cpuTypes := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1alpha1.LabelInstanceCPUType)
+
+	return lo.Filter(zones, func(zone string, _ int) bool {
+		info := p.cloudCapacityProvider.GetZoneInfo(region, zone)
+		if info == nil || len(info.CPUTypes) == 0 {
+			return true
+		}
+
+		return lo.ContainsBy(info.CPUTypes, cpuTypes.Has)
+	})
+}
+
+// filterZonesByNUMANodes returns the zones which can bind the VM to the number of host NUMA nodes requested by the NodeClaim.
+func (p *DefaultProvider) filterZonesByNUMANodes(nodeClaim *karpv1.NodeClaim, region string, zones []string) []string {
+	minNodes, maxNodes := numaNodesRange(scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...))
+	if minNodes == 0 && maxNodes == 0 {
+		return zones
+	}
+
+	return lo.Filter(zones, func(zone string, _ int) bool {
+		info := p.cloudCapacityProvider.GetZoneInfo(region, zone)
+
+		return info != nil && info.NUMANodes > 0 && info.NUMANodes >= minNodes
+	})
+}
+
+// numaNodesRange returns the minimum and maximum number of host NUMA nodes requested by the NUMA labels,
+// zero means no limit.
+func numaNodesRange(requirements scheduling.Requirements) (int, int) {
+	minNodes, maxNodes := 0, 0
+
+	aligned := requirements.Get(v1alpha1.LabelInstanceNUMAAligned)
+	switch {
+	case aligned.Has("true") && !aligned.Has("false"):
+		maxNodes = 1
+	case aligned.Has("false") && !aligned.Has("true"):
+		minNodes = 2
+	}
+
+	nodes := requirements.Get(v1alpha1.LabelInstanceNUMANodes)
+	if nodes.Operator() == corev1.NodeSelectorOpIn {
+		values := lo.FilterMap(nodes.Values(), func(v string, _ int) (int, bool) {
+			n, err := strconv.Atoi(v)
+
+			return n, err == nil && n > 0
+		})
+
+		if len(values) > 0 {
+			minNodes = max(minNodes, lo.Min(values))
+			maxNodes = lo.Ternary(maxNodes == 0, lo.Max(values), min(maxNodes, lo.Max(values)))
+		}
+	}
+
+	return minNodes, maxNodes
+}
+
+// zoneArchitecture returns the CPU architecture of the zone, amd64 if the zone is unknown.
+func (p *DefaultProvider) zoneArchitecture(region, zone string) string {
+	if info := p.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil && info.Architecture != "" {
+		return info.Architecture
+	}
+
+	return karpv1.ArchitectureAmd64
+}
+
 func getValuesByKey(instanceType *cloudprovider.InstanceType, key string, defaults []string) []string {
 	requestedKey := instanceType.Offerings.Available()
 	if len(defaults) != 0 {
@@ -0,0 +1,277 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var defaultPlacementScoreWeights = v1alpha1.PlacementScoreWeights{
+	Memory:     lo.ToPtr[int32](40),
+	HostMemory: lo.ToPtr[int32](20),
+	HostCPU:    lo.ToPtr[int32](20),
+	Storage:    lo.ToPtr[int32](10),
+	NodePool:   lo.ToPtr[int32](10),
+}
+
+// neutralZoneScore is the score of the zone with unknown usage.
+const neutralZoneScore = 0.5
+
+// zoneSignals are the raw placement signals of the zone.
+type zoneSignals struct {
+	Zone string
+	// AvailableMemory is the memory in bytes which can be allocated for new VMs.
+	AvailableMemory uint64
+	// CPULoad is the host CPU usage in percentage.
+	CPULoad int
+	// MemoryUsage is the host memory usage in percentage.
+	MemoryUsage int
+	// StorageFree is the free space ratio of the boot device storage, 1 if unknown.
+	StorageFree float64
+	// Nodes is the number of nodes of the same NodePool in the zone.
+	Nodes int
+	// Unknown is true if the usage of the zone is unknown, the zone gets the neutral score and is ordered last.
+	Unknown bool
+
+	// Score is the weighted score of the zone, higher is better.
+	Score float64
+}
+
+func (p *DefaultProvider) sortZonesByScore(
+	ctx context.Context,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1alpha1.ProxmoxNodeClass,
+	region string,
+	zones []string,
+) []string {
+	log := log.FromContext(ctx).WithName("instance.sortZonesByScore()")
+
+	nodeCounts, err := p.getNodePoolZoneCounts(ctx, nodeClaim, region)
+	if err != nil {
+		log.Error(err, "Failed to get the number of nodes in zones", "region", region)
+	}
+
+	storageID := ""
+	if nodeClass.Spec.BootDevice != nil {
+		storageID = nodeClass.Spec.BootDevice.Storage
+	}
+
+	signals := make([]zoneSignals, 0, len(zones))
+
+	for _, zone := range zones {
+		usage := p.cloudCapacityProvider.GetZoneUsage(region, zone)
+		if usage == nil {
+			signals = append(signals, zoneSignals{Zone: zone, Unknown: true})
+
+			continue
+		}
+
+		s := zoneSignals{
+			Zone:            zone,
+			AvailableMemory: usage.AvailableMemory,
+			CPULoad:         usage.CPULoad,
+			MemoryUsage:     usage.MemoryUsage,
+			StorageFree:     1,
+			Nodes:           nodeCounts[zone],
+		}
+
+		if storageID != "" {
+			if storage := p.cloudCapacityProvider.GetStorageInZone(region, zone, storageID); storage != nil && storage.Size > 0 {
+				s.StorageFree = float64(storage.Size-min(storage.Used, storage.Size)) / float64(storage.Size)
+			}
+		}
+
+		signals = append(signals, s)
+	}
+
+	var (
+		weights     *v1alpha1.PlacementScoreWeights
+		zoneWeights map[string]int32
+	)
+
+	if nodeClass.Spec.PlacementStrategy != nil {
+		weights = nodeClass.Spec.PlacementStrategy.ScoreWeights
+		zoneWeights = nodeClass.Spec.PlacementStrategy.ZoneWeights
+	}
+
+	signals = scoreZones(signals, weights, zoneWeights)
+
+	log.V(4).Info("Zones scored", "region", region, "zones", lo.Map(signals, func(s zoneSignals, _ int) string {
+		return fmt.Sprintf("%s=%.3f", s.Zone, s.Score)
+	}))
+
+	return lo.Map(signals, func(s zoneSignals, _ int) string { return s.Zone })
+}
+
+// scoreZones calculates the zone scores and sorts the zones by the score, the best zone is first.
+// Every signal is normalized to 0..1 and the score is the weighted average of the signals
+// multiplied by the relative zone weight. The zones with unknown usage are ordered last.
+func scoreZones(signals []zoneSignals, weights *v1alpha1.PlacementScoreWeights, zoneWeights map[string]int32) []zoneSignals {
+	w := defaultPlacementScoreWeights
+	if weights != nil {
+		w.Memory = lo.CoalesceOrEmpty(weights.Memory, w.Memory)
+		w.HostMemory = lo.CoalesceOrEmpty(weights.HostMemory, w.HostMemory)
+		w.HostCPU = lo.CoalesceOrEmpty(weights.HostCPU, w.HostCPU)
+		w.Storage = lo.CoalesceOrEmpty(weights.Storage, w.Storage)
+		w.NodePool = lo.CoalesceOrEmpty(weights.NodePool, w.NodePool)
+	}
+
+	wMemory := float64(*w.Memory)
+	wHostMemory := float64(*w.HostMemory)
+	wHostCPU := float64(*w.HostCPU)
+	wStorage := float64(*w.Storage)
+	wNodePool := float64(*w.NodePool)
+
+	total := wMemory + wHostMemory + wHostCPU + wStorage + wNodePool
+
+	var (
+		maxMemory uint64
+		maxNodes  int
+	)
+
+	for _, s := range signals {
+		if s.Unknown {
+			continue
+		}
+
+		maxMemory = max(maxMemory, s.AvailableMemory)
+		maxNodes = max(maxNodes, s.Nodes)
+	}
+
+	res := make([]zoneSignals, len(signals))
+
+	for i, s := range signals {
+		if s.Unknown {
+			s.Score = neutralZoneScore * zoneWeight(zoneWeights, s.Zone) / defaultZoneWeight
+			res[i] = s
+
+			continue
+		}
+
+		memory := 1.0
+		if maxMemory > 0 {
+			memory = float64(s.AvailableMemory) / float64(maxMemory)
+		}
+
+		nodes := 1.0
+		if maxNodes > 0 {
+			nodes = 1 - float64(s.Nodes)/float64(maxNodes)
+		}
+
+		if total > 0 {
+			s.Score = (wMemory*memory +
+				wHostMemory*usageScore(s.MemoryUsage) +
+				wHostCPU*usageScore(s.CPULoad) +
+				wStorage*s.StorageFree +
+				wNodePool*nodes) / total
+		}
+
+		s.Score *= zoneWeight(zoneWeights, s.Zone) / defaultZoneWeight
+		res[i] = s
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Unknown != res[j].Unknown {
+			return !res[i].Unknown
+		}
+
+		return res[i].Score > res[j].Score
+	})
+
+	return res
+}
+
+// usageScore converts the usage in percentage to the free ratio.
+func usageScore(usage int) float64 {
+	return 1 - float64(min(max(usage, 0), 100))/100
+}
+
+// getNodePoolZoneCounts returns the number of nodes of the NodeClaim's NodePool in each zone of the region.
+func (p *DefaultProvider) getNodePoolZoneCounts(ctx context.Context, nodeClaim *karpv1.NodeClaim, region string) (map[string]int, error) {
+	nodePool := nodeClaim.Labels[karpv1.NodePoolLabelKey]
+	if nodePool == "" {
+		return map[string]int{}, nil
+	}
+
+	return p.getZoneNodeCounts(ctx, region, labels.SelectorFromSet(labels.Set{karpv1.NodePoolLabelKey: nodePool}))
+}
+
+// launchedZone is the zone of a launched NodeClaim.
+type launchedZone struct {
+	region string
+	zone   string
+}
+
+// trackLaunchedZone counts the launched NodeClaim in the zone until the NodeClaim gets the zone label.
+func (p *DefaultProvider) trackLaunchedZone(name, region, zone string) {
+	p.muLaunched.Lock()
+	defer p.muLaunched.Unlock()
+
+	p.launched[name] = launchedZone{region: region, zone: zone}
+}
+
+func (p *DefaultProvider) untrackLaunchedZone(name string) {
+	p.muLaunched.Lock()
+	defer p.muLaunched.Unlock()
+
+	delete(p.launched, name)
+}
+
+// getZoneNodeCounts returns the number of NodeClaims matching the selector in each zone of the region.
+// The NodeClaims are read from the cache, the launched NodeClaims are counted before their nodes register.
+func (p *DefaultProvider) getZoneNodeCounts(ctx context.Context, region string, selector labels.Selector) (map[string]int, error) {
+	counts := map[string]int{}
+
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := p.kubeClient.List(ctx, nodeClaims, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return counts, fmt.Errorf("failed to list nodeclaims: %w", err)
+	}
+
+	p.muLaunched.Lock()
+	defer p.muLaunched.Unlock()
+
+	for _, nodeClaim := range nodeClaims.Items {
+		nodeRegion, zone := nodeClaim.Labels[corev1.LabelTopologyRegion], nodeClaim.Labels[corev1.LabelTopologyZone]
+
+		if l, ok := p.launched[nodeClaim.Name]; ok {
+			if zone != "" {
+				delete(p.launched, nodeClaim.Name)
+			} else {
+				nodeRegion, zone = l.region, l.zone
+			}
+		}
+
+		if nodeRegion == region && zone != "" {
+			counts[zone]++
+		}
+	}
+
+	return counts, nil
+}
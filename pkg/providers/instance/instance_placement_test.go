@@ -19,10 +19,16 @@ package instance
 import (
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -303,3 +309,297 @@ func TestGetCapacityType(t *testing.T) {
 		assert.Equal(t, tt.expect, res, tt.name)
 	}
 }
+
+func TestSortZonesByWeightedLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		zones   []string
+		loads   map[string]int
+		weights map[string]int32
+		expect  []string
+	}{
+		{
+			name:   "no-weights",
+			zones:  []string{"pve-1", "pve-2", "pve-3"},
+			loads:  map[string]int{"pve-1": 10, "pve-2": 30, "pve-3": 20},
+			expect: []string{"pve-1", "pve-3", "pve-2"},
+		},
+		{
+			name:    "weighted",
+			zones:   []string{"pve-1", "pve-2", "pve-3"},
+			loads:   map[string]int{"pve-1": 10, "pve-2": 30, "pve-3": 20},
+			weights: map[string]int32{"pve-2": 400},
+			expect:  []string{"pve-2", "pve-1", "pve-3"},
+		},
+		{
+			name:    "idle-zones",
+			zones:   []string{"pve-1", "pve-2", "pve-3"},
+			weights: map[string]int32{"pve-1": 50, "pve-3": 200},
+			expect:  []string{"pve-3", "pve-2", "pve-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expect, sortZonesByWeightedLoad(tt.zones, tt.loads, tt.weights))
+		})
+	}
+}
+
+func TestShuffleZonesByWeight(t *testing.T) {
+	zones := []string{"pve-1", "pve-2", "pve-3"}
+	weights := map[string]int32{"pve-1": 1, "pve-2": 1, "pve-3": 1000}
+
+	first := map[string]int{}
+
+	for range 1000 {
+		res := shuffleZonesByWeight(zones, weights)
+
+		assert.ElementsMatch(t, zones, res)
+		first[res[0]]++
+	}
+
+	assert.Greater(t, first["pve-3"], 900)
+}
+
+func TestScoreZones(t *testing.T) {
+	signals := []zoneSignals{
+		{Zone: "pve-1", AvailableMemory: 8 << 30, CPULoad: 10, MemoryUsage: 80, StorageFree: 0.5, Nodes: 2},
+		{Zone: "pve-2", AvailableMemory: 32 << 30, CPULoad: 50, MemoryUsage: 40, StorageFree: 0.5, Nodes: 2},
+		{Zone: "pve-3", AvailableMemory: 16 << 30, CPULoad: 10, MemoryUsage: 50, StorageFree: 0.9},
+	}
+
+	tests := []struct {
+		name        string
+		weights     *v1alpha1.PlacementScoreWeights
+		zoneWeights map[string]int32
+		expect      []string
+	}{
+		{
+			name:   "default-weights",
+			expect: []string{"pve-2", "pve-3", "pve-1"},
+		},
+		{
+			name: "cpu-only",
+			weights: &v1alpha1.PlacementScoreWeights{
+				Memory:     lo.ToPtr[int32](0),
+				HostMemory: lo.ToPtr[int32](0),
+				HostCPU:    lo.ToPtr[int32](100),
+				Storage:    lo.ToPtr[int32](0),
+				NodePool:   lo.ToPtr[int32](0),
+			},
+			expect: []string{"pve-1", "pve-3", "pve-2"},
+		},
+		{
+			name: "nodepool-spread",
+			weights: &v1alpha1.PlacementScoreWeights{
+				Memory:     lo.ToPtr[int32](0),
+				HostMemory: lo.ToPtr[int32](0),
+				HostCPU:    lo.ToPtr[int32](0),
+				Storage:    lo.ToPtr[int32](0),
+				NodePool:   lo.ToPtr[int32](100),
+			},
+			expect: []string{"pve-3", "pve-1", "pve-2"},
+		},
+		{
+			name:        "zone-weights",
+			zoneWeights: map[string]int32{"pve-1": 1000},
+			expect:      []string{"pve-1", "pve-2", "pve-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := scoreZones(signals, tt.weights, tt.zoneWeights)
+
+			assert.Equal(t, tt.expect, lo.Map(res, func(s zoneSignals, _ int) string { return s.Zone }))
+		})
+	}
+}
+
+func TestScoreZonesUnknownUsage(t *testing.T) {
+	signals := []zoneSignals{
+		{Zone: "pve-1", Unknown: true},
+		{Zone: "pve-2", AvailableMemory: 8 << 30, CPULoad: 90, MemoryUsage: 90, StorageFree: 0.1, Nodes: 4},
+		{Zone: "pve-3", AvailableMemory: 32 << 30, CPULoad: 10, MemoryUsage: 10, StorageFree: 0.9},
+	}
+
+	res := scoreZones(signals, nil, map[string]int32{"pve-1": 1000})
+
+	assert.Equal(t, []string{"pve-3", "pve-2", "pve-1"}, lo.Map(res, func(s zoneSignals, _ int) string { return s.Zone }))
+	assert.Greater(t, res[2].Score, 0.0)
+}
+
+func TestNUMANodesRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements scheduling.Requirements
+		minNodes     int
+		maxNodes     int
+	}{
+		{
+			name:         "no-requirements",
+			requirements: scheduling.NewRequirements(),
+		},
+		{
+			name: "any-alignment",
+			requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMAAligned, corev1.NodeSelectorOpIn, "true", "false"),
+			),
+		},
+		{
+			name: "aligned",
+			requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMAAligned, corev1.NodeSelectorOpIn, "true"),
+			),
+			maxNodes: 1,
+		},
+		{
+			name: "not-aligned",
+			requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMAAligned, corev1.NodeSelectorOpIn, "false"),
+			),
+			minNodes: 2,
+		},
+		{
+			name: "nodes",
+			requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMANodes, corev1.NodeSelectorOpIn, "2", "4"),
+			),
+			minNodes: 2,
+			maxNodes: 4,
+		},
+		{
+			name: "aligned-and-nodes",
+			requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMAAligned, corev1.NodeSelectorOpIn, "true"),
+				scheduling.NewRequirement(v1alpha1.LabelInstanceNUMANodes, corev1.NodeSelectorOpIn, "1", "2"),
+			),
+			minNodes: 1,
+			maxNodes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			minNodes, maxNodes := numaNodesRange(tt.requirements)
+
+			assert.Equal(t, tt.minNodes, minNodes)
+			assert.Equal(t, tt.maxNodes, maxNodes)
+		})
+	}
+}
+
+func TestSpreadZonesByCount(t *testing.T) {
+	domains := []string{"pve-1", "pve-2", "pve-3"}
+
+	tests := []struct {
+		name              string
+		zones             []string
+		counts            map[string]int
+		maxSkew           int
+		whenUnsatisfiable string
+		expect            []string
+	}{
+		{
+			name:    "empty",
+			zones:   []string{"pve-3", "pve-1", "pve-2"},
+			maxSkew: 1,
+			expect:  []string{"pve-3", "pve-1", "pve-2"},
+		},
+		{
+			name:    "skewed",
+			zones:   []string{"pve-3", "pve-1", "pve-2"},
+			counts:  map[string]int{"pve-1": 1, "pve-3": 1},
+			maxSkew: 1,
+			expect:  []string{"pve-2"},
+		},
+		{
+			name:    "max-skew-2",
+			zones:   []string{"pve-3", "pve-1", "pve-2"},
+			counts:  map[string]int{"pve-1": 2, "pve-3": 1},
+			maxSkew: 2,
+			expect:  []string{"pve-3", "pve-2"},
+		},
+		{
+			name:    "domain-without-capacity",
+			zones:   []string{"pve-1", "pve-2"},
+			counts:  map[string]int{"pve-1": 1, "pve-2": 1},
+			maxSkew: 1,
+			expect:  []string{},
+		},
+		{
+			name:              "schedule-anyway",
+			zones:             []string{"pve-1", "pve-2"},
+			counts:            map[string]int{"pve-1": 2, "pve-2": 1},
+			maxSkew:           1,
+			whenUnsatisfiable: v1alpha1.ZoneSpreadScheduleAnyway,
+			expect:            []string{"pve-2", "pve-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expect, spreadZonesByCount(tt.zones, domains, tt.counts, tt.maxSkew, tt.whenUnsatisfiable))
+		})
+	}
+}
+
+func TestGetZoneNodeCounts(t *testing.T) {
+	nodeClaim := func(name, nodePool, region, zone string) *karpv1.NodeClaim {
+		nc := &karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool},
+			},
+		}
+
+		if region != "" {
+			nc.Labels[corev1.LabelTopologyRegion] = region
+			nc.Labels[corev1.LabelTopologyZone] = zone
+		}
+
+		return nc
+	}
+
+	p := &DefaultProvider{
+		kubeClient: fake.NewClientBuilder().WithObjects(
+			nodeClaim("node-1", "default", "region-1", "pve-1"),
+			nodeClaim("node-2", "default", "region-1", "pve-1"),
+			nodeClaim("node-3", "default", "region-2", "pve-1"),
+			nodeClaim("node-4", "other", "region-1", "pve-2"),
+			nodeClaim("node-5", "default", "", ""),
+			nodeClaim("node-6", "default", "", ""),
+		).Build(),
+		launched: map[string]launchedZone{},
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{karpv1.NodePoolLabelKey: "default"})
+
+	counts, err := p.getZoneNodeCounts(t.Context(), "region-1", selector)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"pve-1": 2}, counts)
+
+	// The launched NodeClaim is counted before it gets the zone label
+	p.trackLaunchedZone("node-5", "region-1", "pve-2")
+	p.trackLaunchedZone("node-1", "region-1", "pve-3")
+
+	counts, err = p.getZoneNodeCounts(t.Context(), "region-1", selector)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"pve-1": 2, "pve-2": 1}, counts)
+	assert.Equal(t, map[string]launchedZone{"node-5": {region: "region-1", zone: "pve-2"}}, p.launched)
+
+	p.untrackLaunchedZone("node-5")
+
+	counts, err = p.getZoneNodeCounts(t.Context(), "region-1", selector)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"pve-1": 2}, counts)
+}
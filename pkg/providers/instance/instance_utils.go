@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/luthermonson/go-proxmox"
@@ -27,12 +28,14 @@ import (
 	goproxmox "github.com/sergelogvinov/go-proxmox"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/operator/options"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/cloudinit"
 	provider "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instance/provider"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/instancetemplate"
 	pxpool "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/proxmoxpool"
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources"
 	vmresources "github.com/sergelogvinov/karpenter-provider-proxmox/pkg/proxmox/resources/vm"
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/nodesettings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -41,6 +44,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
 func (p *DefaultProvider) instanceCreate(ctx context.Context,
@@ -81,13 +85,11 @@ func (p *DefaultProvider) instanceCreate(ctx context.Context,
 	// Scheduling uses StorageEphemeral capacity to determine the InstanceType
 	size := max(nodeClass.Spec.BootDevice.Size.ScaledValue(resource.Giga), instanceType.Capacity.StorageEphemeral().ScaledValue(resource.Giga))
 
-	opt := &resources.VMResources{
-		ID:         newID,
-		CPUs:       int(instanceType.Capacity.Cpu().Value()),
-		Memory:     uint64(instanceType.Capacity.Memory().Value()),
-		DiskGBytes: uint64(size),
-		StorageID:  storage,
-	}
+	opt := cloudcapacity.NodeClassResources(instanceType.Capacity, nodeClass)
+	opt.ID = newID
+	opt.DiskGBytes = uint64(size)
+	opt.StorageID = storage
+	opt.MinNUMANodes, opt.MaxNUMANodes = numaNodesRange(scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...))
 
 	if err := p.cloudCapacityProvider.AllocateCapacityInZone(ctx, region, zone, newID, opt); err != nil {
 		return nil, fmt.Errorf("failed to reserve capacity: %v", err)
@@ -135,6 +137,18 @@ func (p *DefaultProvider) instanceCreate(ctx context.Context,
 			if defErr := px.DeleteVMByID(ctx, zone, newID); defErr != nil && !errors.Is(defErr, goproxmox.ErrVirtualMachineNotFound) {
 				log.Error(defErr, "failed to delete vm", "vmID", newID)
 			}
+
+			if defErr := removeCloudInitSnippets(ctx, newID); defErr != nil {
+				log.Error(defErr, "failed to remove cloud-init snippets", "vmID", newID)
+			}
+
+			if defErr := p.metadataProvider.Delete(ctx, nodeClaim.Name); defErr != nil {
+				log.Error(defErr, "failed to delete instance metadata", "vmID", newID)
+			}
+
+			if defErr := p.kubernetesBootstrapProvider.DeleteNodeClaimTokens(ctx, nodeClaim.Name); defErr != nil {
+				log.Error(defErr, "failed to revoke bootstrap tokens", "vmID", newID)
+			}
 		}
 	}()
 
@@ -143,6 +157,28 @@ func (p *DefaultProvider) instanceCreate(ctx context.Context,
 		return nil, fmt.Errorf("failed to clone vm template %d: %v", vmTemplateID, err)
 	}
 
+	if opt.MinMemory > 0 {
+		err = px.UpdateVMByID(ctx, zone, newID, map[string]any{"balloon": opt.MinMemory / 1024 / 1024})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure memory balloon for vm %d: %v", newID, err)
+		}
+	}
+
+	// The page size is resolved by the allocation, it matches the hugepages reserved on the host
+	if opt.HugePageSize > 0 {
+		err = px.UpdateVMByID(ctx, zone, newID, map[string]any{"numa": 1, "hugepages": strconv.FormatUint(opt.HugePageSize, 10)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure hugepages for vm %d: %v", newID, err)
+		}
+	}
+
+	if cpu := p.instanceCPU(nodeClaim, instanceTemplate, region, zone); cpu != "" {
+		err = px.UpdateVMByID(ctx, zone, newID, map[string]any{"cpu": cpu})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure cpu type for vm %d: %v", newID, err)
+		}
+	}
+
 	err = p.instanceNetworkSetup(ctx, region, zone, newID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to configure networking for vm %d: %v", newID, err)
@@ -166,11 +202,27 @@ func (p *DefaultProvider) instanceCreate(ctx context.Context,
 		}
 	}
 
-	if nodeClass.Spec.MetadataOptions.Type == "cdrom" {
+	switch nodeClass.Spec.MetadataOptions.Type {
+	case v1alpha1.MetadataOptionsTypeCDRom:
 		err = p.attachCloudInitISO(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, newID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to attach cloud-init ISO to vm %d: %v", newID, err)
 		}
+	case v1alpha1.MetadataOptionsTypeSnippets:
+		err = p.attachCloudInitSnippets(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, newID, storage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach cloud-init snippets to vm %d: %v", newID, err)
+		}
+	case v1alpha1.MetadataOptionsTypeFWCfg:
+		err = p.attachIgnitionFWCfg(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, newID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach ignition config to vm %d: %v", newID, err)
+		}
+	case v1alpha1.MetadataOptionsTypeHTTP:
+		err = p.publishCloudInitMetadata(ctx, nodeClaim, nodeClass, instanceTemplate, instanceType, region, zone, newID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish cloud-init metadata of vm %d: %v", newID, err)
+		}
 	}
 
 	log.V(1).Info("Starting VM", "vmID", newID)
@@ -205,15 +257,61 @@ func (p *DefaultProvider) instanceCreate(ctx context.Context,
 		},
 		Status: corev1.NodeStatus{
 			NodeInfo: corev1.NodeSystemInfo{
-				Architecture:    karpv1.ArchitectureAmd64,
+				Architecture:    p.zoneArchitecture(region, zone),
 				OperatingSystem: string(corev1.Linux),
 			},
 		},
 	}
 
+	if len(opt.NUMANodes) > 0 {
+		node.Labels[v1alpha1.LabelInstanceNUMANodes] = strconv.Itoa(len(opt.NUMANodes))
+		node.Labels[v1alpha1.LabelInstanceNUMAAligned] = strconv.FormatBool(len(opt.NUMANodes) == 1)
+	}
+
 	return node, nil
 }
 
+// instanceCPU returns the cpu option of the VM if the CPU type of the template does not satisfy
+// the NodeClaim requirements, the template CPU flags are kept.
+func (p *DefaultProvider) instanceCPU(
+	nodeClaim *karpv1.NodeClaim,
+	instanceTemplate *instancetemplate.InstanceTemplateInfo,
+	region string,
+	zone string,
+) string {
+	cpuTypes := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1alpha1.LabelInstanceCPUType)
+	if cpuTypes.Operator() != corev1.NodeSelectorOpIn {
+		return ""
+	}
+
+	cpu := goproxmox.VMCPU{}
+	if err := cpu.UnmarshalString(instanceTemplate.TemplateCPU); err != nil || cpu.Type == "" {
+		// Proxmox uses kvm64 if the CPU type is not set
+		cpu.Type = "kvm64"
+	}
+
+	if cpuTypes.Has(cpu.Type) {
+		return ""
+	}
+
+	zoneTypes := cpuTypes.Values()
+	if info := p.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil && len(info.CPUTypes) > 0 {
+		zoneTypes = info.CPUTypes
+	}
+
+	cpu.Type = nodesettings.PreferredCPUType(zoneTypes, cpuTypes.Has)
+	if cpu.Type == "" {
+		return ""
+	}
+
+	value, err := cpu.ToString()
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
+
 func (p *DefaultProvider) instanceDelete(ctx context.Context,
 	nodeClaim *karpv1.NodeClaim,
 	region string,
@@ -263,5 +361,13 @@ func (p *DefaultProvider) instanceDelete(ctx context.Context,
 		log.Error(err, "Failed to release capacity after VM deletion", "vmID", vmr.VMID)
 	}
 
+	if err := removeCloudInitSnippets(ctx, int(vmr.VMID)); err != nil {
+		log.Error(err, "Failed to remove cloud-init snippets", "vmID", vmr.VMID)
+	}
+
+	if err := p.metadataProvider.Delete(ctx, nodeClaim.Name); err != nil {
+		log.Error(err, "Failed to delete instance metadata", "vmID", vmr.VMID)
+	}
+
 	return nil
 }
@@ -37,6 +37,7 @@ import (
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/locks"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
 type Provider interface {
@@ -85,6 +86,8 @@ type InstanceTemplateInfo struct {
 	TemplateTags []string
 	// TemplateStorage is the storage of boot disk for the template.
 	TemplateStorageID string
+	// TemplateCPU is the cpu option of the template, e.g. "x86-64-v2-AES,flags=+aes".
+	TemplateCPU string
 	// Status of the template, e.g. "available", "disabled", etc.
 	Status string
 }
@@ -147,9 +150,20 @@ func (p *DefaultProvider) Create(ctx context.Context, templateClass *v1alpha1.Pr
 			continue
 		}
 
-		zones := lo.Intersect(storageImage.Zones, storageTemplate.Zones)
+		// The template can be used only on the nodes with the same CPU architecture
+		zones := lo.Filter(lo.Intersect(storageImage.Zones, storageTemplate.Zones), func(zone string, _ int) bool {
+			info := p.cloudCapacityProvider.GetZoneInfo(region, zone)
+
+			return info != nil && lo.CoalesceOrEmpty(info.Architecture, karpv1.ArchitectureAmd64) == templateClass.GetArch()
+		})
+		if len(zones) == 0 {
+			log.Info("No zones found with the template architecture", "region", region, "arch", templateClass.GetArch())
+
+			continue
+		}
+
 		if storageTemplate.Shared {
-			zones = []string{storageTemplate.Zones[0]}
+			zones = zones[:1]
 		}
 
 		for _, zone := range zones {
@@ -364,6 +378,7 @@ func (p *DefaultProvider) SyncInstanceTemplates(ctx context.Context, regions ...
 
 			if vmRes.VirtualMachineConfig != nil {
 				info.TemplateTags = strings.Split(vmRes.VirtualMachineConfig.Tags, ";")
+				info.TemplateCPU = vmRes.VirtualMachineConfig.CPU
 				info.TemplateHash = fmt.Sprintf("%d-%d", vm.VMID, lo.Must(hashstructure.Hash(vmRes.VirtualMachineConfig.Meta, hashstructure.FormatV2, nil)))
 
 				if strings.Contains(vmRes.VirtualMachineConfig.Description, "Hash: ") {
@@ -204,7 +204,7 @@ func (p *DefaultProvider) createTemplate(
 		vm["tpmstate0"] = fmt.Sprintf("file=%s:4,version=%s", storageTemplate.Name, templateClass.Spec.TPM.Version)
 	}
 
-	if templateClass.Spec.Bios == "ovmf" {
+	if templateClass.GetBios() == "ovmf" {
 		vm["efidisk0"] = fmt.Sprintf("%s:0,efitype=4m", storageTemplate.Name)
 	}
 
@@ -350,13 +350,8 @@ func applyVirtualMachineTemplateConfig(templateClass *v1alpha1.ProxmoxTemplate,
 		vm["description"] = templateClass.Spec.Description + " Hash: " + templateClass.Hash()
 	}
 
-	if templateClass.Spec.Machine != "" {
-		vm["machine"] = templateClass.Spec.Machine
-	}
-
-	if templateClass.Spec.Bios != "" {
-		vm["bios"] = templateClass.Spec.Bios
-	}
+	vm["machine"] = templateClass.GetMachine()
+	vm["bios"] = templateClass.GetBios()
 
 	if templateClass.Spec.QemuGuestAgent != nil {
 		agent := goproxmox.VMQemuGuestAgent{
@@ -375,9 +370,9 @@ func applyVirtualMachineTemplateConfig(templateClass *v1alpha1.ProxmoxTemplate,
 		vm["agent"] = value
 	}
 
-	if templateClass.Spec.CPU != nil {
-		vm["cpu"] = fmt.Sprintf("cputype=%s", templateClass.Spec.CPU.Type)
+	vm["cpu"] = fmt.Sprintf("cputype=%s", templateClass.GetCPUType())
 
+	if templateClass.Spec.CPU != nil {
 		if len(templateClass.Spec.CPU.Flags) > 0 {
 			flags := strings.Join(templateClass.Spec.CPU.Flags, ",")
 			vm["cpu"] = fmt.Sprintf("%s,flags=%s", vm["cpu"], flags)
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/cloudcapacity"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -68,9 +70,98 @@ func NewDefaultProvider(ctx context.Context, cloudCapacityProvider cloudcapacity
 }
 
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1alpha1.ProxmoxNodeClass) ([]*cloudprovider.InstanceType, error) {
-	return p.ListWithFilter(ctx, func(_ *cloudprovider.InstanceType) bool {
+	instanceTypes := p.ListWithFilter(ctx, func(_ *cloudprovider.InstanceType) bool {
 		return true
-	}), nil
+	})
+
+	if nodeClass != nil && nodeClass.Spec.MemoryBalloon != nil {
+		for _, instanceType := range instanceTypes {
+			reserveBalloonMemory(instanceType, nodeClass.Spec.MemoryBalloon)
+		}
+	}
+
+	if nodeClass != nil && (nodeClass.Spec.Overcommit != nil || nodeClass.Spec.MemoryBalloon != nil) {
+		for _, instanceType := range instanceTypes {
+			p.fitOfferings(instanceType, nodeClass)
+		}
+	}
+
+	if archs := p.nodeClassArchitectures(nodeClass); len(archs) > 0 {
+		for _, instanceType := range instanceTypes {
+			restrictArchitectures(instanceType, archs)
+		}
+	}
+
+	return instanceTypes, nil
+}
+
+// nodeClassArchitectures returns the CPU architectures of the zones where the node class templates are available.
+func (p *DefaultProvider) nodeClassArchitectures(nodeClass *v1alpha1.ProxmoxNodeClass) []string {
+	if nodeClass == nil {
+		return nil
+	}
+
+	archs := []string{}
+
+	for _, region := range p.cloudCapacityProvider.Regions() {
+		for _, zone := range nodeClass.GetZones(region) {
+			if info := p.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil && info.Architecture != "" {
+				archs = append(archs, info.Architecture)
+			}
+		}
+	}
+
+	return lo.Uniq(archs)
+}
+
+// fitOfferings updates the availability of the offerings with the overcommit ratios and the balloon memory
+// of the node class, the node class can lower the host overcommit and commits only the balloon minimum.
+func (p *DefaultProvider) fitOfferings(instanceType *cloudprovider.InstanceType, nodeClass *v1alpha1.ProxmoxNodeClass) {
+	req := cloudcapacity.NodeClassResources(instanceType.Capacity, nodeClass)
+
+	for _, offering := range instanceType.Offerings {
+		region := offering.Requirements.Get(corev1.LabelTopologyRegion).Any()
+		zone := offering.Requirements.Get(corev1.LabelTopologyZone).Any()
+
+		offering.Available = p.cloudCapacityProvider.FitInZone(region, zone, req)
+	}
+
+	instanceType.Requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn,
+		lo.Map(instanceType.Offerings.Available(), func(o *cloudprovider.Offering, _ int) string {
+			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
+		})...)
+}
+
+// restrictArchitectures marks the offerings of other architectures as unavailable,
+// the node class cannot launch them because it has no templates in these zones.
+func restrictArchitectures(instanceType *cloudprovider.InstanceType, archs []string) {
+	for _, offering := range instanceType.Offerings {
+		if !lo.ContainsBy(archs, offering.Requirements.Get(corev1.LabelArchStable).Has) {
+			offering.Available = false
+		}
+	}
+
+	instanceType.Requirements[corev1.LabelArchStable] = scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn,
+		lo.Intersect(archs, offeringArchitectures(instanceType.Offerings))...)
+}
+
+// reserveBalloonMemory reserves the memory above the balloon minimum for the system,
+// so the kubelet publishes only the guaranteed memory as allocatable.
+func reserveBalloonMemory(instanceType *cloudprovider.InstanceType, balloon *v1alpha1.MemoryBalloon) {
+	memory := uint64(instanceType.Capacity.Memory().Value())
+
+	minMemory := balloon.GetMinMemory(memory)
+	if minMemory == 0 || minMemory >= memory {
+		return
+	}
+
+	if instanceType.Overhead.SystemReserved == nil {
+		instanceType.Overhead.SystemReserved = corev1.ResourceList{}
+	}
+
+	reserved := instanceType.Overhead.SystemReserved.Memory().DeepCopy()
+	reserved.Add(*resource.NewQuantity(int64(memory-minMemory), resource.BinarySI))
+	instanceType.Overhead.SystemReserved[corev1.ResourceMemory] = reserved
 }
 
 func (p *DefaultProvider) ListWithFilter(ctx context.Context, filter func(*cloudprovider.InstanceType) bool) []*cloudprovider.InstanceType {
@@ -175,20 +266,47 @@ func (p *DefaultProvider) createOfferings(opts *InstanceTypeStatic, regions []st
 
 	for _, region := range regions {
 		for _, zone := range p.cloudCapacityProvider.Zones(region) {
-			available := p.cloudCapacityProvider.FitInZone(region, zone, opts.Capacity)
+			available := p.cloudCapacityProvider.FitInZone(region, zone, cloudcapacity.NodeClassResources(opts.Capacity, nil))
+
+			arch := karpv1.ArchitectureAmd64
+			cpuTypes := []string{}
+			numaNodes := 0
+
+			if info := p.cloudCapacityProvider.GetZoneInfo(region, zone); info != nil {
+				arch = lo.CoalesceOrEmpty(info.Architecture, arch)
+				cpuTypes = info.CPUTypes
+				numaNodes = min(info.NUMANodes, int(opts.Capacity.Cpu().Value()))
+			}
 
 			// We use capacityType array to allow multiple capacity types per instance type in the future
 			for _, ct := range lo.Uniq([]string{capacityType}) {
+				requirements := scheduling.NewRequirements(
+					scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, opts.Name),
+					scheduling.NewRequirement(corev1.LabelTopologyRegion, corev1.NodeSelectorOpIn, region),
+					scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
+					scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, arch),
+					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, ct),
+					scheduling.NewRequirement(v1alpha1.LabelInstanceFamily, corev1.NodeSelectorOpIn, strings.Split(opts.Name, ".")[0]),
+				)
+
+				if len(cpuTypes) > 0 {
+					requirements.Add(scheduling.NewRequirement(v1alpha1.LabelInstanceCPUType, corev1.NodeSelectorOpIn, cpuTypes...))
+				}
+
+				// The VMs are bound to the host NUMA nodes only by the static policy
+				if numaNodes > 0 {
+					requirements.Add(
+						scheduling.NewRequirement(v1alpha1.LabelInstanceNUMANodes, corev1.NodeSelectorOpIn, lo.Map(lo.RangeFrom(1, numaNodes), func(n int, _ int) string {
+							return strconv.Itoa(n)
+						})...),
+						scheduling.NewRequirement(v1alpha1.LabelInstanceNUMAAligned, corev1.NodeSelectorOpIn, lo.Ternary(numaNodes > 1, []string{"true", "false"}, []string{"true"})...),
+					)
+				}
+
 				opts.Offerings = append(opts.Offerings, &cloudprovider.Offering{
-					Price:     lo.Ternary(ct == karpv1.CapacityTypeSpot, price*.5, price),
-					Available: available,
-					Requirements: scheduling.NewRequirements(
-						scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, opts.Name),
-						scheduling.NewRequirement(corev1.LabelTopologyRegion, corev1.NodeSelectorOpIn, region),
-						scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
-						scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, ct),
-						scheduling.NewRequirement(v1alpha1.LabelInstanceFamily, corev1.NodeSelectorOpIn, strings.Split(opts.Name, ".")[0]),
-					),
+					Price:        lo.Ternary(ct == karpv1.CapacityTypeSpot, price*.5, price),
+					Available:    available,
+					Requirements: requirements,
 				})
 			}
 		}
@@ -199,7 +317,7 @@ func computeRequirements(instanceTypeName string, offerings cloudprovider.Offeri
 	requirements := scheduling.NewRequirements(
 		// Well Known Upstream
 		scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, instanceTypeName),
-		scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, string(karpv1.ArchitectureAmd64)),
+		scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, offeringArchitectures(offerings)...),
 		scheduling.NewRequirement(corev1.LabelOSStable, corev1.NodeSelectorOpIn, string(corev1.Linux)),
 		scheduling.NewRequirement(corev1.LabelTopologyRegion, corev1.NodeSelectorOpIn, regions...),
 		// scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, lo.Map(offerings.Available(), func(o *cloudprovider.Offering, _ int) string {
@@ -213,13 +331,44 @@ func computeRequirements(instanceTypeName string, offerings cloudprovider.Offeri
 
 		// Well Known to Proxmox
 		scheduling.NewRequirement(v1alpha1.LabelInstanceFamily, corev1.NodeSelectorOpIn, strings.Split(instanceTypeName, ".")[0]),
-		scheduling.NewRequirement(v1alpha1.LabelInstanceCPUType, corev1.NodeSelectorOpDoesNotExist),
+		offeringRequirement(offerings, v1alpha1.LabelInstanceCPUType),
 		scheduling.NewRequirement(v1alpha1.LabelInstanceImageID, corev1.NodeSelectorOpDoesNotExist),
+		offeringRequirement(offerings, v1alpha1.LabelInstanceNUMANodes),
+		offeringRequirement(offerings, v1alpha1.LabelInstanceNUMAAligned),
 	)
 
 	return requirements
 }
 
+// offeringArchitectures returns the CPU architectures of the offerings, amd64 if the offerings are unknown.
+func offeringArchitectures(offerings cloudprovider.Offerings) []string {
+	archs := lo.Uniq(lo.FlatMap(offerings, func(o *cloudprovider.Offering, _ int) []string {
+		return o.Requirements.Get(corev1.LabelArchStable).Values()
+	}))
+	if len(archs) == 0 {
+		return []string{karpv1.ArchitectureAmd64}
+	}
+
+	return archs
+}
+
+// offeringRequirement returns the requirement of the label values which the zones of the offerings provide,
+// DoesNotExist if no zone sets the label.
+func offeringRequirement(offerings cloudprovider.Offerings, key string) *scheduling.Requirement {
+	values := lo.Uniq(lo.FlatMap(offerings, func(o *cloudprovider.Offering, _ int) []string {
+		if !o.Requirements.Has(key) {
+			return nil
+		}
+
+		return o.Requirements.Get(key).Values()
+	}))
+	if len(values) == 0 {
+		return scheduling.NewRequirement(key, corev1.NodeSelectorOpDoesNotExist)
+	}
+
+	return scheduling.NewRequirement(key, corev1.NodeSelectorOpIn, values...)
+}
+
 func loadInstanceTypesFromFile(name string) ([]*InstanceTypeStatic, error) {
 	data, err := os.ReadFile(name)
 	if err != nil {
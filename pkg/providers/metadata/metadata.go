@@ -0,0 +1,241 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/apis/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	secretPrefix = "karpenter-metadata-"
+
+	keyUserData      = "user-data"
+	keyMetaData      = "meta-data"
+	keyVendorData    = "vendor-data"
+	keyNetworkConfig = "network-config"
+	keyToken         = "token"
+
+	indexToken   = "token"
+	indexAddress = "address"
+
+	tokenSize = 16
+)
+
+// InstanceMetadata contains the rendered cloud-init data of the instance.
+type InstanceMetadata struct {
+	// Name is the NodeClaim name.
+	Name string
+	// UUID is the Proxmox VM UUID from smbios1.
+	UUID string
+	// Token is the secret of the instance in the datasource URL path.
+	Token string
+	// Addresses is the list of static IP addresses of the VM.
+	Addresses []string
+
+	UserData      string
+	MetaData      string
+	VendorData    string
+	NetworkConfig string
+}
+
+type Provider interface {
+	Set(ctx context.Context, data *InstanceMetadata) error
+	GetByToken(ctx context.Context, token string) (*InstanceMetadata, error)
+	GetByAddress(ctx context.Context, address string) (*InstanceMetadata, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// DefaultProvider keeps the instance metadata in secrets, so every controller replica can serve it.
+// The secrets are read from an informer cache indexed by the instance token and addresses.
+type DefaultProvider struct {
+	kubernetesInterface kubernetes.Interface
+	informer            cache.SharedIndexInformer
+}
+
+func NewProvider(
+	ctx context.Context,
+	kubernetesInterface kubernetes.Interface,
+) *DefaultProvider {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubernetesInterface, 0,
+		informers.WithNamespace(metav1.NamespaceSystem),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = v1alpha1.LabelInstanceMetadata + "=true"
+		}),
+	)
+
+	informer := factory.Core().V1().Secrets().Informer()
+	informer.AddIndexers(cache.Indexers{ //nolint:errcheck
+		indexToken:   tokenIndexFunc,
+		indexAddress: addressIndexFunc,
+	})
+
+	return &DefaultProvider{
+		kubernetesInterface: kubernetesInterface,
+		informer:            informer,
+	}
+}
+
+// NewToken returns a random secret of the instance.
+func NewToken() (string, error) {
+	b := make([]byte, tokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate instance token: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Start runs the informer of the metadata secrets until the context is done.
+func (p *DefaultProvider) Start(ctx context.Context) error {
+	p.informer.Run(ctx.Done())
+
+	return nil
+}
+
+// NeedLeaderElection allows to serve the metadata by all controller replicas.
+func (p *DefaultProvider) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *DefaultProvider) Set(ctx context.Context, data *InstanceMetadata) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretPrefix + data.Name,
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				v1alpha1.LabelInstanceMetadata: "true",
+				v1alpha1.LabelInstanceUUID:     strings.ToLower(data.UUID),
+			},
+			Annotations: map[string]string{
+				v1alpha1.AnnotationProxmoxInstanceAddresses: strings.Join(data.Addresses, ","),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			keyUserData:      []byte(data.UserData),
+			keyMetaData:      []byte(data.MetaData),
+			keyVendorData:    []byte(data.VendorData),
+			keyNetworkConfig: []byte(data.NetworkConfig),
+			keyToken:         []byte(data.Token),
+		},
+	}
+
+	_, err := p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store instance metadata %s: %w", data.Name, err)
+	}
+
+	return nil
+}
+
+func (p *DefaultProvider) GetByToken(ctx context.Context, token string) (*InstanceMetadata, error) {
+	if token == "" {
+		return nil, ErrInstanceMetadataNotFound
+	}
+
+	return p.getByIndex(indexToken, token)
+}
+
+func (p *DefaultProvider) GetByAddress(ctx context.Context, address string) (*InstanceMetadata, error) {
+	if address == "" {
+		return nil, ErrInstanceMetadataNotFound
+	}
+
+	return p.getByIndex(indexAddress, address)
+}
+
+func (p *DefaultProvider) Delete(ctx context.Context, name string) error {
+	if err := p.kubernetesInterface.CoreV1().Secrets(metav1.NamespaceSystem).Delete(ctx, secretPrefix+name, metav1.DeleteOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *DefaultProvider) getByIndex(index, value string) (*InstanceMetadata, error) {
+	if !p.informer.HasSynced() {
+		return nil, fmt.Errorf("instance metadata cache is not synced")
+	}
+
+	items, err := p.informer.GetIndexer().ByIndex(index, value)
+	if err != nil {
+		return nil, fmt.Errorf("looking up instance metadata: %w", err)
+	}
+
+	for _, item := range items {
+		if secret, ok := item.(*corev1.Secret); ok {
+			return secretToInstanceMetadata(secret), nil
+		}
+	}
+
+	return nil, ErrInstanceMetadataNotFound
+}
+
+func tokenIndexFunc(obj any) ([]string, error) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || len(secret.Data[keyToken]) == 0 {
+		return nil, nil
+	}
+
+	return []string{string(secret.Data[keyToken])}, nil
+}
+
+func addressIndexFunc(obj any) ([]string, error) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return nil, nil
+	}
+
+	addresses := strings.Split(secret.Annotations[v1alpha1.AnnotationProxmoxInstanceAddresses], ",")
+
+	return slices.DeleteFunc(addresses, func(a string) bool { return a == "" }), nil
+}
+
+func secretToInstanceMetadata(secret *corev1.Secret) *InstanceMetadata {
+	return &InstanceMetadata{
+		Name:          strings.TrimPrefix(secret.Name, secretPrefix),
+		UUID:          secret.Labels[v1alpha1.LabelInstanceUUID],
+		Token:         string(secret.Data[keyToken]),
+		Addresses:     strings.Split(secret.Annotations[v1alpha1.AnnotationProxmoxInstanceAddresses], ","),
+		UserData:      string(secret.Data[keyUserData]),
+		MetaData:      string(secret.Data[keyMetaData]),
+		VendorData:    string(secret.Data[keyVendorData]),
+		NetworkConfig: string(secret.Data[keyNetworkConfig]),
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// Server is the NoCloud HTTP metadata service.
+// The instance is identified by its secret token in the request path,
+// or by the source IP address which must match a static IP address of the VM.
+//
+// The VM UUID is not used as a key: it is readable by every Proxmox user with VM audit access
+// and does not prove the request comes from the VM, but the metadata contains the bootstrap token.
+// The random token is written to the SMBIOS serial next to the UUID, so it is known only to the VM.
+//
+// Cloud-init datasource configuration example, the token is set in the SMBIOS serial of the VM:
+//
+//	ds=nocloud;s=http://karpenter-metadata:8090/<token>/
+type Server struct {
+	address  string
+	provider Provider
+	log      logr.Logger
+}
+
+func NewServer(ctx context.Context, address string, provider Provider) *Server {
+	return &Server{
+		address:  address,
+		provider: provider,
+		log:      log.FromContext(ctx).WithName("metadata"),
+	}
+}
+
+// NeedLeaderElection allows to serve the metadata by all controller replicas.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the HTTP server until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.log.Error(err, "Failed to shutdown metadata service")
+		}
+	}()
+
+	s.log.Info("Starting metadata service", "address", s.address)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Handler returns the HTTP handler of the metadata service.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{key}", s.handleByAddress)
+	mux.HandleFunc("GET /{token}/{key}", s.handleByToken)
+
+	return mux
+}
+
+func (s *Server) handleByToken(w http.ResponseWriter, r *http.Request) {
+	data, err := s.provider.GetByToken(r.Context(), r.PathValue("token"))
+	s.serve(w, r, data, err)
+}
+
+func (s *Server) handleByAddress(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	data, err := s.provider.GetByAddress(r.Context(), host)
+	s.serve(w, r, data, err)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, data *InstanceMetadata, err error) {
+	if err != nil {
+		if !errors.Is(err, ErrInstanceMetadataNotFound) {
+			s.log.Error(err, "Failed to get instance metadata", "key", r.PathValue("key"), "remoteAddr", r.RemoteAddr)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		http.NotFound(w, r)
+
+		return
+	}
+
+	var content string
+
+	switch r.PathValue("key") {
+	case keyUserData:
+		content = data.UserData
+	case keyMetaData:
+		content = data.MetaData
+	case keyVendorData:
+		content = data.VendorData
+	case keyNetworkConfig:
+		content = data.NetworkConfig
+	}
+
+	if content == "" && r.PathValue("key") != keyVendorData {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	s.log.V(1).Info("Serving instance metadata", "nodeClaim", data.Name, "key", r.PathValue("key"), "remoteAddr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(content)) //nolint:errcheck
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/providers/metadata"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServer(t *testing.T) {
+	ctx := t.Context()
+
+	provider := metadata.NewProvider(ctx, fake.NewClientset())
+
+	go provider.Start(ctx) //nolint:errcheck
+
+	token, err := metadata.NewToken()
+	assert.NoError(t, err)
+	assert.Len(t, token, 32)
+
+	assert.NoError(t, provider.Set(ctx, &metadata.InstanceMetadata{
+		Name:      "node-1",
+		UUID:      "5D2B2C5E-7E0A-4B0E-9A53-3D1E6C0F3A11",
+		Token:     token,
+		Addresses: []string{"192.168.0.10", "fd00::10"},
+		UserData:  "#cloud-config\nhostname: node-1\n",
+		MetaData:  "instance-id: 100\n",
+	}))
+
+	assert.Eventually(t, func() bool {
+		_, err := provider.GetByToken(ctx, token)
+
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	handler := metadata.NewServer(ctx, "", provider).Handler()
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		code       int
+		body       string
+	}{
+		{
+			name:       "user-data by token",
+			path:       "/" + token + "/user-data",
+			remoteAddr: "10.0.0.1:41000",
+			code:       http.StatusOK,
+			body:       "#cloud-config\nhostname: node-1\n",
+		},
+		{
+			name:       "meta-data by address",
+			path:       "/meta-data",
+			remoteAddr: "192.168.0.10:41000",
+			code:       http.StatusOK,
+			body:       "instance-id: 100\n",
+		},
+		{
+			name:       "meta-data by ipv6 address",
+			path:       "/meta-data",
+			remoteAddr: "[fd00::10]:41000",
+			code:       http.StatusOK,
+			body:       "instance-id: 100\n",
+		},
+		{
+			name:       "empty vendor-data",
+			path:       "/vendor-data",
+			remoteAddr: "192.168.0.10:41000",
+			code:       http.StatusOK,
+		},
+		{
+			name:       "empty network-config",
+			path:       "/network-config",
+			remoteAddr: "192.168.0.10:41000",
+			code:       http.StatusNotFound,
+		},
+		{
+			name:       "unknown address",
+			path:       "/user-data",
+			remoteAddr: "192.168.0.11:41000",
+			code:       http.StatusNotFound,
+		},
+		{
+			name:       "uuid is not a token",
+			path:       "/5d2b2c5e-7e0a-4b0e-9a53-3d1e6c0f3a11/user-data",
+			remoteAddr: "192.168.0.10:41000",
+			code:       http.StatusNotFound,
+		},
+		{
+			name:       "unknown token",
+			path:       "/00000000000000000000000000000000/user-data",
+			remoteAddr: "192.168.0.10:41000",
+			code:       http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.code == http.StatusOK {
+				body, err := io.ReadAll(rec.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.body, string(body))
+			}
+		})
+	}
+
+	assert.NoError(t, provider.Delete(ctx, "node-1"))
+	assert.NoError(t, provider.Delete(ctx, "node-1"))
+
+	assert.Eventually(t, func() bool {
+		_, err := provider.GetByAddress(ctx, "192.168.0.10")
+
+		return errors.Is(err, metadata.ErrInstanceMetadataNotFound)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package resources
 
 import (
+	"strconv"
+
 	goproxmox "github.com/sergelogvinov/go-proxmox"
 
 	"k8s.io/utils/cpuset"
@@ -30,6 +32,10 @@ type VMResources struct {
 	Affinity string
 	// Memory is the amount of memory in bytes assigned to the VM.
 	Memory uint64
+	// MinMemory is the balloon minimum memory in bytes, zero means the balloon is disabled.
+	MinMemory uint64
+	// HugePageSize is the size in MiB of the host hugepages backing the memory, zero means no hugepages.
+	HugePageSize uint64
 	// DiskGBytes is the amount of system disk in gigabytes assigned to the VM.
 	DiskGBytes uint64
 	// StorageID is the ID of the storage where the VM's disk is located.
@@ -39,4 +45,40 @@ type VMResources struct {
 	CPUSet cpuset.CPUSet
 	// NUMANodes represents the topology on the Host assigned to the VM.
 	NUMANodes map[int]goproxmox.NUMANodeState
+	// MinNUMANodes and MaxNUMANodes limit the number of host NUMA nodes the VM is bound to, zero means no limit.
+	MinNUMANodes int
+	MaxNUMANodes int
+
+	// CPUOvercommitRatio is the maximum host CPU overcommit tolerated by the VM, 0 uses the host ratio.
+	CPUOvercommitRatio float64
+	// MemoryOvercommitRatio is the maximum host memory overcommit tolerated by the VM, 0 uses the host ratio.
+	MemoryOvercommitRatio float64
+}
+
+// CommittedMemory returns the memory in bytes which the host has to guarantee to the VM.
+func (r *VMResources) CommittedMemory() uint64 {
+	if r.MinMemory > 0 && r.MinMemory < r.Memory {
+		return r.MinMemory
+	}
+
+	return r.Memory
+}
+
+// ParseHugePageSize returns the size in MiB of the hugepages of the Proxmox hugepages option for the memory in bytes.
+// Proxmox uses 1GiB pages for the memory aligned to 1GiB and 2MiB pages otherwise if the option is any.
+func ParseHugePageSize(hugepages string, memory uint64) uint64 {
+	if hugepages == "any" {
+		if memory%(1024*1024*1024) == 0 {
+			return 1024
+		}
+
+		return 2
+	}
+
+	size, err := strconv.ParseUint(hugepages, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return size
 }
@@ -43,6 +43,14 @@ func GetResourceFromVM(vm *proxmox.VirtualMachine) (opt *resources.VMResources,
 	}
 
 	if vm.VirtualMachineConfig != nil {
+		if vm.VirtualMachineConfig.Balloon > 0 {
+			opt.MinMemory = uint64(vm.VirtualMachineConfig.Balloon) * 1024 * 1024
+		}
+
+		if vm.VirtualMachineConfig.Hugepages != "" {
+			opt.HugePageSize = resources.ParseHugePageSize(vm.VirtualMachineConfig.Hugepages, opt.Memory)
+		}
+
 		if vm.VirtualMachineConfig.Affinity != "" {
 			opt.Affinity = vm.VirtualMachineConfig.Affinity
 			opt.CPUSet, err = cpuset.Parse(vm.VirtualMachineConfig.Affinity)
@@ -120,6 +128,10 @@ func GenerateVMOptionsFromResources(res *resources.VMResources) (opts map[string
 		"memory": res.Memory / 1024 / 1024,
 	}
 
+	if res.MinMemory > 0 {
+		opts["balloon"] = res.MinMemory / 1024 / 1024
+	}
+
 	if !res.CPUSet.IsEmpty() {
 		opts["affinity"] = res.CPUSet.String()
 	}
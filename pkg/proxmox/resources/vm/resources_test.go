@@ -54,6 +54,24 @@ func TestGetResourceFromVM(t *testing.T) {
 				Memory: 8192 * 1024,
 			},
 		},
+		{
+			name: "VM with hugepages",
+			vm: &proxmox.VirtualMachine{
+				VMID:   100,
+				CPUs:   4,
+				MaxMem: 8 * 1024 * 1024 * 1024,
+				VirtualMachineConfig: &proxmox.VirtualMachineConfig{
+					Hugepages: "any",
+				},
+			},
+			expected: &resources.VMResources{
+				ID:           100,
+				CPUs:         4,
+				CPUSet:       cpuset.New(),
+				Memory:       8 * 1024 * 1024 * 1024,
+				HugePageSize: 1024,
+			},
+		},
 		{
 			name: "static VM",
 			vm: &proxmox.VirtualMachine{
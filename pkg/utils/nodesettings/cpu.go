@@ -19,6 +19,7 @@ package nodesettings
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/luthermonson/go-proxmox"
@@ -28,6 +29,9 @@ import (
 	"k8s.io/utils/cpuset"
 )
 
+// armModels are the substrings of the ARM64 server CPU models.
+var armModels = []string{"Neoverse", "Ampere", "Cortex", "Kunpeng", "ARM"}
+
 func GetNodeSettingByNode(n *proxmox.Node) (*settings.NodeSettings, error) {
 	if n == nil {
 		return nil, nil
@@ -44,6 +48,8 @@ func GetNodeSettingByNode(n *proxmox.Node) (*settings.NodeSettings, error) {
 		return nodeSettingsAMD(n)
 	case strings.Contains(n.CPUInfo.Model, "Intel"):
 		return nodeSettingsIntel(n)
+	case slices.ContainsFunc(armModels, func(m string) bool { return strings.Contains(n.CPUInfo.Model, m) }):
+		return nodeSettingsARM(n)
 	}
 
 	return nil, nil
@@ -206,3 +212,33 @@ func nodeSettingsIntel(n *proxmox.Node) (*settings.NodeSettings, error) {
 
 	return st, nil
 }
+
+// nodeSettingsARM assumes one NUMA node per socket, ARM server CPUs do not have SMT.
+func nodeSettingsARM(n *proxmox.Node) (*settings.NodeSettings, error) {
+	st := &settings.NodeSettings{
+		Arch:            "arm64",
+		NumCores:        n.CPUInfo.Cores,
+		NumSockets:      n.CPUInfo.Sockets,
+		NumThreads:      max(1, n.CPUInfo.CPUs/n.CPUInfo.Cores),
+		NumUncoreCaches: n.CPUInfo.Sockets,
+	}
+
+	nps := n.CPUInfo.Sockets
+
+	st.NUMANodes = make(map[int]settings.NUMAInfo, nps)
+	for i := range nps {
+		cpuPerNuma := n.CPUInfo.CPUs / nps
+
+		cpus, err := cpuset.Parse(fmt.Sprintf("%d-%d", i*cpuPerNuma, (i+1)*cpuPerNuma-1))
+		if err != nil {
+			return nil, fmt.Errorf("parsing cpus for numa node %d: %w", i, err)
+		}
+
+		st.NUMANodes[i] = settings.NUMAInfo{
+			CPUs:    cpus.String(),
+			MemSize: n.Memory.Total / uint64(nps),
+		}
+	}
+
+	return st, nil
+}
@@ -314,3 +314,87 @@ func TestNodeSettingsIntel(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeSettingsARM(t *testing.T) {
+	node := &proxmox.Node{
+		CPUInfo: proxmox.CPUInfo{
+			Model:   "160 x Neoverse-N1 (2 Socket)",
+			Sockets: 2,
+			Cores:   160,
+			CPUs:    160,
+		},
+		Memory: proxmox.Memory{
+			Total: 512 * 1024 * 1024 * 1024,
+		},
+	}
+
+	st, err := GetNodeSettingByNode(node)
+	assert.NoError(t, err)
+	assert.Equal(t, &settings.NodeSettings{
+		Arch:            "arm64",
+		NumCores:        160,
+		NumSockets:      2,
+		NumThreads:      1,
+		NumUncoreCaches: 2,
+		NUMANodes: settings.NUMANodes{
+			0: {
+				CPUs:    "0-79",
+				MemSize: 256 * 1024 * 1024 * 1024,
+			},
+			1: {
+				CPUs:    "80-159",
+				MemSize: 256 * 1024 * 1024 * 1024,
+			},
+		},
+	}, st)
+}
+
+func TestSupportedCPUTypes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		arch     string
+		flags    string
+		expected []string
+	}{
+		{
+			name:     "unknown flags",
+			arch:     "amd64",
+			expected: []string{"kvm64", "x86-64-v2", "x86-64-v2-AES", "x86-64-v3", "x86-64-v4", "host", "max"},
+		},
+		{
+			name:     "x86-64-v2 without aes",
+			arch:     "amd64",
+			flags:    "fpu cx16 lahf_lm popcnt pni sse4_1 sse4_2 ssse3 avx avx2",
+			expected: []string{"kvm64", "x86-64-v2", "host", "max"},
+		},
+		{
+			name:     "x86-64-v3",
+			arch:     "amd64",
+			flags:    "fpu cx16 lahf_lm popcnt pni sse4_1 sse4_2 ssse3 aes avx avx2 bmi1 bmi2 f16c fma abm movbe xsave",
+			expected: []string{"kvm64", "x86-64-v2", "x86-64-v2-AES", "x86-64-v3", "host", "max"},
+		},
+		{
+			name:     "arm64",
+			arch:     "arm64",
+			flags:    "fp asimd aes",
+			expected: []string{"host", "max"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, SupportedCPUTypes(tc.arch, tc.flags))
+		})
+	}
+}
+
+func TestPreferredCPUType(t *testing.T) {
+	types := []string{"kvm64", "x86-64-v2", "x86-64-v2-AES", "x86-64-v3", "host", "max"}
+
+	assert.Equal(t, "x86-64-v3", PreferredCPUType(types, func(string) bool { return true }))
+	assert.Equal(t, "x86-64-v2-AES", PreferredCPUType(types, func(t string) bool { return t != "x86-64-v3" }))
+	assert.Equal(t, "host", PreferredCPUType(types, func(t string) bool { return t == "host" || t == "max" }))
+	assert.Empty(t, PreferredCPUType(types, func(t string) bool { return t == "x86-64-v4" }))
+}
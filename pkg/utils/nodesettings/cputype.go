@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodesettings
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// cpuTypeFlags are the host CPU flags required by the x86-64 CPU types, each level includes the previous one.
+var cpuTypeFlags = []struct {
+	Type  string
+	Flags []string
+}{
+	{Type: "kvm64"},
+	{Type: "x86-64-v2", Flags: []string{"cx16", "lahf_lm", "popcnt", "pni", "sse4_1", "sse4_2", "ssse3"}},
+	{Type: "x86-64-v2-AES", Flags: []string{"aes"}},
+	{Type: "x86-64-v3", Flags: []string{"avx", "avx2", "bmi1", "bmi2", "f16c", "fma", "abm", "movbe", "xsave"}},
+	{Type: "x86-64-v4", Flags: []string{"avx512f", "avx512bw", "avx512cd", "avx512dq", "avx512vl"}},
+}
+
+// SupportedCPUTypes returns the emulated CPU types which the host can run, based on the host CPU flags.
+// All CPU types of the architecture are returned if the flags are unknown.
+func SupportedCPUTypes(arch string, flags string) []string {
+	if arch == karpv1.ArchitectureArm64 {
+		return []string{"host", "max"}
+	}
+
+	hostFlags := strings.Fields(flags)
+	types := []string{}
+
+	for _, t := range cpuTypeFlags {
+		if len(hostFlags) > 0 && !lo.Every(hostFlags, t.Flags) {
+			break
+		}
+
+		types = append(types, t.Type)
+	}
+
+	return append(types, "host", "max")
+}
+
+// PreferredCPUType returns the most capable CPU type accepted by the filter,
+// the x86-64 levels are preferred over the host passthrough.
+func PreferredCPUType(types []string, accept func(string) bool) string {
+	preferred := slices.Clone(types)
+	slices.Reverse(preferred)
+
+	for _, t := range []string{"host", "max"} {
+		if idx := slices.Index(preferred, t); idx != -1 {
+			preferred = append(slices.Delete(preferred, idx, idx+1), t)
+		}
+	}
+
+	for _, t := range preferred {
+		if accept(t) {
+			return t
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "proxmox_scheduler"
+	metricsSubsystem = "reconciler"
+
+	resultSuccess = "success"
+	resultRetry   = "retry"
+	resultFailed  = "failed"
+)
+
+var (
+	// EventsTotal counts the reconciled events by event type and result
+	EventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "events_total",
+			Help:      "Number of reconciled events by event type and result (success, retry, failed).",
+		},
+		[]string{"type", "result"},
+	)
+
+	// RetryQueueLength is the number of events waiting for a retry
+	RetryQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "retry_queue_length",
+			Help:      "Number of events waiting for a retry.",
+		},
+	)
+)
+
+// RegisterMetrics registers the reconciler metrics in the registry
+func RegisterMetrics(registry prometheus.Registerer) {
+	registry.MustRegister(EventsTotal, RetryQueueLength)
+}
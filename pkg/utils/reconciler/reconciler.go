@@ -79,7 +79,9 @@ type ReconcilerConfig struct {
 	BaseDelay  time.Duration
 	MaxDelay   time.Duration
 	WatchPath  string
-	SyncDelay  time.Duration
+	// ConfigPaths are the additional directories to watch, e.g. the directory of the configuration file
+	ConfigPaths []string
+	SyncDelay   time.Duration
 
 	Logger logr.Logger
 }
@@ -141,6 +143,15 @@ func (rf *Reconciler) Start() error {
 			return fmt.Errorf("failed to watch path %s: %w", rf.config.WatchPath, err)
 		}
 
+	}
+
+	for _, path := range rf.config.ConfigPaths {
+		if err := rf.watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch path %s: %w", path, err)
+		}
+	}
+
+	if rf.config.WatchPath != "" || len(rf.config.ConfigPaths) > 0 {
 		rf.wg.Add(1)
 		go rf.watchFiles()
 	}
@@ -288,12 +299,16 @@ func (rf *Reconciler) processRetries() {
 						eventQueue = append(eventQueue, *retry)
 					}
 
+					RetryQueueLength.Set(float64(len(eventQueue)))
+
 					continue
 				}
 
 				eventQueue = append(eventQueue, retryEvent)
 			}
 
+			RetryQueueLength.Set(float64(len(eventQueue)))
+
 		case <-retryTicker.C:
 			now := time.Now()
 			newQueue := eventQueue[:0]
@@ -310,6 +325,8 @@ func (rf *Reconciler) processRetries() {
 
 			eventQueue = newQueue
 
+			RetryQueueLength.Set(float64(len(eventQueue)))
+
 		case <-rf.ctx.Done():
 			return
 		}
@@ -327,6 +344,8 @@ func (rf *Reconciler) processRetryableEvent(retryEvent retryableEvent) *retryabl
 		delay := time.Duration(float64(rf.config.BaseDelay) * math.Pow(2, float64(retryEvent.attempts)))
 		delay = min(delay, rf.config.MaxDelay)
 
+		EventsTotal.WithLabelValues(string(retryEvent.event.Type), resultRetry).Inc()
+
 		rf.logger.Error(err, "Reconciliation failed, scheduling retry",
 			"attempt", retryEvent.attempts+1,
 			"maxRetries", rf.config.MaxRetries,
@@ -338,8 +357,12 @@ func (rf *Reconciler) processRetryableEvent(retryEvent retryableEvent) *retryabl
 			nextRetry: time.Now().Add(delay),
 		}
 	case err != nil:
+		EventsTotal.WithLabelValues(string(retryEvent.event.Type), resultFailed).Inc()
+
 		rf.logger.Error(err, "Reconciliation permanently failed", "attempts", retryEvent.attempts)
 	default:
+		EventsTotal.WithLabelValues(string(retryEvent.event.Type), resultSuccess).Inc()
+
 		rf.logger.V(4).Info("Reconciliation succeeded", "type", retryEvent.event.Type, "key", retryEvent.event.Key)
 	}
 
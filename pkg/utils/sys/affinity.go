@@ -17,54 +17,181 @@ limitations under the License.
 package sys
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"strconv"
-	"strings"
+
+	"go.uber.org/multierr"
+	"golang.org/x/sys/unix"
 
 	"k8s.io/utils/cpuset"
 )
 
-func PinThreadsToCores(ctx context.Context, vmID int, pid int, threads []int, cores []int) (err error) {
-	if len(threads) == 0 || len(cores) == 0 || pid <= 0 {
-		return nil
+// ThreadsLayout is the CPU placement of the VM threads and the VM cgroup
+type ThreadsLayout struct {
+	// Threads is the CPU affinity of each thread ID
+	Threads map[int]cpuset.CPUSet
+	// Cgroup is the cgroup path of the VM, relative to the cgroup root, empty to keep the cgroup as is
+	Cgroup string
+	// CPUs are the cpuset.cpus of the VM cgroup
+	CPUs cpuset.CPUSet
+	// Mems are the cpuset.mems of the VM cgroup, empty to keep the memory nodes as is
+	Mems cpuset.CPUSet
+}
+
+// Apply applies the layout, the previous affinity of the threads and the cgroup cpuset
+// are restored if any part of the layout cannot be applied.
+func (l *ThreadsLayout) Apply() (err error) {
+	cgroup := l.Cgroup
+	oldCPUs, oldMems := cpuset.New(), cpuset.New()
+
+	if cgroup != "" {
+		oldCPUs, oldMems, err = GetCgroupCPUSet(cgroup)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			// The cpuset controller is not enabled for the VM cgroup
+			cgroup = ""
+		}
 	}
 
-	if len(threads) != len(cores) {
-		return fmt.Errorf("VM %d: thread count %d does not match core count %d", vmID, len(threads), len(cores))
+	if cgroup != "" {
+		if err = SetCgroupCPUSet(cgroup, l.CPUs, l.Mems); err != nil {
+			return multierr.Append(err, SetCgroupCPUSet(cgroup, oldCPUs, oldMems))
+		}
 	}
 
-	return func() error {
-		defer func() {
-			// Revert the process affinity to all threads on exit
-			if err != nil {
-				strs := make([]string, len(cores))
-				for i, c := range cores {
-					strs[i] = strconv.Itoa(c)
-				}
-
-				cmd := exec.CommandContext(ctx, "taskset", "-pc", strings.Join(strs, ","), strconv.Itoa(pid))
-				if output, err := cmd.CombinedOutput(); err != nil {
-					fmt.Printf("VM %d: Failed to set process %d CPU affinity to cores %v: %v, output: %s\n", vmID, pid, strings.Join(strs, ","), err, output)
-				}
+	applied := map[int]cpuset.CPUSet{}
+
+	defer func() {
+		if err != nil {
+			if cgroup != "" {
+				err = multierr.Append(err, SetCgroupCPUSet(cgroup, oldCPUs, oldMems))
 			}
-		}()
 
-		var output []byte
+			for tid, cpus := range applied {
+				err = multierr.Append(err, SetThreadAffinity(tid, cpus))
+			}
+		}
+	}()
+
+	for tid, cpus := range l.Threads {
+		old, getErr := GetThreadAffinity(tid)
+		if getErr != nil {
+			if errors.Is(getErr, unix.ESRCH) {
+				continue
+			}
+
+			return getErr
+		}
+
+		if err = SetThreadAffinity(tid, cpus); err != nil {
+			if errors.Is(err, unix.ESRCH) {
+				err = nil
 
-		for i, threadID := range threads {
-			core := cores[i]
+				continue
+			}
+
+			return err
+		}
+
+		applied[tid] = old
+	}
+
+	return nil
+}
+
+// Verify returns an error if the current placement of the threads or the cgroup cpuset differs from the layout
+func (l *ThreadsLayout) Verify() error {
+	if l.Cgroup != "" {
+		cpus, mems, err := GetCgroupCPUSet(l.Cgroup)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err == nil {
+			if !cpus.Equals(l.CPUs) {
+				return fmt.Errorf("cgroup %s cpus %s, expected %s", l.Cgroup, cpus.String(), l.CPUs.String())
+			}
+
+			if !l.Mems.IsEmpty() && !mems.Equals(l.Mems) {
+				return fmt.Errorf("cgroup %s mems %s, expected %s", l.Cgroup, mems.String(), l.Mems.String())
+			}
+		}
+	}
 
-			cmd := exec.CommandContext(ctx, "taskset", "--cpu-list", "--pid", strconv.Itoa(core), strconv.Itoa(threadID))
-			if output, err = cmd.CombinedOutput(); err != nil {
-				return fmt.Errorf("VM %d: Failed to pin thread %d to core %d: %w, output: %s", vmID, threadID, core, err, output)
+	for tid, expected := range l.Threads {
+		cpus, err := GetThreadAffinity(tid)
+		if err != nil {
+			if errors.Is(err, unix.ESRCH) {
+				continue
 			}
+
+			return err
 		}
 
+		if !cpus.Equals(expected) {
+			return fmt.Errorf("thread %d affinity %s, expected %s", tid, cpus.String(), expected.String())
+		}
+	}
+
+	return nil
+}
+
+// SetThreadAffinity sets the CPU affinity of the thread
+func SetThreadAffinity(tid int, cpus cpuset.CPUSet) error {
+	var set unix.CPUSet
+
+	set.Zero()
+
+	for _, cpu := range cpus.UnsortedList() {
+		set.Set(cpu)
+	}
+
+	if err := unix.SchedSetaffinity(tid, &set); err != nil {
+		return fmt.Errorf("failed to set thread %d affinity to cores %s: %w", tid, cpus.String(), err)
+	}
+
+	return nil
+}
+
+// GetThreadAffinity returns the CPU affinity of the thread
+func GetThreadAffinity(tid int) (cpuset.CPUSet, error) {
+	var set unix.CPUSet
+
+	if err := unix.SchedGetaffinity(tid, &set); err != nil {
+		return cpuset.New(), fmt.Errorf("failed to get thread %d affinity: %w", tid, err)
+	}
+
+	cpus := make([]int, 0, set.Count())
+	for cpu := 0; len(cpus) < set.Count(); cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpuset.New(cpus...), nil
+}
+
+// SetThreadsAffinity sets the CPU affinity of the threads to the given CPUs, the exited threads are skipped
+func SetThreadsAffinity(vmID int, threads []int, cpus cpuset.CPUSet) error {
+	if len(threads) == 0 || cpus.IsEmpty() {
 		return nil
-	}()
+	}
+
+	for _, threadID := range threads {
+		if err := SetThreadAffinity(threadID, cpus); err != nil {
+			if errors.Is(err, unix.ESRCH) {
+				continue
+			}
+
+			return fmt.Errorf("VM %d: %w", vmID, err)
+		}
+	}
+
+	return nil
 }
 
 func SetPciIRQAffinity(vmID int, pciAddress string, irqs []int, cpus cpuset.CPUSet) error {
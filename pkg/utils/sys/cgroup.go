@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// CgroupRoot is the mount point of the cgroup v2 hierarchy
+	CgroupRoot = "/sys/fs/cgroup"
+)
+
+// VMCgroupPath returns the cgroup path of the Proxmox VM scope, relative to the cgroup root
+func VMCgroupPath(vmID int) string {
+	return filepath.Join("qemu.slice", fmt.Sprintf("%d.scope", vmID))
+}
+
+// GetCgroupCPUSet returns the configured cpuset.cpus and cpuset.mems of the cgroup, the path is relative to the cgroup root.
+// Empty sets mean the cgroup inherits the parent cpuset.
+// It returns os.ErrNotExist if the cgroup does not exist or the cpuset controller is not enabled.
+func GetCgroupCPUSet(path string) (cpus cpuset.CPUSet, mems cpuset.CPUSet, err error) {
+	if cpus, err = readCgroupSet(path, "cpuset.cpus"); err != nil {
+		return cpuset.New(), cpuset.New(), err
+	}
+
+	if mems, err = readCgroupSet(path, "cpuset.mems"); err != nil {
+		return cpuset.New(), cpuset.New(), err
+	}
+
+	return cpus, mems, nil
+}
+
+// SetCgroupCPUSet sets the cpuset.cpus and cpuset.mems of the cgroup, the path is relative to the cgroup root.
+// The empty mems are not changed.
+// It returns os.ErrNotExist if the cgroup does not exist or the cpuset controller is not enabled.
+func SetCgroupCPUSet(path string, cpus cpuset.CPUSet, mems cpuset.CPUSet) error {
+	if err := writeCgroupSet(path, "cpuset.cpus", cpus); err != nil {
+		return err
+	}
+
+	if !mems.IsEmpty() {
+		return writeCgroupSet(path, "cpuset.mems", mems)
+	}
+
+	return nil
+}
+
+func readCgroupSet(path, name string) (cpuset.CPUSet, error) {
+	data, err := os.ReadFile(filepath.Join(CgroupRoot, path, name))
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("failed to read cgroup %s %s: %w", path, name, err)
+	}
+
+	set, err := cpuset.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("failed to parse cgroup %s %s: %w", path, name, err)
+	}
+
+	return set, nil
+}
+
+func writeCgroupSet(path, name string, set cpuset.CPUSet) error {
+	f, err := os.OpenFile(filepath.Join(CgroupRoot, path, name), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open cgroup %s %s: %w", path, name, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if _, err := f.WriteString(set.String() + "\n"); err != nil {
+		return fmt.Errorf("failed to set cgroup %s %s to %s: %w", path, name, set.String(), err)
+	}
+
+	return nil
+}
@@ -19,10 +19,59 @@ package sys
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+// PciDevice represents a PCI device of the host.
+type PciDevice struct {
+	Address  string
+	Vendor   string
+	Device   string
+	Class    string
+	NUMANode int
+}
+
+// pciPassthroughClasses are the PCI base classes of the devices usually passed through to VMs:
+// network, display, processor and processing accelerators.
+var pciPassthroughClasses = []string{"0x02", "0x03", "0x0b", "0x12"}
+
+// GetPciDevices returns the PCI devices which can be passed through to VMs.
+func GetPciDevices() ([]PciDevice, error) {
+	entries, err := os.ReadDir("/sys/bus/pci/devices")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PCI devices: %w", err)
+	}
+
+	devices := []PciDevice{}
+
+	for _, entry := range entries {
+		path := filepath.Join("/sys/bus/pci/devices", entry.Name())
+
+		class := readSysValue(filepath.Join(path, "class"))
+		if !slices.ContainsFunc(pciPassthroughClasses, func(c string) bool { return strings.HasPrefix(class, c) }) {
+			continue
+		}
+
+		numaNode, err := strconv.Atoi(readSysValue(filepath.Join(path, "numa_node")))
+		if err != nil || numaNode < 0 {
+			numaNode = 0
+		}
+
+		devices = append(devices, PciDevice{
+			Address:  entry.Name(),
+			Vendor:   readSysValue(filepath.Join(path, "vendor")),
+			Device:   readSysValue(filepath.Join(path, "device")),
+			Class:    class,
+			NUMANode: numaNode,
+		})
+	}
+
+	return devices, nil
+}
+
 func GetPciDeviceIRQs(pciAddress string) ([]int, error) {
 	var irqs []int
 
@@ -61,3 +110,12 @@ func GetPciDeviceIRQs(pciAddress string) ([]int, error) {
 
 	return irqs, nil
 }
+
+func readSysValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.uber.org/multierr"
+
+	"k8s.io/utils/cpuset"
+)
+
+// netSysPath is the sysfs path of the network devices, it is a variable for tests
+var netSysPath = "/sys/class/net"
+
+// GetVMTapInterfaces returns the tap network devices of the VM, Proxmox names them tap<vmid>i<N>.
+func GetVMTapInterfaces(vmID int) ([]string, error) {
+	entries, err := os.ReadDir(netSysPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network devices: %w", err)
+	}
+
+	prefix := fmt.Sprintf("tap%di", vmID)
+
+	var taps []string
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix) {
+			taps = append(taps, entry.Name())
+		}
+	}
+
+	return taps, nil
+}
+
+// GetNetworkUplinks returns the physical network devices behind the network device.
+// It follows the bridge of the device, the lower devices of bridges, bonds and VLANs,
+// and the veth pairs of the Proxmox firewall bridges.
+func GetNetworkUplinks(iface string) []string {
+	uplinks := []string{}
+	visited := map[string]bool{iface: true}
+
+	queue := []string{}
+	if master := networkMaster(iface); master != "" {
+		queue = append(queue, master)
+	}
+
+	for len(queue) > 0 {
+		dev := queue[0]
+		queue = queue[1:]
+
+		if visited[dev] {
+			continue
+		}
+
+		visited[dev] = true
+
+		if isPhysicalNetworkDevice(dev) {
+			uplinks = append(uplinks, dev)
+
+			continue
+		}
+
+		lowers := networkLowers(dev)
+		if len(lowers) > 0 {
+			queue = append(queue, lowers...)
+
+			continue
+		}
+
+		// The veth device of the firewall bridge, the peer is a port of the VM bridge
+		if peer := networkPeer(dev); peer != "" && !visited[peer] {
+			visited[peer] = true
+
+			if master := networkMaster(peer); master != "" {
+				queue = append(queue, master)
+			}
+		}
+	}
+
+	slices.Sort(uplinks)
+
+	return uplinks
+}
+
+// NetworkSteering sets the receive packet steering (RPS), transmit packet steering (XPS)
+// and the IRQ affinity of the network devices and keeps their original values.
+type NetworkSteering struct {
+	// original maps the network device to the original values of its sysfs and procfs files
+	original map[string]map[string]string
+}
+
+// NewNetworkSteering returns a new NetworkSteering
+func NewNetworkSteering() *NetworkSteering {
+	return &NetworkSteering{
+		original: map[string]map[string]string{},
+	}
+}
+
+// Devices returns the steered network devices
+func (s *NetworkSteering) Devices() []string {
+	devices := make([]string, 0, len(s.original))
+	for dev := range s.original {
+		devices = append(devices, dev)
+	}
+
+	slices.Sort(devices)
+
+	return devices
+}
+
+// Steer sets RPS of the receive queues and the IRQ affinity of the network device to cpus,
+// the CPUs are spread over the transmit queues by XPS.
+func (s *NetworkSteering) Steer(iface string, cpus cpuset.CPUSet) error {
+	if cpus.IsEmpty() {
+		return nil
+	}
+
+	queuesPath := filepath.Join(netSysPath, iface, "queues")
+
+	rxQueues, err := filepath.Glob(filepath.Join(queuesPath, "rx-*", "rps_cpus"))
+	if err != nil {
+		return err
+	}
+
+	txQueues, err := filepath.Glob(filepath.Join(queuesPath, "tx-*", "xps_cpus"))
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(txQueues, func(a, b string) int { return queueIndex(a) - queueIndex(b) })
+
+	mask := cpuMask(cpus)
+	errs := []error{}
+
+	for _, rxQueue := range rxQueues {
+		errs = append(errs, s.set(iface, rxQueue, mask))
+	}
+
+	cpuList := cpus.List()
+
+	for i, txQueue := range txQueues {
+		txCPUs := []int{}
+		for j := i % len(cpuList); j < len(cpuList); j += len(txQueues) {
+			txCPUs = append(txCPUs, cpuList[j])
+		}
+
+		errs = append(errs, s.set(iface, txQueue, cpuMask(cpuset.New(txCPUs...))))
+	}
+
+	for _, irq := range networkDeviceIRQs(iface) {
+		errs = append(errs, s.set(iface, fmt.Sprintf("/proc/irq/%d/smp_affinity_list", irq), cpus.String()))
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return fmt.Errorf("failed to set steering of network device %s: %w", iface, err)
+	}
+
+	return nil
+}
+
+// Restore writes back the original values of the network device,
+// the files of the removed devices are skipped.
+func (s *NetworkSteering) Restore(iface string) error {
+	original, ok := s.original[iface]
+	if !ok {
+		return nil
+	}
+
+	delete(s.original, iface)
+
+	errs := []error{}
+
+	for path, value := range original {
+		if err := writeSysValue(path, value); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return fmt.Errorf("failed to restore steering of network device %s: %w", iface, err)
+	}
+
+	return nil
+}
+
+func (s *NetworkSteering) set(iface, path, value string) error {
+	if _, ok := s.original[iface][path]; !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if s.original[iface] == nil {
+			s.original[iface] = map[string]string{}
+		}
+
+		s.original[iface][path] = strings.TrimSpace(string(data))
+	}
+
+	return writeSysValue(path, value)
+}
+
+// cpuMask returns the CPU mask in the format of the rps_cpus and xps_cpus files,
+// comma-separated 32-bit hex words with the highest CPUs first.
+func cpuMask(cpus cpuset.CPUSet) string {
+	if cpus.IsEmpty() {
+		return "0"
+	}
+
+	words := make([]uint32, cpus.List()[cpus.Size()-1]/32+1)
+	for _, cpu := range cpus.List() {
+		words[cpu/32] |= 1 << (cpu % 32)
+	}
+
+	parts := make([]string, 0, len(words))
+	for i := len(words) - 1; i >= 0; i-- {
+		parts = append(parts, fmt.Sprintf("%08x", words[i]))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func queueIndex(path string) int {
+	_, idx, _ := strings.Cut(filepath.Base(filepath.Dir(path)), "-")
+
+	i, err := strconv.Atoi(idx)
+	if err != nil {
+		return 0
+	}
+
+	return i
+}
+
+func networkMaster(iface string) string {
+	link, err := os.Readlink(filepath.Join(netSysPath, iface, "master"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(link)
+}
+
+func networkLowers(iface string) []string {
+	entries, err := os.ReadDir(filepath.Join(netSysPath, iface))
+	if err != nil {
+		return nil
+	}
+
+	var lowers []string
+
+	for _, entry := range entries {
+		if lower, ok := strings.CutPrefix(entry.Name(), "lower_"); ok {
+			lowers = append(lowers, lower)
+		}
+	}
+
+	return lowers
+}
+
+// networkPeer returns the peer of the veth device
+func networkPeer(iface string) string {
+	ifindex := readSysValue(filepath.Join(netSysPath, iface, "ifindex"))
+	iflink := readSysValue(filepath.Join(netSysPath, iface, "iflink"))
+
+	if iflink == "" || iflink == ifindex {
+		return ""
+	}
+
+	entries, err := os.ReadDir(netSysPath)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if readSysValue(filepath.Join(netSysPath, entry.Name(), "ifindex")) == iflink {
+			return entry.Name()
+		}
+	}
+
+	return ""
+}
+
+func isPhysicalNetworkDevice(iface string) bool {
+	_, err := os.Stat(filepath.Join(netSysPath, iface, "device"))
+
+	return err == nil
+}
+
+// networkDeviceIRQs returns the IRQs of the PCI device of the network device
+func networkDeviceIRQs(iface string) []int {
+	link, err := os.Readlink(filepath.Join(netSysPath, iface, "device"))
+	if err != nil {
+		return nil
+	}
+
+	irqs, err := GetPciDeviceIRQs(filepath.Base(link))
+	if err != nil {
+		return nil
+	}
+
+	slices.Sort(irqs)
+
+	return slices.Compact(irqs)
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/utils/cpuset"
+)
+
+func writeTestFile(t *testing.T, path, value string) {
+	t.Helper()
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte(value+"\n"), 0o600))
+}
+
+func testNetSysPath(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	orig := netSysPath
+	netSysPath = root
+
+	t.Cleanup(func() { netSysPath = orig })
+
+	return root
+}
+
+func TestCPUMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpus     cpuset.CPUSet
+		expected string
+	}{
+		{
+			name:     "empty",
+			cpus:     cpuset.New(),
+			expected: "0",
+		},
+		{
+			name:     "first-word",
+			cpus:     cpuset.New(0, 1, 31),
+			expected: "80000003",
+		},
+		{
+			name:     "above-31",
+			cpus:     cpuset.New(32),
+			expected: "00000001,00000000",
+		},
+		{
+			name:     "both-words",
+			cpus:     cpuset.New(0, 31, 32, 63),
+			expected: "80000001,80000001",
+		},
+		{
+			name:     "above-63",
+			cpus:     cpuset.New(64),
+			expected: "00000001,00000000,00000000",
+		},
+		{
+			name:     "all-words",
+			cpus:     cpuset.New(4, 63, 95),
+			expected: "80000000,80000000,00000010",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cpuMask(tt.cpus))
+		})
+	}
+}
+
+func TestQueueIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{
+			name:     "rx",
+			path:     "/sys/class/net/eth0/queues/rx-0/rps_cpus",
+			expected: 0,
+		},
+		{
+			name:     "tx",
+			path:     "/sys/class/net/eth0/queues/tx-3/xps_cpus",
+			expected: 3,
+		},
+		{
+			name:     "two-digits",
+			path:     "/sys/class/net/eth0/queues/tx-12/xps_cpus",
+			expected: 12,
+		},
+		{
+			name:     "invalid",
+			path:     "/sys/class/net/eth0/queues/xps_cpus",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, queueIndex(tt.path))
+		})
+	}
+}
+
+func TestNetworkSteeringSteer(t *testing.T) {
+	const original = "ffffffff"
+
+	tests := []struct {
+		name     string
+		rxQueues int
+		txQueues int
+		cpus     cpuset.CPUSet
+		expected map[string]string
+	}{
+		{
+			name:     "empty-cpus",
+			rxQueues: 1,
+			txQueues: 1,
+			cpus:     cpuset.New(),
+			expected: map[string]string{
+				"rx-0/rps_cpus": original,
+				"tx-0/xps_cpus": original,
+			},
+		},
+		{
+			name:     "less-tx-queues",
+			rxQueues: 2,
+			txQueues: 2,
+			cpus:     cpuset.New(0, 1, 2, 3, 4),
+			expected: map[string]string{
+				"rx-0/rps_cpus": "0000001f",
+				"rx-1/rps_cpus": "0000001f",
+				"tx-0/xps_cpus": "00000015",
+				"tx-1/xps_cpus": "0000000a",
+			},
+		},
+		{
+			name:     "more-tx-queues",
+			rxQueues: 1,
+			txQueues: 4,
+			cpus:     cpuset.New(2, 3),
+			expected: map[string]string{
+				"rx-0/rps_cpus": "0000000c",
+				"tx-0/xps_cpus": "00000004",
+				"tx-1/xps_cpus": "00000008",
+				"tx-2/xps_cpus": "00000004",
+				"tx-3/xps_cpus": "00000008",
+			},
+		},
+		{
+			name:     "queue-order",
+			rxQueues: 1,
+			txQueues: 11,
+			cpus:     cpuset.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+			expected: map[string]string{
+				"rx-0/rps_cpus":  "000007ff",
+				"tx-0/xps_cpus":  "00000001",
+				"tx-1/xps_cpus":  "00000002",
+				"tx-2/xps_cpus":  "00000004",
+				"tx-9/xps_cpus":  "00000200",
+				"tx-10/xps_cpus": "00000400",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queuesPath := filepath.Join(testNetSysPath(t), "tap100i0", "queues")
+
+			for i := range tt.rxQueues {
+				writeTestFile(t, filepath.Join(queuesPath, "rx-"+strconv.Itoa(i), "rps_cpus"), original)
+			}
+
+			for i := range tt.txQueues {
+				writeTestFile(t, filepath.Join(queuesPath, "tx-"+strconv.Itoa(i), "xps_cpus"), original)
+			}
+
+			steering := NewNetworkSteering()
+			assert.NoError(t, steering.Steer("tap100i0", tt.cpus))
+
+			for path, value := range tt.expected {
+				assert.Equal(t, value, readSysValue(filepath.Join(queuesPath, path)), path)
+			}
+
+			assert.NoError(t, steering.Restore("tap100i0"))
+			assert.Empty(t, steering.Devices())
+
+			for path := range tt.expected {
+				assert.Equal(t, original, readSysValue(filepath.Join(queuesPath, path)), path)
+			}
+		})
+	}
+}
+
+func TestGetNetworkUplinks(t *testing.T) {
+	root := testNetSysPath(t)
+
+	for _, dev := range []string{"eno1", "eno2", "eno3"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dev, "device"), 0o755))
+	}
+
+	for dev, lowers := range map[string][]string{
+		"bond0":     {"eno1", "eno2"},
+		"vmbr0":     {"bond0", "tap100i0", "fwpr101p0"},
+		"vmbr1":     {"eno3", "tap102i0"},
+		"fwbr101i0": {"tap101i0", "fwln101i0"},
+	} {
+		for _, lower := range lowers {
+			assert.NoError(t, os.MkdirAll(filepath.Join(root, dev, "lower_"+lower), 0o755))
+		}
+	}
+
+	for dev, master := range map[string]string{
+		"tap100i0":  "vmbr0",
+		"tap101i0":  "fwbr101i0",
+		"fwpr101p0": "vmbr0",
+		"tap102i0":  "vmbr1",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dev), 0o755))
+		assert.NoError(t, os.Symlink("../"+master, filepath.Join(root, dev, "master")))
+	}
+
+	// The veth pair of the Proxmox firewall bridge
+	writeTestFile(t, filepath.Join(root, "fwln101i0", "ifindex"), "20")
+	writeTestFile(t, filepath.Join(root, "fwln101i0", "iflink"), "21")
+	writeTestFile(t, filepath.Join(root, "fwpr101p0", "ifindex"), "21")
+	writeTestFile(t, filepath.Join(root, "fwpr101p0", "iflink"), "20")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "tap103i0"), 0o755))
+
+	tests := []struct {
+		name     string
+		iface    string
+		expected []string
+	}{
+		{
+			name:     "bridge-bond",
+			iface:    "tap100i0",
+			expected: []string{"eno1", "eno2"},
+		},
+		{
+			name:     "firewall-bridge",
+			iface:    "tap101i0",
+			expected: []string{"eno1", "eno2"},
+		},
+		{
+			name:     "bridge",
+			iface:    "tap102i0",
+			expected: []string{"eno3"},
+		},
+		{
+			name:     "no-bridge",
+			iface:    "tap103i0",
+			expected: []string{},
+		},
+		{
+			name:     "not-found",
+			iface:    "tap104i0",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GetNetworkUplinks(tt.iface))
+		})
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/multierr"
+)
+
+const uncoreSysPath = "/sys/devices/system/cpu/intel_uncore_frequency"
+
+// cpuSysPath is the sysfs path of the CPUs, it is a variable for tests
+var cpuSysPath = "/sys/devices/system/cpu"
+
+// SetCPUEnergyPerformancePreference sets the energy performance preference (EPP) of intel_pstate or amd-pstate drivers,
+// e.g. performance, balance_performance, balance_power, power or default.
+// The CPUs without EPP support or with the performance governor are skipped.
+func SetCPUEnergyPerformancePreference(vmID int, cpus []int, epp string) error {
+	if len(cpus) == 0 || epp == "" {
+		return nil
+	}
+
+	for _, cpuID := range cpus {
+		eppFile := fmt.Sprintf("%s/cpu%d/cpufreq/energy_performance_preference", cpuSysPath, cpuID)
+
+		if err := writeSysValue(eppFile, epp); err != nil {
+			// The EPP cannot be changed with the performance governor
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.EBUSY) {
+				continue
+			}
+
+			return fmt.Errorf("failed to set energy performance preference for VM %d, CPU %d: %w", vmID, cpuID, err)
+		}
+	}
+
+	return nil
+}
+
+// SetCPUIdleMaxState disables the idle states (C-states) deeper than maxState,
+// all idle states are enabled if maxState is negative.
+func SetCPUIdleMaxState(vmID int, cpus []int, maxState int) error {
+	for _, cpuID := range cpus {
+		states, err := filepath.Glob(fmt.Sprintf("%s/cpu%d/cpuidle/state*", cpuSysPath, cpuID))
+		if err != nil {
+			return err
+		}
+
+		for _, state := range states {
+			id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(state), "state"))
+			if err != nil {
+				continue
+			}
+
+			disable := "0"
+			if maxState >= 0 && id > maxState {
+				disable = "1"
+			}
+
+			if err := writeSysValue(filepath.Join(state, "disable"), disable); err != nil {
+				return fmt.Errorf("failed to set idle state %d for VM %d, CPU %d: %w", id, vmID, cpuID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TurboBoost sets the turbo boost of the CPUs and keeps the original values.
+type TurboBoost struct {
+	// original maps the sysfs boost file to its original value
+	original map[string]string
+}
+
+// NewTurboBoost returns a new TurboBoost
+func NewTurboBoost() *TurboBoost {
+	return &TurboBoost{
+		original: map[string]string{},
+	}
+}
+
+// SetCPUs enables or disables the turbo boost of the CPUs with the per-CPU boost of amd-pstate driver.
+// It returns os.ErrNotExist if the driver does not support the per-CPU boost, see SetGlobal.
+func (b *TurboBoost) SetCPUs(cpus []int, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+
+	for _, cpuID := range cpus {
+		if err := b.set(fmt.Sprintf("%s/cpu%d/cpufreq/boost", cpuSysPath, cpuID), value); err != nil {
+			return fmt.Errorf("failed to set boost for CPU %d: %w", cpuID, err)
+		}
+	}
+
+	return nil
+}
+
+// RestoreCPUs writes back the original per-CPU boost of the CPUs.
+func (b *TurboBoost) RestoreCPUs(cpus []int) error {
+	errs := []error{}
+
+	for _, cpuID := range cpus {
+		errs = append(errs, b.restore(fmt.Sprintf("%s/cpu%d/cpufreq/boost", cpuSysPath, cpuID)))
+	}
+
+	if err := multierr.Combine(errs...); err != nil {
+		return fmt.Errorf("failed to restore boost of CPUs: %w", err)
+	}
+
+	return nil
+}
+
+// SetGlobal enables or disables the turbo boost of all CPUs
+func (b *TurboBoost) SetGlobal(enabled bool) error {
+	noTurbo, boost := "1", "0"
+	if enabled {
+		noTurbo, boost = "0", "1"
+	}
+
+	err := b.set(cpuSysPath+"/intel_pstate/no_turbo", noTurbo)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to set intel_pstate turbo: %w", err)
+	}
+
+	if err := b.set(cpuSysPath+"/cpufreq/boost", boost); err != nil {
+		return fmt.Errorf("failed to set cpufreq boost: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreGlobal writes back the original turbo boost of all CPUs.
+func (b *TurboBoost) RestoreGlobal() error {
+	if err := multierr.Combine(
+		b.restore(cpuSysPath+"/intel_pstate/no_turbo"),
+		b.restore(cpuSysPath+"/cpufreq/boost"),
+	); err != nil {
+		return fmt.Errorf("failed to restore turbo boost: %w", err)
+	}
+
+	return nil
+}
+
+func (b *TurboBoost) set(path, value string) error {
+	if _, ok := b.original[path]; !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		b.original[path] = strings.TrimSpace(string(data))
+	}
+
+	return writeSysValue(path, value)
+}
+
+func (b *TurboBoost) restore(path string) error {
+	value, ok := b.original[path]
+	if !ok {
+		return nil
+	}
+
+	delete(b.original, path)
+
+	if err := writeSysValue(path, value); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// SetUncoreMaxFrequency raises the minimum uncore frequency of the CPU package to the maximum,
+// or restores the initial minimum frequency.
+func SetUncoreMaxFrequency(packageID int, enabled bool) error {
+	dies, err := filepath.Glob(fmt.Sprintf("%s/package_%02d_die_*", uncoreSysPath, packageID))
+	if err != nil {
+		return err
+	}
+
+	if len(dies) == 0 {
+		return fmt.Errorf("uncore frequency of package %d: %w", packageID, os.ErrNotExist)
+	}
+
+	for _, die := range dies {
+		source := "initial_min_freq_khz"
+		if enabled {
+			source = "max_freq_khz"
+		}
+
+		freq := readSysValue(filepath.Join(die, source))
+		if freq == "" {
+			return fmt.Errorf("failed to read uncore frequency of %s", filepath.Base(die))
+		}
+
+		if err := writeSysValue(filepath.Join(die, "min_freq_khz"), freq); err != nil {
+			return fmt.Errorf("failed to set uncore frequency of %s: %w", filepath.Base(die), err)
+		}
+	}
+
+	return nil
+}
+
+func writeSysValue(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = f.WriteString(value + "\n")
+
+	return err
+}
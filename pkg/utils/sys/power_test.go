@@ -0,0 +1,207 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCPUSysPath(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	orig := cpuSysPath
+	cpuSysPath = root
+
+	t.Cleanup(func() { cpuSysPath = orig })
+
+	return root
+}
+
+func TestSetCPUIdleMaxState(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpus     []int
+		maxState int
+		initial  string
+		expected map[int][]string
+	}{
+		{
+			name:     "max-state",
+			cpus:     []int{0, 1},
+			maxState: 1,
+			initial:  "0",
+			expected: map[int][]string{
+				0: {"0", "0", "1", "1"},
+				1: {"0", "0", "1", "1"},
+				2: {"0", "0", "0", "0"},
+			},
+		},
+		{
+			name:     "polling-only",
+			cpus:     []int{2},
+			maxState: 0,
+			initial:  "0",
+			expected: map[int][]string{
+				0: {"0", "0", "0", "0"},
+				1: {"0", "0", "0", "0"},
+				2: {"0", "1", "1", "1"},
+			},
+		},
+		{
+			name:     "enable-all",
+			cpus:     []int{0, 1, 2},
+			maxState: -1,
+			initial:  "1",
+			expected: map[int][]string{
+				0: {"0", "0", "0", "0"},
+				1: {"0", "0", "0", "0"},
+				2: {"0", "0", "0", "0"},
+			},
+		},
+		{
+			name:     "without-idle-states",
+			cpus:     []int{3},
+			maxState: 1,
+			initial:  "0",
+			expected: map[int][]string{
+				0: {"0", "0", "0", "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := testCPUSysPath(t)
+
+			for cpu := range 3 {
+				for state := range 4 {
+					writeTestFile(t, filepath.Join(root, "cpu"+strconv.Itoa(cpu), "cpuidle", "state"+strconv.Itoa(state), "disable"), tt.initial)
+				}
+			}
+
+			assert.NoError(t, SetCPUIdleMaxState(100, tt.cpus, tt.maxState))
+
+			for cpu, states := range tt.expected {
+				for state, value := range states {
+					path := filepath.Join(root, "cpu"+strconv.Itoa(cpu), "cpuidle", "state"+strconv.Itoa(state), "disable")
+					assert.Equal(t, value, readSysValue(path), path)
+				}
+			}
+		})
+	}
+}
+
+func TestTurboBoostCPUs(t *testing.T) {
+	root := testCPUSysPath(t)
+
+	writeTestFile(t, filepath.Join(root, "cpu0", "cpufreq", "boost"), "1")
+	writeTestFile(t, filepath.Join(root, "cpu1", "cpufreq", "boost"), "0")
+
+	boost := NewTurboBoost()
+
+	assert.NoError(t, boost.SetCPUs([]int{0, 1}, false))
+	assert.Equal(t, "0", readSysValue(filepath.Join(root, "cpu0", "cpufreq", "boost")))
+
+	// The original value is kept from the first change
+	assert.NoError(t, boost.SetCPUs([]int{0, 1}, true))
+	assert.Equal(t, "1", readSysValue(filepath.Join(root, "cpu1", "cpufreq", "boost")))
+
+	assert.ErrorIs(t, boost.SetCPUs([]int{2}, true), os.ErrNotExist)
+
+	assert.NoError(t, boost.RestoreCPUs([]int{0, 1, 2}))
+	assert.Equal(t, "1", readSysValue(filepath.Join(root, "cpu0", "cpufreq", "boost")))
+	assert.Equal(t, "0", readSysValue(filepath.Join(root, "cpu1", "cpufreq", "boost")))
+	assert.Empty(t, boost.original)
+}
+
+func TestTurboBoostGlobal(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		enabled  bool
+		expected map[string]string
+	}{
+		{
+			name: "intel-pstate",
+			files: map[string]string{
+				"intel_pstate/no_turbo": "0",
+				"cpufreq/boost":         "1",
+			},
+			enabled: false,
+			expected: map[string]string{
+				"intel_pstate/no_turbo": "1",
+				"cpufreq/boost":         "1",
+			},
+		},
+		{
+			name: "intel-pstate-enable",
+			files: map[string]string{
+				"intel_pstate/no_turbo": "1",
+			},
+			enabled: true,
+			expected: map[string]string{
+				"intel_pstate/no_turbo": "0",
+			},
+		},
+		{
+			name: "cpufreq",
+			files: map[string]string{
+				"cpufreq/boost": "1",
+			},
+			enabled: false,
+			expected: map[string]string{
+				"cpufreq/boost": "0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := testCPUSysPath(t)
+
+			for path, value := range tt.files {
+				writeTestFile(t, filepath.Join(root, path), value)
+			}
+
+			boost := NewTurboBoost()
+			assert.NoError(t, boost.SetGlobal(tt.enabled))
+
+			for path, value := range tt.expected {
+				assert.Equal(t, value, readSysValue(filepath.Join(root, path)), path)
+			}
+
+			assert.NoError(t, boost.RestoreGlobal())
+
+			for path, value := range tt.files {
+				assert.Equal(t, value, readSysValue(filepath.Join(root, path)), path)
+			}
+		})
+	}
+
+	t.Run("not-supported", func(t *testing.T) {
+		testCPUSysPath(t)
+
+		assert.ErrorIs(t, NewTurboBoost().SetGlobal(false), os.ErrNotExist)
+	})
+}
@@ -109,3 +109,38 @@ func GetProcessThreads(pid int, filter string) ([]int, error) {
 
 	return threads, nil
 }
+
+// GetVhostThreads returns the vhost kernel worker threads of the given QEMU process.
+// Since Linux 6.4 the vhost workers are threads of the QEMU process itself, and they are returned by GetProcessThreads.
+func GetVhostThreads(pid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proc directory: %w", err)
+	}
+
+	name := fmt.Sprintf("vhost-%d", pid)
+
+	var threads []int
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil || tid <= 0 || tid == pid {
+			continue
+		}
+
+		commData, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", tid))
+		if err != nil {
+			continue
+		}
+
+		if strings.TrimSpace(string(commData)) == name {
+			threads = append(threads, tid)
+		}
+	}
+
+	return threads, nil
+}
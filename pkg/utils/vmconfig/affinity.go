@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmconfig
+
+import (
+	"slices"
+
+	"k8s.io/utils/cpuset"
+)
+
+const (
+	// AffinityConflictOverlap is the CPU affinity shared with other VMs
+	AffinityConflictOverlap = "overlap"
+	// AffinityConflictTopology is the CPU affinity out of the host CPUs
+	AffinityConflictTopology = "topology"
+)
+
+// AffinityConflict is the CPU affinity of the VM which cannot be used as is
+type AffinityConflict struct {
+	VMID     int
+	Reason   string
+	Affinity cpuset.CPUSet
+	// CPUs are the conflicting CPUs of the affinity
+	CPUs cpuset.CPUSet
+	// VMs are the VMs with the overlapping CPU affinity
+	VMs []int
+}
+
+// ValidateAffinity checks the CPU affinity of the VMs in the order, the first VM keeps the CPUs,
+// the next VMs with the same CPUs or with the CPUs out of the host CPUs are returned as conflicts.
+func ValidateAffinity(order []int, affinity map[int]cpuset.CPUSet, hostCPUs cpuset.CPUSet) []AffinityConflict {
+	conflicts := []AffinityConflict{}
+	owners := map[int]int{}
+
+	for _, vmID := range order {
+		cpus, ok := affinity[vmID]
+		if !ok || cpus.IsEmpty() {
+			continue
+		}
+
+		if !hostCPUs.IsEmpty() && !cpus.IsSubsetOf(hostCPUs) {
+			conflicts = append(conflicts, AffinityConflict{
+				VMID:     vmID,
+				Reason:   AffinityConflictTopology,
+				Affinity: cpus,
+				CPUs:     cpus.Difference(hostCPUs),
+			})
+
+			continue
+		}
+
+		overlap := []int{}
+		vms := []int{}
+
+		for _, cpu := range cpus.List() {
+			owner, ok := owners[cpu]
+			if !ok {
+				continue
+			}
+
+			overlap = append(overlap, cpu)
+
+			if !slices.Contains(vms, owner) {
+				vms = append(vms, owner)
+			}
+		}
+
+		if len(overlap) > 0 {
+			conflicts = append(conflicts, AffinityConflict{
+				VMID:     vmID,
+				Reason:   AffinityConflictOverlap,
+				Affinity: cpus,
+				CPUs:     cpuset.New(overlap...),
+				VMs:      vms,
+			})
+
+			continue
+		}
+
+		for _, cpu := range cpus.List() {
+			owners[cpu] = vmID
+		}
+	}
+
+	return conflicts
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
+
+	"k8s.io/utils/cpuset"
+)
+
+func TestValidateAffinity(t *testing.T) {
+	hostCPUs := cpuset.New(0, 1, 2, 3, 4, 5, 6, 7)
+
+	testCases := []struct {
+		name     string
+		order    []int
+		affinity map[int]cpuset.CPUSet
+		expected []vmconfig.AffinityConflict
+	}{
+		{
+			name:     "no vms",
+			order:    []int{},
+			affinity: map[int]cpuset.CPUSet{},
+			expected: []vmconfig.AffinityConflict{},
+		},
+		{
+			name:  "no conflicts",
+			order: []int{100, 101, 102},
+			affinity: map[int]cpuset.CPUSet{
+				100: cpuset.New(0, 1),
+				101: cpuset.New(2, 3),
+				102: cpuset.New(),
+			},
+			expected: []vmconfig.AffinityConflict{},
+		},
+		{
+			name:  "overlap",
+			order: []int{100, 101, 102},
+			affinity: map[int]cpuset.CPUSet{
+				100: cpuset.New(0, 1),
+				101: cpuset.New(2, 3),
+				102: cpuset.New(1, 2, 4),
+			},
+			expected: []vmconfig.AffinityConflict{
+				{
+					VMID:     102,
+					Reason:   vmconfig.AffinityConflictOverlap,
+					Affinity: cpuset.New(1, 2, 4),
+					CPUs:     cpuset.New(1, 2),
+					VMs:      []int{100, 101},
+				},
+			},
+		},
+		{
+			name:  "conflicting vm does not own cpus",
+			order: []int{100, 101, 102},
+			affinity: map[int]cpuset.CPUSet{
+				100: cpuset.New(0, 1),
+				101: cpuset.New(1, 2),
+				102: cpuset.New(2, 3),
+			},
+			expected: []vmconfig.AffinityConflict{
+				{
+					VMID:     101,
+					Reason:   vmconfig.AffinityConflictOverlap,
+					Affinity: cpuset.New(1, 2),
+					CPUs:     cpuset.New(1),
+					VMs:      []int{100},
+				},
+			},
+		},
+		{
+			name:  "out of topology",
+			order: []int{100, 101},
+			affinity: map[int]cpuset.CPUSet{
+				100: cpuset.New(6, 7, 8, 9),
+				101: cpuset.New(6, 7),
+			},
+			expected: []vmconfig.AffinityConflict{
+				{
+					VMID:     100,
+					Reason:   vmconfig.AffinityConflictTopology,
+					Affinity: cpuset.New(6, 7, 8, 9),
+					CPUs:     cpuset.New(8, 9),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conflicts := vmconfig.ValidateAffinity(tc.order, tc.affinity, hostCPUs)
+			assert.Equal(t, tc.expected, conflicts)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmconfig
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var descriptionAffinityRe = regexp.MustCompile(`affinity=\d+(?:-\d+)?(?:,\d+(?:-\d+)?)*`)
+
+// IsIncomingMigration returns true if the QEMU process was started as the target of a live migration
+func IsIncomingMigration(cmdlineArgs []string) bool {
+	return slices.Contains(cmdlineArgs, "-incoming")
+}
+
+// SetDescriptionAffinity returns the VM description with the CPU affinity replaced or appended
+func SetDescriptionAffinity(description, affinity string) string {
+	if descriptionAffinityRe.MatchString(description) {
+		return descriptionAffinityRe.ReplaceAllLiteralString(description, "affinity="+affinity)
+	}
+
+	if strings.TrimSpace(description) == "" {
+		return "affinity=" + affinity
+	}
+
+	return description + ", affinity=" + affinity
+}
+
+// PendingAffinity returns the CPU affinity from the [PENDING] section of the VM config file
+func PendingAffinity(config []byte) string {
+	pending := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			pending = line == "[PENDING]"
+
+			continue
+		}
+
+		if pending {
+			if affinity, ok := strings.CutPrefix(line, "affinity:"); ok {
+				return strings.TrimSpace(affinity)
+			}
+		}
+	}
+
+	return ""
+}
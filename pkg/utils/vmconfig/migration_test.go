@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sergelogvinov/karpenter-provider-proxmox/pkg/utils/vmconfig"
+)
+
+func TestIsIncomingMigration(t *testing.T) {
+	assert.False(t, vmconfig.IsIncomingMigration([]string{"/usr/bin/kvm", "-id", "100", "-smp", "4"}))
+	assert.True(t, vmconfig.IsIncomingMigration([]string{"/usr/bin/kvm", "-id", "100", "-incoming", "unix:/run/qemu-server/100.migrate", "-S"}))
+}
+
+func TestSetDescriptionAffinity(t *testing.T) {
+	testCases := []struct {
+		name        string
+		description string
+		affinity    string
+		expected    string
+	}{
+		{
+			name:        "empty description",
+			description: "",
+			affinity:    "0-3",
+			expected:    "affinity=0-3",
+		},
+		{
+			name:        "without affinity",
+			description: "Karpenter managed instance, class=default",
+			affinity:    "4-7",
+			expected:    "Karpenter managed instance, class=default, affinity=4-7",
+		},
+		{
+			name:        "replace affinity",
+			description: "Karpenter managed instance, class=default, capacity-type=on-demand, affinity=0-3,32-35",
+			affinity:    "8-11,40-43",
+			expected:    "Karpenter managed instance, class=default, capacity-type=on-demand, affinity=8-11,40-43",
+		},
+		{
+			name:        "replace affinity in the middle",
+			description: "Karpenter managed instance, affinity=0,2,4, class=default",
+			affinity:    "1,3,5",
+			expected:    "Karpenter managed instance, affinity=1,3,5, class=default",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, vmconfig.SetDescriptionAffinity(tc.description, tc.affinity))
+		})
+	}
+}
+
+func TestPendingAffinity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name:     "without pending",
+			config:   "affinity: 0-3\ncores: 4\nmemory: 4096\n",
+			expected: "",
+		},
+		{
+			name:     "pending affinity",
+			config:   "affinity: 0-3\ncores: 4\nmemory: 4096\n\n[PENDING]\naffinity: 8-11\nnuma0: cpus=0-3,hostnodes=1,memory=4096,policy=bind\n",
+			expected: "8-11",
+		},
+		{
+			name:     "affinity in snapshot",
+			config:   "affinity: 0-3\ncores: 4\n\n[PENDING]\nmemory: 8192\n\n[snap1]\naffinity: 4-7\n",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, vmconfig.PendingAffinity([]byte(tc.config)))
+		})
+	}
+}
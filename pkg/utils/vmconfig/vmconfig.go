@@ -18,6 +18,7 @@ package vmconfig
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/luthermonson/go-proxmox"
@@ -27,6 +28,8 @@ import (
 	"k8s.io/utils/cpuset"
 )
 
+const vmConfigPath = "/etc/pve/qemu-server/%d.conf"
+
 // LoadVMConfig loads the VM configuration for the given VM ID.
 func LoadVMConfig(vmID int) (*proxmox.VirtualMachineConfig, error) {
 	vm, err := goproxmox.GetLocalVMConfig(vmID)
@@ -34,6 +37,16 @@ func LoadVMConfig(vmID int) (*proxmox.VirtualMachineConfig, error) {
 		return nil, fmt.Errorf("failed to get VM config for VM %d: %w", vmID, err)
 	}
 
+	// The pending CPU affinity is set by the scheduler to the running VM,
+	// for example after a live migration, Proxmox applies it on the next start.
+	if data, err := os.ReadFile(fmt.Sprintf(vmConfigPath, vmID)); err == nil {
+		if affinity := PendingAffinity(data); affinity != "" {
+			if _, err := cpuset.Parse(affinity); err == nil {
+				vm.Affinity = affinity
+			}
+		}
+	}
+
 	if vm.Affinity == "" {
 		for part := range strings.SplitSeq(vm.Description, ",") {
 			if affinity, ok := strings.CutPrefix(strings.TrimSpace(part), "affinity="); ok {